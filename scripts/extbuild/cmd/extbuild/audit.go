@@ -0,0 +1,417 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/duckdb/extension-ci-tools/internal/objfile"
+	"github.com/duckdb/extension-ci-tools/internal/wasm"
+	"github.com/spf13/cobra"
+)
+
+func newAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect extension binaries for portability problems",
+	}
+	cmd.AddCommand(newAuditGLIBCCommand())
+	cmd.AddCommand(newAuditSymbolsCommand())
+	cmd.AddCommand(newAuditWasmCommand())
+	return cmd
+}
+
+// glibcAudit is the result of auditing one Linux extension binary.
+type glibcAudit struct {
+	Path       string `json:"path"`
+	DuckDBArch string `json:"duckdb_arch"`
+	objfile.LinuxDependencies
+	MaxGLIBCVersion   string `json:"max_glibc_version,omitempty"`
+	MaxGLIBCXXVersion string `json:"max_glibcxx_version,omitempty"`
+}
+
+// problems lists every requirement of the binary the target arch cannot meet.
+func (a glibcAudit) problems() []error {
+	var errs []error
+	if strings.HasSuffix(a.DuckDBArch, "_musl") {
+		if a.UsesGLIBC() {
+			errs = append(errs, fmt.Errorf("%s is a musl platform but the binary depends on glibc (%s)", a.DuckDBArch, a.glibcEvidence()))
+		}
+		return errs
+	}
+	for _, check := range []struct {
+		name    string
+		version *objfile.SymbolVersion
+		limit   string
+	}{
+		{name: "GLIBC", version: a.GLIBC, limit: a.MaxGLIBCVersion},
+		{name: "GLIBCXX", version: a.GLIBCXX, limit: a.MaxGLIBCXXVersion},
+	} {
+		if check.version == nil || check.limit == "" {
+			continue
+		}
+		if objfile.CompareVersions(check.version.Version, check.limit) > 0 {
+			errs = append(errs, fmt.Errorf("%s %s required by %s exceeds the limit %s for %s",
+				check.name, check.version.Version, strings.Join(check.version.Symbols, ", "), check.limit, a.DuckDBArch))
+		}
+	}
+	return errs
+}
+
+func (a glibcAudit) glibcEvidence() string {
+	if a.GLIBC != nil {
+		return fmt.Sprintf("GLIBC_%s required by %s", a.GLIBC.Version, strings.Join(a.GLIBC.Symbols, ", "))
+	}
+	return "links " + strings.Join(a.Libraries, ", ")
+}
+
+func renderGLIBCAuditText(w io.Writer, a glibcAudit) {
+	_, _ = fmt.Fprintf(w, "file:        %s\n", a.Path)
+	_, _ = fmt.Fprintf(w, "duckdb_arch: %s\n", a.DuckDBArch)
+	_, _ = fmt.Fprintf(w, "libraries:   %s\n", cmp.Or(strings.Join(a.Libraries, ", "), "none"))
+	for _, row := range []struct {
+		name    string
+		version *objfile.SymbolVersion
+		limit   string
+	}{
+		{name: "glibc:", version: a.GLIBC, limit: a.MaxGLIBCVersion},
+		{name: "glibcxx:", version: a.GLIBCXX, limit: a.MaxGLIBCXXVersion},
+	} {
+		if row.version == nil {
+			_, _ = fmt.Fprintf(w, "%-12s none\n", row.name)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%-12s %s (limit %s) required by %s\n",
+			row.name, row.version.Version, cmp.Or(row.limit, "none"), strings.Join(row.version.Symbols, ", "))
+	}
+}
+
+func newAuditGLIBCCommand() *cobra.Command {
+	var (
+		duckdbArch string
+		matrixPath string
+		maxGLIBC   string
+		maxGLIBCXX string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "glibc <extension-file>",
+		Short: "Check the GLIBC and GLIBCXX symbol versions a Linux extension binary requires",
+		Long: `Reports the highest GLIBC and GLIBCXX symbol versions the binary imports and
+fails when they exceed the max_glibc_version and max_glibcxx_version of the
+duckdb_arch in the distribution matrix. Binaries for _musl arches must not
+depend on glibc at all.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+
+			target, err := objfile.IdentifyFile(path)
+			if err != nil {
+				return fmt.Errorf("identify %q: %w", path, err)
+			}
+			if target.Format != objfile.FormatELF {
+				return fmt.Errorf("audit glibc: %q is a %s binary (must be ELF)", path, target)
+			}
+
+			if duckdbArch == "" {
+				info, err := metadata.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("read metadata of %q (or pass --duckdb-arch): %w", path, err)
+				}
+				duckdbArch = info.DuckDBPlatform
+			}
+
+			audit := glibcAudit{Path: path, DuckDBArch: duckdbArch}
+			if matrixPath != "" {
+				matrix, err := loadMatrixFile(matrixPath)
+				if err != nil {
+					return err
+				}
+				entry, ok := matrix.Entry(duckdbArch)
+				if !ok {
+					return fmt.Errorf("duckdb_arch %q is not in the distribution matrix", duckdbArch)
+				}
+				audit.MaxGLIBCVersion = entry.MaxGLIBCVersion
+				audit.MaxGLIBCXXVersion = entry.MaxGLIBCXXVersion
+			}
+			audit.MaxGLIBCVersion = cmp.Or(maxGLIBC, audit.MaxGLIBCVersion)
+			audit.MaxGLIBCXXVersion = cmp.Or(maxGLIBCXX, audit.MaxGLIBCXXVersion)
+
+			audit.LinuxDependencies, err = objfile.ReadLinuxDependencies(path)
+			if err != nil {
+				return fmt.Errorf("read dependencies of %q: %w", path, err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				renderGLIBCAuditText(out, audit)
+			case "json":
+				payload, err := json.MarshalIndent(audit, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render audit: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+
+			if errs := audit.problems(); len(errs) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("glibc audit of %q failed: %w", path, errors.Join(errs...))
+			}
+			commandLogger(cmd).Info("GLIBC symbol versions are within limits", "file", path, "duckdb_arch", duckdbArch)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "The duckdb_arch the binary is built for (default: the footer duckdb_platform)")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "", "Distribution matrix JSON file holding the per-arch limits")
+	cmd.Flags().StringVar(&maxGLIBC, "max-glibc", "", "Highest GLIBC version the binary may require (overrides the matrix)")
+	cmd.Flags().StringVar(&maxGLIBCXX, "max-glibcxx", "", "Highest GLIBCXX version the binary may require (overrides the matrix)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+
+	return cmd
+}
+
+// symbolsAudit is the result of checking the exports and needed libraries of
+// one extension binary.
+type symbolsAudit struct {
+	Path          string `json:"path"`
+	ExtensionName string `json:"extension_name"`
+	ABIType       string `json:"abi_type"`
+	Entrypoint    string `json:"entrypoint"`
+	objfile.Linkage
+}
+
+// extensionEntrypoint returns the init function DuckDB looks up when loading
+// an extension of the given ABI type.
+func extensionEntrypoint(name, abiType string) (string, error) {
+	switch abiType {
+	case metadata.ABITypeCStruct, metadata.ABITypeCStructUnstable:
+		return name + "_init_c_api", nil
+	case metadata.ABITypeCPP:
+		return name + "_duckdb_cpp_init", nil
+	default:
+		return "", fmt.Errorf("invalid ABI type %q (must be %s|%s|%s)", abiType, metadata.ABITypeCStruct, metadata.ABITypeCStructUnstable, metadata.ABITypeCPP)
+	}
+}
+
+// isDuckDBSymbol reports whether an exported symbol belongs to DuckDB itself:
+// a C API function or anything in the duckdb C++ namespace, mangled for the
+// Itanium or the MSVC ABI.
+func isDuckDBSymbol(name string) bool {
+	return strings.HasPrefix(name, "duckdb_") || strings.Contains(name, "6duckdb") || strings.Contains(name, "@duckdb@@")
+}
+
+// isDuckDBLibrary reports whether a needed library is a DuckDB shared library.
+func isDuckDBLibrary(library string) bool {
+	base := strings.ToLower(library[strings.LastIndexAny(library, `/\`)+1:])
+	return strings.HasPrefix(base, "libduckdb") || strings.HasPrefix(base, "duckdb.")
+}
+
+func (a symbolsAudit) problems() []error {
+	var errs []error
+	if !slices.Contains(a.Exports, a.Entrypoint) {
+		errs = append(errs, fmt.Errorf("%s entrypoint %s is not exported", a.ABIType, a.Entrypoint))
+	}
+	if a.ABIType != metadata.ABITypeCPP {
+		var leaked []string
+		for _, name := range a.Exports {
+			if name != a.Entrypoint && isDuckDBSymbol(name) {
+				leaked = append(leaked, name)
+			}
+		}
+		if len(leaked) > 0 {
+			errs = append(errs, fmt.Errorf("%s extension exports DuckDB symbols: %s", a.ABIType, strings.Join(leaked, ", ")))
+		}
+	}
+	for _, library := range a.Libraries {
+		if isDuckDBLibrary(library) {
+			errs = append(errs, fmt.Errorf("extension links the DuckDB library %s dynamically", library))
+		}
+	}
+	return errs
+}
+
+func renderSymbolsAuditText(w io.Writer, a symbolsAudit) {
+	exported := "missing"
+	if slices.Contains(a.Exports, a.Entrypoint) {
+		exported = "exported"
+	}
+	_, _ = fmt.Fprintf(w, "file:       %s\n", a.Path)
+	_, _ = fmt.Fprintf(w, "extension:  %s\n", a.ExtensionName)
+	_, _ = fmt.Fprintf(w, "abi_type:   %s\n", a.ABIType)
+	_, _ = fmt.Fprintf(w, "entrypoint: %s (%s)\n", a.Entrypoint, exported)
+	_, _ = fmt.Fprintf(w, "exports:    %s\n", cmp.Or(strings.Join(a.Exports, ", "), "none"))
+	_, _ = fmt.Fprintf(w, "libraries:  %s\n", cmp.Or(strings.Join(a.Libraries, ", "), "none"))
+}
+
+func newAuditSymbolsCommand() *cobra.Command {
+	var (
+		extensionName string
+		abiType       string
+		format        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "symbols <extension-file>",
+		Short: "Check the exported entrypoint and DuckDB linkage of an extension binary",
+		Long: `Checks that the binary exports <name>_init_c_api (C_STRUCT and
+C_STRUCT_UNSTABLE) or <name>_duckdb_cpp_init (CPP), that C API extensions do
+not export DuckDB symbols, and that no DuckDB shared library is loaded
+dynamically. ELF, Mach-O, PE and wasm binaries are supported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+
+			if extensionName == "" {
+				extensionName, _, _ = strings.Cut(filepath.Base(path), ".")
+			}
+			if abiType == "" {
+				info, err := metadata.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("read metadata of %q (or pass --abi-type): %w", path, err)
+				}
+				abiType = info.ABIType
+			}
+			entrypoint, err := extensionEntrypoint(extensionName, abiType)
+			if err != nil {
+				return err
+			}
+
+			linkage, err := objfile.ReadLinkage(path)
+			if err != nil {
+				return fmt.Errorf("read symbols of %q: %w", path, err)
+			}
+			audit := symbolsAudit{Path: path, ExtensionName: extensionName, ABIType: abiType, Entrypoint: entrypoint, Linkage: linkage}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				renderSymbolsAuditText(out, audit)
+			case "json":
+				payload, err := json.MarshalIndent(audit, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render audit: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+
+			if errs := audit.problems(); len(errs) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("symbol audit of %q failed: %w", path, errors.Join(errs...))
+			}
+			commandLogger(cmd).Info("Extension exports its entrypoint", "file", path, "entrypoint", entrypoint)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&extensionName, "extension-name", "", "Name of the extension (default: the file name up to the first dot)")
+	cmd.Flags().StringVar(&abiType, "abi-type", "", "ABI type: C_STRUCT|C_STRUCT_UNSTABLE|CPP (default: the footer abi_type)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+
+	return cmd
+}
+
+// wasmAudit is the result of validating one wasm extension module.
+type wasmAudit struct {
+	Path       string `json:"path"`
+	DuckDBArch string `json:"duckdb_arch"`
+	wasm.Module
+}
+
+func renderWasmAuditText(w io.Writer, a wasmAudit) {
+	_, _ = fmt.Fprintf(w, "file:        %s\n", a.Path)
+	_, _ = fmt.Fprintf(w, "duckdb_arch: %s\n", a.DuckDBArch)
+	_, _ = fmt.Fprintf(w, "features:    %s\n", a.Features)
+	_, _ = fmt.Fprintf(w, "sections:\n")
+	for _, s := range a.Sections {
+		name := s.Kind()
+		if s.Name != "" {
+			name += " " + s.Name
+		}
+		_, _ = fmt.Fprintf(w, "  %-26s offset %-8d size %d\n", name, s.Offset, s.Size)
+	}
+	_, _ = fmt.Fprintf(w, "imports:\n")
+	for _, imp := range a.Imports {
+		_, _ = fmt.Fprintf(w, "  %s.%s (%s)\n", imp.Module, imp.Name, imp.Kind)
+	}
+	_, _ = fmt.Fprintf(w, "exports:\n")
+	for _, exp := range a.Exports {
+		_, _ = fmt.Fprintf(w, "  %s (%s)\n", exp.Name, exp.Kind)
+	}
+}
+
+func newAuditWasmCommand() *cobra.Command {
+	var (
+		duckdbArch string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "wasm <extension-file>",
+		Short: "Validate the section layout and features of a wasm extension module",
+		Long: `Parses a .duckdb_extension.wasm side module, lists its sections, imports and
+exports, and checks that the duckdb_signature custom section is last and
+complete. Fails when the module uses wasm exceptions, threads or SIMD while
+its duckdb_arch is not built with them, e.g. a wasm_mvp build using wasm
+exceptions.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+
+			module, err := wasm.ParseFile(path)
+			if err != nil {
+				return fmt.Errorf("parse %q: %w", path, err)
+			}
+			if duckdbArch == "" {
+				info, err := metadata.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("read metadata of %q (or pass --duckdb-arch): %w", path, err)
+				}
+				duckdbArch = info.DuckDBPlatform
+			}
+			audit := wasmAudit{Path: path, DuckDBArch: duckdbArch, Module: module}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				renderWasmAuditText(out, audit)
+			case "json":
+				payload, err := json.MarshalIndent(audit, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render audit: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+
+			errs := append(module.ValidateExtension(), module.Features.CheckPlatform(duckdbArch)...)
+			if len(errs) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("wasm audit of %q failed: %w", path, errors.Join(errs...))
+			}
+			commandLogger(cmd).Info("Wasm module is valid", "file", path, "duckdb_arch", duckdbArch, "features", module.Features.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "The wasm duckdb_arch the module is built for (default: the footer duckdb_platform)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+
+	return cmd
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditGLIBCSubcommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		platform string
+		args     []string
+		wantErr  string
+	}{
+		{
+			name:     "within the matrix limits",
+			platform: "linux_amd64",
+			args:     []string{"--matrix", matrixConfigPath(t)},
+		},
+		{
+			name:     "GLIBCXX above the override",
+			platform: "linux_amd64",
+			args:     []string{"--matrix", matrixConfigPath(t), "--max-glibcxx", "3.4.20"},
+			wantErr:  "GLIBCXX 3.4.21 required by",
+		},
+		{
+			name:     "GLIBC above the override",
+			platform: "linux_arm64",
+			args:     []string{"--max-glibc", "2.2"},
+			wantErr:  "exceeds the limit 2.2 for linux_arm64",
+		},
+		{
+			name:     "glibc dependency on a musl arch",
+			platform: "linux_amd64_musl",
+			wantErr:  "linux_amd64_musl is a musl platform but the binary depends on glibc (GLIBC_2.2.5 required by __cxa_atexit)",
+		},
+		{
+			name:     "arch outside the matrix",
+			platform: "linux_amd64_gcc4",
+			args:     []string{"--matrix", matrixConfigPath(t)},
+			wantErr:  `duckdb_arch "linux_amd64_gcc4" is not in the distribution matrix`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := stampTestLibrary(t, "libquack_cpp_linux_amd64.so", tc.platform, metadata.ABITypeCPP)
+			stdout, _, err := executeRootCommandWithResult(t, append([]string{"audit", "glibc", path}, tc.args...))
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				assert.Contains(t, stdout, "glibcxx:     3.4.21 (limit 3.4.25)")
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestAuditGLIBCSubcommandJSON(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"audit", "glibc", objfileTestdataPath(t, "libquack_linux_amd64.so"),
+		"--duckdb-arch", "linux_arm64", "--matrix", matrixConfigPath(t), "--format", "json",
+	})
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, "linux_arm64", got["duckdb_arch"])
+	assert.Equal(t, "2.28", got["max_glibc_version"])
+	assert.Equal(t, map[string]any{"version": "2.2.5", "symbols": []any{"printf"}}, got["glibc"])
+	assert.NotContains(t, got, "glibcxx")
+}
+
+func TestAuditGLIBCSubcommandRequiresArch(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"audit", "glibc", objfileTestdataPath(t, "libquack_linux_amd64.so")})
+	require.ErrorContains(t, err, "or pass --duckdb-arch")
+}
+
+func TestAuditGLIBCSubcommandRejectsNonELF(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"audit", "glibc", metadataTestdataPath(t, "quack.duckdb_extension.wasm.golden")})
+	require.ErrorContains(t, err, "(must be ELF)")
+}
+
+func TestAuditSymbolsSubcommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		library string
+		abiType string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "C API entrypoint",
+			library: "libquack_linux_amd64.so",
+			abiType: metadata.ABITypeCStruct,
+		},
+		{
+			name:    "C++ entrypoint",
+			library: "libquack_cpp_linux_amd64.so",
+			abiType: metadata.ABITypeCPP,
+		},
+		{
+			name:    "ABI type override",
+			library: "libquack_cpp_linux_amd64.so",
+			abiType: metadata.ABITypeCPP,
+			args:    []string{"--abi-type", metadata.ABITypeCStructUnstable},
+			wantErr: []string{"C_STRUCT_UNSTABLE entrypoint quack_init_c_api is not exported"},
+		},
+		{
+			name:    "misnamed extension",
+			library: "libquack_linux_amd64.so",
+			abiType: metadata.ABITypeCStruct,
+			args:    []string{"--extension-name", "duck"},
+			wantErr: []string{"C_STRUCT entrypoint duck_init_c_api is not exported"},
+		},
+		{
+			name:    "leaked DuckDB symbols",
+			library: "libquack_leaky_linux_amd64.so",
+			abiType: metadata.ABITypeCStruct,
+			wantErr: []string{
+				"C_STRUCT extension exports DuckDB symbols: _ZN6duckdb6LeakedEi, duckdb_open",
+				"extension links the DuckDB library libduckdb.so dynamically",
+			},
+		},
+		{
+			name:    "unknown ABI type",
+			library: "libquack_linux_amd64.so",
+			abiType: metadata.ABITypeCStruct,
+			args:    []string{"--abi-type", "RUST"},
+			wantErr: []string{`invalid ABI type "RUST"`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := stampTestLibrary(t, tc.library, "linux_amd64", tc.abiType)
+			stdout, _, err := executeRootCommandWithResult(t, append([]string{"audit", "symbols", path}, tc.args...))
+			if len(tc.wantErr) == 0 {
+				require.NoError(t, err)
+				assert.Contains(t, stdout, "(exported)")
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tc.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestAuditSymbolsSubcommandJSON(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"audit", "symbols", objfileTestdataPath(t, "libquack_cpp_linux_amd64.so"),
+		"--extension-name", "quack", "--abi-type", metadata.ABITypeCPP, "--format", "json",
+	})
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, "quack_duckdb_cpp_init", got["entrypoint"])
+	assert.Equal(t, []any{"quack_duckdb_cpp_init"}, got["exports"])
+}
+
+// wasmExtension writes a side module whose only function uses a legacy
+// try block, followed by a footer for platform.
+func wasmExtension(t *testing.T, platform string) string {
+	t.Helper()
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, 0x00, 0x09, 0x08)
+	module = append(module, "dylink.0"...)
+	module = append(module,
+		0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section: () -> ()
+		0x03, 0x02, 0x01, 0x00, // function section
+		0x07, 0x14, 0x01, 0x10, // export section: quack_init_c_api
+	)
+	module = append(module, "quack_init_c_api"...)
+	module = append(module,
+		0x00, 0x00,
+		0x0a, 0x07, 0x01, 0x05, 0x00, 0x06, 0x40, 0x0b, 0x0b, // code section: try end
+	)
+	footer, err := metadata.Metadata{
+		ABIType:          metadata.ABITypeCStruct,
+		ExtensionVersion: "v0.1.0",
+		DuckDBVersion:    "v1.2.0",
+		DuckDBPlatform:   platform,
+	}.Footer()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension.wasm")
+	require.NoError(t, os.WriteFile(path, append(module, footer...), 0o644))
+	return path
+}
+
+func TestAuditWasmSubcommand(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"audit", "wasm", wasmExtension(t, "wasm_eh")})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "features:    exceptions\n")
+	assert.Contains(t, stdout, "  custom duckdb_signature    offset 60       size 531\n")
+	assert.Contains(t, stdout, "  quack_init_c_api (func)\n")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"audit", "wasm", wasmExtension(t, "wasm_mvp")})
+	require.ErrorContains(t, err, "wasm_mvp build uses wasm exceptions")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"audit", "wasm", wasmExtension(t, "wasm_mvp"), "--duckdb-arch", "wasm_threads"})
+	require.NoError(t, err)
+}
+
+func TestAuditWasmSubcommandLayout(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"audit", "wasm", metadataTestdataPath(t, "quack.duckdb_extension.wasm.golden"), "--format", "json"})
+	require.ErrorContains(t, err, "first section must be the dylink.0 custom section of a side module")
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, "wasm_eh", got["duckdb_arch"])
+	assert.Equal(t, map[string]any{"exceptions": false, "threads": false, "simd": false}, got["features"])
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/duckdb/extension-ci-tools/internal/cmakeflags"
+	"github.com/spf13/cobra"
+)
+
+func newCMakeArgsCommand() *cobra.Command {
+	var (
+		duckdbArch string
+		buildType  string
+		matrixPath string
+		projDir    string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cmake-args",
+		Short: "Print the CMake configure command duckdb_extension.Makefile runs for a build",
+		Long: `Prints the CMake configure command line the build type target of
+duckdb_extension.Makefile runs, or the wasm_* target for wasm platforms.
+
+The osx_build_arch and vcpkg triplets come from the distribution matrix entry
+of --arch. All other inputs are read from the Makefile variables in the
+environment, such as VCPKG_TOOLCHAIN_PATH, EXT_CONFIG, EXT_FLAGS, GEN or
+DISABLE_SANITIZER. EXT_CONFIG defaults to extension_config.cmake in the
+extension directory, like extension Makefiles set it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			bt, err := cmakeflags.ParseBuildType(buildType)
+			if err != nil {
+				return err
+			}
+
+			opts, err := cmakeflags.FromEnv(os.Getenv)
+			if err != nil {
+				return err
+			}
+			matrix, err := loadMatrixFile(matrixPath)
+			if err != nil {
+				return err
+			}
+			entry, ok := matrix.Entry(duckdbArch)
+			if !ok {
+				return fmt.Errorf("duckdb_arch %q is not in the distribution matrix", duckdbArch)
+			}
+			opts.DuckDBPlatform = duckdbArch
+			opts.BuildType = bt
+			opts.OSXBuildArch = ""
+			if entry.OSXBuildArch != nil {
+				opts.OSXBuildArch = *entry.OSXBuildArch
+			}
+			opts.VCPKGTargetTriplet = entry.VCPKGTargetTriplet
+			opts.VCPKGHostTriplet = entry.VCPKGHostTriplet
+			if projDir != "" {
+				abs, err := filepath.Abs(projDir)
+				if err != nil {
+					return fmt.Errorf("resolve extension directory %q: %w", projDir, err)
+				}
+				opts.ProjDir = filepath.ToSlash(abs) + "/"
+			}
+			if opts.ExtConfig == "" {
+				opts.ExtConfig = opts.ProjDir + "extension_config.cmake"
+			}
+
+			args, err := opts.Command()
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				_, _ = fmt.Fprintln(out, cmakeflags.Render(args))
+			case "json":
+				payload, err := json.MarshalIndent(struct {
+					Command  []string `json:"command"`
+					BuildDir string   `json:"build_dir"`
+				}{args, opts.BuildDir()}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render cmake arguments: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&duckdbArch, "arch", "", "The duckdb_arch to configure, as named in the distribution matrix")
+	cmd.Flags().StringVar(&buildType, "build-type", string(cmakeflags.BuildRelease), "Build type: debug|release|relassert|reldebug")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.Flags().StringVar(&projDir, "proj-dir", ".", "Extension directory (PROJ_DIR), empty to read PROJ_DIR from the environment")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	_ = cmd.MarkFlagRequired("arch")
+
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCMakeArgsSubcommandUsesMatrixEntry(t *testing.T) {
+	t.Setenv("VCPKG_TOOLCHAIN_PATH", "/vcpkg/scripts/buildsystems/vcpkg.cmake")
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"cmake-args", "--arch", "osx_arm64", "--build-type", "relassert",
+		"--matrix", matrixConfigPath(t), "--proj-dir", "/work/quack",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "-DDUCKDB_EXTENSION_CONFIGS=/work/quack/extension_config.cmake ")
+	assert.Contains(t, stdout, "-DOSX_BUILD_ARCH=arm64 -DRust_CARGO_TARGET=aarch64-apple-darwin ")
+	assert.Contains(t, stdout, "-DVCPKG_TARGET_TRIPLET=arm64-osx-release -DVCPKG_HOST_TRIPLET=arm64-osx-release ")
+	assert.Contains(t, stdout, "-DVCPKG_MANIFEST_DIR=/work/quack/ ")
+	assert.Contains(t, stdout, "-S ./duckdb -DFORCE_ASSERT=1 -B build/relassert\n")
+}
+
+func TestCMakeArgsSubcommandJSON(t *testing.T) {
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"cmake-args", "--arch", "wasm_eh", "--matrix", matrixConfigPath(t), "--format", "json",
+	})
+	require.NoError(t, err)
+
+	var got struct {
+		Command  []string `json:"command"`
+		BuildDir string   `json:"build_dir"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, "build/wasm_eh", got.BuildDir)
+	assert.Equal(t, []string{"emcmake", "cmake"}, got.Command[:2])
+	assert.Contains(t, got.Command, "-DCMAKE_CXX_FLAGS= -fwasm-exceptions -DWEBDB_FAST_EXCEPTIONS=1")
+}
+
+func TestCMakeArgsSubcommandRejectsInvalidInputs(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{"cmake-args", "--arch", "solaris_sparc", "--matrix", matrixConfigPath(t)})
+	require.EqualError(t, err, `duckdb_arch "solaris_sparc" is not in the distribution matrix`)
+
+	_, _, err = executeRootCommandWithResult(t, []string{"cmake-args", "--arch", "linux_amd64", "--build-type", "fast", "--matrix", matrixConfigPath(t)})
+	require.EqualError(t, err, `invalid build type "fast" (must be debug|release|relassert|reldebug)`)
+
+	t.Setenv("BUILD_EXTENSION_TEST_DEPS", "some")
+	_, _, err = executeRootCommandWithResult(t, []string{"cmake-args", "--arch", "linux_amd64", "--matrix", matrixConfigPath(t)})
+	require.EqualError(t, err, "unknown option passed to BUILD_EXTENSION_TEST_DEPS variable: some")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/duckdb/extension-ci-tools/internal/compat"
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/spf13/cobra"
+)
+
+func newCompatCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compat",
+		Short: "Decide which DuckDB versions can load an extension binary",
+	}
+	cmd.AddCommand(newCompatCheckCommand())
+	return cmd
+}
+
+func newCompatCheckCommand() *cobra.Command {
+	var (
+		extensionPath  string
+		duckdbVersions []string
+		format         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check whether DuckDB versions can load an extension under the rules of its ABI type",
+		Long: `Reads the abi_type and duckdb_version of the extension footer and decides for
+each --duckdb version whether it can load the extension:
+
+  C_STRUCT           duckdb_version is the minimum C API version; later
+                     releases of the same major version load the extension.
+  C_STRUCT_UNSTABLE  duckdb_version is the exact DuckDB version.
+  CPP                duckdb_version is the exact DuckDB version.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+
+			info, err := metadata.ReadFile(extensionPath)
+			if err != nil {
+				return fmt.Errorf("read metadata of %q: %w", extensionPath, err)
+			}
+
+			results := make([]compat.Result, 0, len(duckdbVersions))
+			incompatible := 0
+			for _, version := range duckdbVersions {
+				result, err := compat.Check(info.Metadata, version)
+				if err != nil {
+					return err
+				}
+				if !result.Loadable {
+					incompatible++
+				}
+				results = append(results, result)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				for _, r := range results {
+					verdict := "cannot load"
+					if r.Loadable {
+						verdict = "can load"
+					}
+					_, _ = fmt.Fprintf(out, "DuckDB %s %s %s (%s, duckdb_version %s): %s\n",
+						r.DuckDBVersion, verdict, extensionPath, r.ABIType, r.Requires, r.Reason)
+				}
+			case "json":
+				payload, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render results: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+
+			if incompatible > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d DuckDB versions cannot load %q", incompatible, len(results), extensionPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&extensionPath, "extension", "", "Extension binary with a metadata footer")
+	cmd.Flags().StringSliceVar(&duckdbVersions, "duckdb", nil, "DuckDB version to check, e.g. v1.5.4 (repeatable)")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	_ = cmd.MarkFlagRequired("extension")
+	_ = cmd.MarkFlagRequired("duckdb")
+
+	return cmd
+}
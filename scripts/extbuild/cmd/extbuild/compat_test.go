@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompatCheckSubcommand(t *testing.T) {
+	t.Parallel()
+
+	path := metadataTestdataPath(t, "quack.duckdb_extension.golden")
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"compat", "check", "--extension", path, "--duckdb", "v1.5.4"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "DuckDB v1.5.4 can load")
+	assert.Contains(t, stdout, "(C_STRUCT, duckdb_version v1.2.0): the extension needs at least C API v1.2.0")
+
+	stdout, _, err = executeRootCommandWithResult(t, []string{"compat", "check", "--extension", path, "--duckdb", "v1.5.4,v1.1.0", "--format", "json"})
+	require.EqualError(t, err, `1 of 2 DuckDB versions cannot load "`+path+`"`)
+
+	var results []compat.Result
+	require.NoError(t, json.Unmarshal([]byte(stdout), &results))
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Loadable)
+	assert.False(t, results[1].Loadable)
+}
+
+func TestCompatCheckSubcommandRequiresFlags(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"compat", "check", "--extension", "x.duckdb_extension"})
+	require.ErrorContains(t, err, `required flag(s) "duckdb" not set`)
+}
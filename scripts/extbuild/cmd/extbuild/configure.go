@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/duckdb/extension-ci-tools/internal/platform"
+	"github.com/duckdb/extension-ci-tools/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newConfigureCommand() *cobra.Command {
+	var (
+		outDir           string
+		extensionVersion bool
+		duckdbPlatform   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "configure",
+		Short: "Write the detected extension version and DuckDB platform (replaces configure_helper.py)",
+		Long: `Writes extension_version.txt and platform.txt to the output directory, for
+the metadata step of the build to pick up.
+
+The extension version is the tag on HEAD, whatever its name, or else the short
+commit hash, like configure_helper.py wrote it. Uncommitted changes are not
+marked, as CI modifies tracked files such as extension_config.cmake before
+configuring. The DuckDB platform is detected from the operating system, the CPU
+architecture, the C library (musl or glibc) and mingw environment hints, and is
+named like the duckdb_arch values of the distribution matrix.
+
+Without --extension-version or --duckdb-platform both files are written.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !extensionVersion && !duckdbPlatform {
+				extensionVersion, duckdbPlatform = true, true
+			}
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return fmt.Errorf("create output directory %q: %w", outDir, err)
+			}
+
+			if extensionVersion {
+				result, err := version.Exact(version.Options{})
+				if err != nil {
+					return fmt.Errorf("detect extension version: %w", err)
+				}
+				if err := writeConfigureFile(cmd, outDir, "extension_version.txt", "version", result.Version); err != nil {
+					return err
+				}
+			}
+			if duckdbPlatform {
+				name, err := platform.Detect(platform.Native())
+				if err != nil {
+					return fmt.Errorf("detect DuckDB platform: %w", err)
+				}
+				if err := writeConfigureFile(cmd, outDir, "platform.txt", "platform", name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outDir, "output-directory", "o", "configure", "Directory to write the configure files to")
+	cmd.Flags().BoolVar(&extensionVersion, "extension-version", false, "Write the detected extension version to extension_version.txt")
+	cmd.Flags().BoolVar(&duckdbPlatform, "duckdb-platform", false, "Write the detected DuckDB platform to platform.txt")
+
+	return cmd
+}
+
+func writeConfigureFile(cmd *cobra.Command, dir, name, key, value string) error {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(value), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", key, err)
+	}
+	commandLogger(cmd).Info("Wrote "+key, key, value, "file", path)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitRepository(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=extbuild", "-c", "user.email=extbuild@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func readConfigureFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "configure", name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestConfigureSubcommandWritesVersionAndPlatform(t *testing.T) {
+	dir := gitRepository(t)
+	t.Chdir(dir)
+
+	_, _, err := executeRootCommandWithResult(t, []string{"configure"})
+	require.NoError(t, err)
+
+	hash := git(t, dir, "log", "-1", "--format=%h")
+	assert.Equal(t, hash[:len(hash)-1], readConfigureFile(t, dir, "extension_version.txt"))
+
+	want, err := platform.Detect(platform.Native())
+	require.NoError(t, err)
+	assert.Equal(t, want, readConfigureFile(t, dir, "platform.txt"))
+}
+
+func TestConfigureSubcommandPrefersTagAtHead(t *testing.T) {
+	dir := gitRepository(t)
+	git(t, dir, "tag", "v1.2.3")
+	t.Chdir(dir)
+
+	_, _, err := executeRootCommandWithResult(t, []string{"configure", "--extension-version", "-o", "out"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "extension_version.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", string(data))
+	assert.NoFileExists(t, filepath.Join(dir, "out", "platform.txt"))
+}
+
+func TestConfigureSubcommandKeepsAnyTagAndIgnoresChanges(t *testing.T) {
+	dir := gitRepository(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extension_config.cmake"), []byte("duckdb_extension_load(quack)\n"), 0o644))
+	git(t, dir, "add", "extension_config.cmake")
+	git(t, dir, "commit", "-q", "-m", "config")
+	git(t, dir, "tag", "nightly-2024-05-01")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extension_config.cmake"), []byte("# Injected Extension Config\n"), 0o644))
+	t.Chdir(dir)
+
+	_, _, err := executeRootCommandWithResult(t, []string{"configure", "--extension-version"})
+	require.NoError(t, err)
+	assert.Equal(t, "nightly-2024-05-01", readConfigureFile(t, dir, "extension_version.txt"))
+}
+
+func TestConfigureSubcommandFailsOutsideGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	_, _, err := executeRootCommandWithResult(t, []string{"configure", "--extension-version"})
+	require.ErrorContains(t, err, "detect extension version: git rev-parse --short HEAD: fatal: not a git repository")
+}
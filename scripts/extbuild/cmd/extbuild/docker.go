@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/dockerenv"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/spf13/cobra"
+)
+
+func newDockerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docker",
+		Short: "Prepare the Docker containers of the Linux builds",
+	}
+	cmd.AddCommand(newDockerEnvCommand())
+	return cmd
+}
+
+func newDockerEnvCommand() *cobra.Command {
+	var (
+		inputsJSON string
+		inputsPath string
+		matrixPath string
+		duckdbArch string
+		outPath    string
+		mask       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Write the Docker --env-file of the Linux build containers",
+		Long: `Writes the env file the Linux build containers run with, from the workflow
+inputs and the matrix entry of --duckdb-arch. The test_env_variables of
+test_config are merged in, replacing variables of the same name.
+
+VCPKG_BINARY_SOURCES and the vcpkg cache credentials are read from the
+environment: VCPKG_CACHING_AWS_ACCESS_KEY_ID, VCPKG_CACHING_AWS_SECRET_ACCESS_KEY,
+VCPKG_CACHING_AWS_ENDPOINT_URL and VCPKG_CACHING_AWS_DEFAULT_REGION. Their
+values are masked in logs, and with --mask (the default in GitHub Actions) an
+add-mask command is printed for each of them, to stderr when -o - writes the
+env file to stdout.
+
+An env file has no quoting, so values with line breaks are rejected.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			in := inputs.Inputs{}
+			if inputsJSON != "" || inputsPath != "" {
+				var err error
+				in, err = loadWorkflowInputs(inputsJSON, inputsPath)
+				if err != nil {
+					return err
+				}
+			}
+			matrix, err := loadMatrixFile(matrixPath)
+			if err != nil {
+				return err
+			}
+			entry, ok := matrix.Entry(duckdbArch)
+			if !ok {
+				return fmt.Errorf("duckdb_arch %q is not in the distribution matrix", duckdbArch)
+			}
+
+			vars, err := dockerenv.Build(dockerenv.Options{
+				Inputs:             in,
+				DuckDBArch:         entry.DuckDBArch,
+				VCPKGTargetTriplet: entry.VCPKGTargetTriplet,
+				Getenv:             os.Getenv,
+			})
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("build docker env: %w", err)
+			}
+
+			if mask {
+				// The env file goes to stdout with -o -, so the masks must not.
+				maskOut := cmd.OutOrStdout()
+				if outPath == "-" {
+					maskOut = cmd.ErrOrStderr()
+				}
+				if err := dockerenv.WriteMasks(maskOut, vars); err != nil {
+					return err
+				}
+			}
+			logger := commandLogger(cmd)
+			for _, v := range vars {
+				logger.Debug("Docker env variable", "variable", v)
+			}
+
+			var buf bytes.Buffer
+			if err := dockerenv.Write(&buf, vars); err != nil {
+				return err
+			}
+			if outPath == "-" {
+				_, err := cmd.OutOrStdout().Write(buf.Bytes())
+				return err
+			}
+			if err := os.WriteFile(outPath, buf.Bytes(), 0o600); err != nil {
+				return fmt.Errorf("write docker env file: %w", err)
+			}
+			logger.Info("Wrote docker env file", "file", outPath, "variables", len(vars))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&inputsJSON, "inputs", "", "Workflow inputs as a JSON object")
+	cmd.Flags().StringVar(&inputsPath, "inputs-file", "", "Path to a JSON file with the workflow inputs")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "The duckdb_arch of the container")
+	cmd.Flags().StringVarP(&outPath, "output", "o", "docker_env.txt", "Env file to write, - for stdout")
+	cmd.Flags().BoolVar(&mask, "mask", os.Getenv("GITHUB_ACTIONS") == "true", "Print GitHub Actions add-mask commands for the secret values")
+	cmd.MarkFlagsMutuallyExclusive("inputs", "inputs-file")
+	_ = cmd.MarkFlagRequired("duckdb-arch")
+
+	return cmd
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerEnvSubcommandWritesEnvFile(t *testing.T) {
+	t.Setenv("VCPKG_BINARY_SOURCES", "clear")
+	t.Setenv("VCPKG_CACHING_AWS_SECRET_ACCESS_KEY", "s3cr3t")
+	path := filepath.Join(t.TempDir(), "docker_env.txt")
+
+	stdout, stderr, err := executeRootCommandWithResult(t, []string{
+		"docker", "env", "--duckdb-arch", "linux_arm64", "--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","build_type":"debug","test_config":"{\"test_env_variables\":{\"QUACK_MODE\":\"loud\"}}"}`,
+		"--mask", "-o", path, "--log-level", "debug",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "::add-mask::s3cr3t\n", stdout)
+	assert.NotContains(t, stderr, "s3cr3t")
+	assert.Contains(t, stderr, "AWS_SECRET_ACCESS_KEY=***")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "VCPKG_BINARY_SOURCES=clear\n")
+	assert.Contains(t, string(data), "AWS_SECRET_ACCESS_KEY=s3cr3t\n")
+	assert.Contains(t, string(data), "VCPKG_TARGET_TRIPLET=arm64-linux-release\n")
+	assert.Contains(t, string(data), "OPENSSL_DIR=/duckdb_build_dir/build/debug/vcpkg_installed/arm64-linux-release\n")
+	assert.Contains(t, string(data), "DUCKDB_PLATFORM=linux_arm64\n")
+	assert.Contains(t, string(data), "EXTENSION_NAME=quack\n")
+	assert.Contains(t, string(data), "\nQUACK_MODE=loud\n")
+}
+
+func TestDockerEnvSubcommandMasksToStderrWithStdoutOutput(t *testing.T) {
+	t.Setenv("VCPKG_CACHING_AWS_SECRET_ACCESS_KEY", "s3cr3t")
+
+	stdout, stderr, err := executeRootCommandWithResult(t, []string{
+		"docker", "env", "--duckdb-arch", "linux_amd64", "--matrix", matrixConfigPath(t), "--mask", "-o", "-",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "::add-mask::")
+	assert.Contains(t, stdout, "AWS_SECRET_ACCESS_KEY=s3cr3t\n")
+	assert.Contains(t, stderr, "::add-mask::s3cr3t\n")
+}
+
+func TestDockerEnvSubcommandRejectsLineBreaks(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"docker", "env", "--duckdb-arch", "linux_amd64", "--matrix", matrixConfigPath(t), "--mask=false", "-o", "-",
+		"--inputs", `{"extension_name":"quack\nEVIL=1"}`,
+	})
+	require.EqualError(t, err, "build docker env: variable EXTENSION_NAME: value contains a line break")
+
+	_, _, err = executeRootCommandWithResult(t, []string{
+		"docker", "env", "--duckdb-arch", "solaris_sparc", "--matrix", matrixConfigPath(t),
+	})
+	require.EqualError(t, err, `duckdb_arch "solaris_sparc" is not in the distribution matrix`)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/plan"
 )
 
 const (
@@ -27,22 +29,38 @@ func detectGitHubEventTypeFromEnv() (string, error) {
 }
 
 func detectGitHubEventTypeFromFile(path string) (string, error) {
-	data, err := os.ReadFile(path)
+	event, err := readGitHubEventFile(path)
 	if err != nil {
 		return "", err
 	}
+	return event.Type, nil
+}
+
+// readGitHubEventFile classifies a GitHub event payload and extracts the ref it
+// was triggered for, which is empty for event types that do not carry one.
+func readGitHubEventFile(path string) (plan.Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return plan.Event{}, err
+	}
 
 	var payload map[string]json.RawMessage
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return "", err
+		return plan.Event{}, err
+	}
+
+	var ref string
+	if raw, ok := payload["ref"]; ok {
+		// A null ref, as sent for some events, leaves ref empty.
+		_ = json.Unmarshal(raw, &ref)
 	}
 
 	if _, ok := payload[githubEventPullRequest]; ok {
-		return githubEventPullRequest, nil
+		return plan.Event{Type: githubEventPullRequest, Ref: ref}, nil
 	}
 	if _, ok := payload["ref"]; ok {
-		return githubEventPush, nil
+		return plan.Event{Type: githubEventPush, Ref: ref}, nil
 	}
 
-	return githubEventUnknown, nil
+	return plan.Event{Type: githubEventUnknown}, nil
 }
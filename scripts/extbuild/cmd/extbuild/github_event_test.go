@@ -75,6 +75,8 @@ func TestDetectGitHubEventTypeFromEnvInvalidJSON(t *testing.T) {
 func TestMatrixSubcommandLogsDetectedEventType(t *testing.T) {
 	eventPath := fixturePath(t, "extension_template_pull_request.json")
 	t.Setenv("GITHUB_EVENT_PATH", eventPath)
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
 	_, stderr, err := executeRootCommandWithResult(t, []string{
 		"matrix",
 		"--input", matrixConfigPath(t),
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/spf13/cobra"
+)
+
+func newInputsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inputs",
+		Short: "Inspect the inputs of the reusable distribution workflow",
+	}
+	cmd.AddCommand(newInputsValidateCommand())
+	return cmd
+}
+
+func newInputsValidateCommand() *cobra.Command {
+	var (
+		inputsJSON string
+		inputsPath string
+		matrixPath string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate _extension_distribution.yml inputs against the matrix and known toolchains",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			in, err := loadWorkflowInputs(inputsJSON, inputsPath)
+			if err != nil {
+				return err
+			}
+			matrix, err := loadMatrixFile(matrixPath)
+			if err != nil {
+				return err
+			}
+
+			issues := inputs.Validate(in, matrix)
+
+			var rendered string
+			switch format {
+			case "text":
+				rendered = inputs.RenderText(issues)
+			case "github":
+				rendered = inputs.RenderGitHubAnnotations(issues)
+			case "json":
+				rendered, err = inputs.RenderJSON(issues)
+				if err != nil {
+					return fmt.Errorf("render issues: %w", err)
+				}
+			default:
+				return fmt.Errorf("invalid format: %q (must be text|json|github)", format)
+			}
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), rendered)
+
+			if inputs.HasErrors(issues) {
+				// The issues were already reported, usage would only bury them.
+				cmd.SilenceUsage = true
+				return errors.New("invalid workflow inputs")
+			}
+			commandLogger(cmd).Info("Workflow inputs are valid", "warnings", len(issues))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&inputsJSON, "inputs", "", "Workflow inputs as a JSON object, e.g. ${{ toJSON(inputs) }}")
+	cmd.Flags().StringVar(&inputsPath, "inputs-file", "", "Path to a JSON file with the workflow inputs")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json|github")
+	cmd.MarkFlagsMutuallyExclusive("inputs", "inputs-file")
+	cmd.MarkFlagsOneRequired("inputs", "inputs-file")
+
+	return cmd
+}
+
+func loadWorkflowInputs(inputsJSON, inputsPath string) (inputs.Inputs, error) {
+	data := []byte(inputsJSON)
+	if inputsPath != "" {
+		var err error
+		data, err = os.ReadFile(inputsPath)
+		if err != nil {
+			return nil, fmt.Errorf("read inputs %q: %w", inputsPath, err)
+		}
+	}
+	return inputs.Parse(data)
+}
+
+func loadMatrixFile(path string) (distmatrix.MatrixFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read input matrix %q: %w", path, err)
+	}
+	matrix, err := distmatrix.ParseMatrixFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse input matrix %q: %w", path, err)
+	}
+	return matrix, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputsValidateAcceptsValidInputs(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"inputs", "validate",
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4","ci_tools_version":"main","extra_toolchains":"rust;python3"}`,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+}
+
+func TestInputsValidateEmitsGitHubAnnotations(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"inputs", "validate",
+		"--matrix", matrixConfigPath(t),
+		"--format", "github",
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4","ci_tools_version":"main","build_type":"fast"}`,
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "invalid workflow inputs")
+	assert.Equal(t, "::error title=Invalid workflow input build_type::invalid value \"fast\" (must be release|debug|relassert|reldebug)\n", stdout)
+}
+
+func TestInputsValidateReadsInputsFileAndRendersJSON(t *testing.T) {
+	t.Parallel()
+
+	inputsPath := filepath.Join(t.TempDir(), "inputs.json")
+	require.NoError(t, os.WriteFile(inputsPath, []byte(`{
+  "extension_name": "quack",
+  "duckdb_version": "v1.5.4",
+  "ci_tools_version": "main",
+  "vcpkg_extra_dependencies": "{\"windows_arm64\": [\"zlib\"]}"
+}`), 0o600))
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"inputs", "validate",
+		"--matrix", matrixConfigPath(t),
+		"--format", "json",
+		"--inputs-file", inputsPath,
+	})
+	require.NoError(t, err)
+
+	var issues []inputs.Issue
+	require.NoError(t, json.Unmarshal([]byte(stdout), &issues))
+	require.Len(t, issues, 1)
+	assert.Equal(t, inputs.SeverityWarning, issues[0].Severity)
+	assert.Equal(t, "vcpkg_extra_dependencies", issues[0].Input)
+}
+
+func TestInputsValidateRequiresInputs(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"inputs", "validate", "--matrix", matrixConfigPath(t)})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "at least one of the flags in the group [inputs inputs-file] is required")
+}
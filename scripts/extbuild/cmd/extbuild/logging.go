@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -20,17 +24,154 @@ const (
 	ansiGray   = "\x1b[90m"
 )
 
-func newLogger(w io.Writer) *slog.Logger {
-	return slog.New(&prettyHandler{
-		writer: w,
-		level:  slog.LevelInfo,
-	})
+const (
+	envLogLevel   = "EXTBUILD_LOG_LEVEL"
+	envLogFormat  = "EXTBUILD_LOG_FORMAT"
+	envLogSource  = "EXTBUILD_LOG_SOURCE"
+	envNoColor    = "NO_COLOR"
+	envForceColor = "FORCE_COLOR"
+)
+
+type logFormat string
+
+const (
+	logFormatPretty logFormat = "pretty"
+	logFormatText   logFormat = "text"
+	logFormatJSON   logFormat = "json"
+	logFormatGitHub logFormat = "github"
+)
+
+type loggerOptions struct {
+	level     slog.Level
+	format    logFormat
+	color     bool
+	addSource bool
+}
+
+func newLogger(w io.Writer, opts loggerOptions) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.level, AddSource: opts.addSource}
+	switch opts.format {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, handlerOpts))
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, handlerOpts))
+	default:
+		return slog.New(newPrettyHandler(w, opts))
+	}
+}
+
+// logFlags holds the raw values of the persistent logging flags.
+type logFlags struct {
+	level  string
+	format string
+	source bool
+}
+
+// resolveLoggerOptions combines the logging flag values with their
+// environment fallbacks. Empty flag values defer to the environment, which in
+// turn defaults to info level pretty output without source locations.
+func resolveLoggerOptions(w io.Writer, flags logFlags) (loggerOptions, error) {
+	levelRaw := flags.level
+	if levelRaw == "" {
+		levelRaw = os.Getenv(envLogLevel)
+	}
+	formatRaw := flags.format
+	if formatRaw == "" {
+		formatRaw = os.Getenv(envLogFormat)
+	}
+
+	level, err := parseLogLevel(levelRaw)
+	if err != nil {
+		return loggerOptions{}, err
+	}
+	format, err := parseLogFormat(formatRaw)
+	if err != nil {
+		return loggerOptions{}, err
+	}
+
+	addSource := flags.source
+	if !addSource {
+		if raw := os.Getenv(envLogSource); raw != "" {
+			addSource, err = strconv.ParseBool(raw)
+			if err != nil {
+				return loggerOptions{}, fmt.Errorf("invalid %s value: %q", envLogSource, raw)
+			}
+		}
+	}
+
+	return loggerOptions{
+		level:     level,
+		format:    format,
+		color:     useColor(w),
+		addSource: addSource,
+	}, nil
+}
+
+func parseLogLevel(raw string) (slog.Level, error) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "":
+		return slog.LevelInfo, nil
+	case "warning":
+		return slog.LevelWarn, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return 0, fmt.Errorf("invalid log level: %q (must be debug|info|warn|error)", raw)
+	}
+	return level, nil
+}
+
+func parseLogFormat(raw string) (logFormat, error) {
+	switch format := logFormat(strings.ToLower(strings.TrimSpace(raw))); format {
+	case "":
+		return logFormatPretty, nil
+	case logFormatPretty, logFormatText, logFormatJSON, logFormatGitHub:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid log format: %q (must be pretty|text|json|github)", raw)
+	}
+}
+
+// useColor follows https://no-color.org: a non-empty NO_COLOR always disables
+// colors, a non-empty FORCE_COLOR enables them, and otherwise colors are only
+// used when writing to a terminal.
+func useColor(w io.Writer) bool {
+	if os.Getenv(envNoColor) != "" {
+		return false
+	}
+	switch os.Getenv(envForceColor) {
+	case "":
+	case "0", "false":
+		return false
+	default:
+		return true
+	}
+	return isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 type loggerContextKey struct{}
 
-func attachCommandLogger(cmd *cobra.Command) {
-	cmd.SetContext(context.WithValue(cmd.Context(), loggerContextKey{}, newLogger(cmd.ErrOrStderr())))
+func attachCommandLogger(cmd *cobra.Command, flags logFlags) error {
+	opts, err := resolveLoggerOptions(cmd.ErrOrStderr(), flags)
+	if err != nil {
+		return err
+	}
+	cmd.SetContext(context.WithValue(cmd.Context(), loggerContextKey{}, newLogger(cmd.ErrOrStderr(), opts)))
+	return nil
 }
 
 func commandLogger(cmd *cobra.Command) *slog.Logger {
@@ -67,80 +208,191 @@ func shortLevel(level slog.Level) string {
 	}
 }
 
+// githubCommand maps a level to the GitHub Actions workflow command that
+// surfaces it as an annotation. Info records are printed without a command.
+func githubCommand(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "::error::"
+	case level >= slog.LevelWarn:
+		return "::warning::"
+	case level <= slog.LevelDebug:
+		return "::debug::"
+	default:
+		return ""
+	}
+}
+
+// escapeGitHubCommand escapes the characters GitHub Actions treats specially
+// inside workflow command messages.
+func escapeGitHubCommand(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// lockedWriter serializes writes from a prettyHandler and all handlers
+// derived from it through WithAttrs and WithGroup.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) writeString(s string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := io.WriteString(w.w, s)
+	return err
+}
+
+// prettyHandler renders records as a single human readable line:
+//
+//	15:04 INF message key=value group.key=value
+//
+// Attributes added through WithAttrs are rendered once when they are added,
+// qualified with the groups that were open at that point.
 type prettyHandler struct {
-	writer io.Writer
-	level  slog.Level
-	attrs  []slog.Attr
-	group  string
-	mu     sync.Mutex
+	out       *lockedWriter
+	level     slog.Level
+	color     bool
+	github    bool
+	addSource bool
+
+	preformatted string
+	groupPrefix  string
 }
 
-func (h prettyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+func newPrettyHandler(w io.Writer, opts loggerOptions) *prettyHandler {
+	return &prettyHandler{
+		out:       &lockedWriter{w: w},
+		level:     opts.level,
+		color:     opts.color && opts.format != logFormatGitHub,
+		github:    opts.format == logFormatGitHub,
+		addSource: opts.addSource,
+	}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
 	return level >= h.level
 }
 
-func (h prettyHandler) Handle(ctx context.Context, record slog.Record) error {
+func (h *prettyHandler) Handle(_ context.Context, record slog.Record) error {
 	var b strings.Builder
-	b.WriteString(ansiGray)
-	b.WriteString(record.Time.Format("15:04"))
-	b.WriteString(ansiReset)
-	b.WriteByte(' ')
-	b.WriteString(colorizeLevel(record.Level))
-	if record.Message != "" {
-		b.WriteByte(' ')
+	if h.github {
+		b.WriteString(githubCommand(record.Level))
 		b.WriteString(record.Message)
+	} else {
+		if !record.Time.IsZero() {
+			b.WriteString(h.paint(ansiGray, record.Time.Format("15:04")))
+			b.WriteByte(' ')
+		}
+		if h.color {
+			b.WriteString(colorizeLevel(record.Level))
+		} else {
+			b.WriteString(shortLevel(record.Level))
+		}
+		if record.Message != "" {
+			b.WriteByte(' ')
+			b.WriteString(record.Message)
+		}
 	}
 
-	for _, attr := range h.attrs {
-		appendAttr(&b, h.group, attr)
-	}
+	b.WriteString(h.preformatted)
 	record.Attrs(func(attr slog.Attr) bool {
-		appendAttr(&b, h.group, attr)
+		appendAttr(&b, h.groupPrefix, attr)
 		return true
 	})
 
-	b.WriteByte('\n')
+	if h.addSource && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		if frame.File != "" {
+			source := filepath.Join(filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File))
+			b.WriteByte(' ')
+			b.WriteString(h.paint(ansiGray, slog.SourceKey+"="+source+":"+strconv.Itoa(frame.Line)))
+		}
+	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	_, err := io.WriteString(h.writer, b.String())
-	return err
+	line := b.String()
+	if h.github && githubCommand(record.Level) != "" {
+		line = escapeGitHubCommand(line)
+	}
+	return h.out.writeString(line + "\n")
 }
 
-func (h prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	cloned := h
-	cloned.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	var b strings.Builder
+	for _, attr := range attrs {
+		appendAttr(&b, h.groupPrefix, attr)
+	}
+	cloned := *h
+	cloned.preformatted = h.preformatted + b.String()
 	return &cloned
 }
 
-func (h prettyHandler) WithGroup(name string) slog.Handler {
-	cloned := h
-	if cloned.group == "" {
-		cloned.group = name
-	} else {
-		cloned.group = cloned.group + "." + name
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
 	}
+	cloned := *h
+	cloned.groupPrefix = h.groupPrefix + name + "."
 	return &cloned
 }
 
-func appendAttr(b *strings.Builder, group string, attr slog.Attr) {
+func (h *prettyHandler) paint(color, s string) string {
+	if !h.color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func appendAttr(b *strings.Builder, prefix string, attr slog.Attr) {
 	attr.Value = attr.Value.Resolve()
 	if attr.Equal(slog.Attr{}) {
 		return
 	}
-	b.WriteByte(' ')
-	if group != "" {
-		b.WriteString(group)
-		b.WriteByte('.')
+
+	if attr.Value.Kind() == slog.KindGroup {
+		members := attr.Value.Group()
+		if len(members) == 0 {
+			return
+		}
+		// Groups with an empty key are inlined into the enclosing group.
+		if attr.Key != "" {
+			prefix = prefix + attr.Key + "."
+		}
+		for _, member := range members {
+			appendAttr(b, prefix, member)
+		}
+		return
 	}
-	b.WriteString(attr.Key)
+
+	b.WriteByte(' ')
+	b.WriteString(formatAttrKey(prefix + attr.Key))
 	b.WriteByte('=')
-	b.WriteString(formatAttrValue(attr.Value.Any()))
+	b.WriteString(formatAttrValue(attr.Value))
 }
 
-func formatAttrValue(v any) string {
-	s := fmt.Sprint(v)
-	if strings.ContainsAny(s, " \t\n\r\"") {
+// formatAttrKey quotes keys that would otherwise be ambiguous when the line
+// is split back into key=value pairs.
+func formatAttrKey(key string) string {
+	if key == "" || strings.ContainsAny(key, "= \t\n\r\"") {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+func formatAttrValue(v slog.Value) string {
+	var s string
+	switch v.Kind() {
+	case slog.KindTime:
+		s = v.Time().Format(time.RFC3339)
+	default:
+		s = v.String()
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\r\"=") {
 		return strconv.Quote(s)
 	}
 	return s
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{input: "", want: slog.LevelInfo},
+		{input: "debug", want: slog.LevelDebug},
+		{input: "INFO", want: slog.LevelInfo},
+		{input: "warn", want: slog.LevelWarn},
+		{input: "warning", want: slog.LevelWarn},
+		{input: "error", want: slog.LevelError},
+		{input: "verbose", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseLogLevel(tc.input)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResolveLoggerOptionsPrefersFlagsOverEnvironment(t *testing.T) {
+	t.Setenv(envLogLevel, "error")
+	t.Setenv(envLogFormat, "json")
+
+	opts, err := resolveLoggerOptions(&bytes.Buffer{}, logFlags{})
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelError, opts.level)
+	assert.Equal(t, logFormatJSON, opts.format)
+
+	opts, err = resolveLoggerOptions(&bytes.Buffer{}, logFlags{level: "debug", format: "text"})
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, opts.level)
+	assert.Equal(t, logFormatText, opts.format)
+
+	_, err = resolveLoggerOptions(&bytes.Buffer{}, logFlags{format: "xml"})
+	require.ErrorContains(t, err, "invalid log format")
+}
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		noColor    string
+		forceColor string
+		want       bool
+	}{
+		{name: "non terminal writer has no colors"},
+		{name: "force color enables colors", forceColor: "1", want: true},
+		{name: "force color zero keeps colors off", forceColor: "0"},
+		{name: "no color wins over force color", noColor: "1", forceColor: "1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envNoColor, tc.noColor)
+			t.Setenv(envForceColor, tc.forceColor)
+			assert.Equal(t, tc.want, useColor(&bytes.Buffer{}))
+		})
+	}
+}
+
+func TestNewLoggerFormats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pretty without colors", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		newLogger(&buf, loggerOptions{format: logFormatPretty}).Info("hello", "key", "value")
+		assert.NotContains(t, buf.String(), "\x1b[")
+		assert.Contains(t, buf.String(), " INF hello key=value\n")
+	})
+
+	t.Run("level filters records", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		logger := newLogger(&buf, loggerOptions{level: slog.LevelWarn, format: logFormatPretty})
+		logger.Info("hidden")
+		logger.Warn("shown")
+		assert.NotContains(t, buf.String(), "hidden")
+		assert.Contains(t, buf.String(), "WRN shown")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		newLogger(&buf, loggerOptions{format: logFormatJSON}).Info("hello", "key", "value")
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "hello", record["msg"])
+		assert.Equal(t, "value", record["key"])
+	})
+
+	t.Run("github", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		logger := newLogger(&buf, loggerOptions{level: slog.LevelDebug, format: logFormatGitHub})
+		logger.Info("plain", "key", "value")
+		logger.Warn("100% broken\nsecond line")
+		logger.Error("failed")
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Equal(t, []string{
+			"plain key=value",
+			"::warning::100%25 broken%0Asecond line",
+			"::error::failed",
+		}, lines)
+	})
+}
+
+func TestRootCommandRejectsInvalidLogLevel(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"--log-level", "loud",
+		"matrix",
+		"--input", matrixConfigPath(t),
+	})
+	require.ErrorContains(t, err, "invalid log level")
+}
+
+func TestPrettyHandlerSlogtest(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		buf.Reset()
+		return newPrettyHandler(&buf, loggerOptions{level: slog.LevelDebug})
+	}, func(t *testing.T) map[string]any {
+		return parsePrettyLine(t, strings.TrimSuffix(buf.String(), "\n"))
+	})
+}
+
+func TestPrettyHandlerGroups(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(newPrettyHandler(&buf, loggerOptions{}))
+	logger.With("before", 1).WithGroup("outer").With("mid", 2).WithGroup("inner").Info("msg",
+		"leaf", 3,
+		slog.Group("nested", "x", "y"),
+		slog.Group("", "inlined", true),
+		slog.Group("empty"),
+	)
+
+	assert.Contains(t, buf.String(), " INF msg before=1 outer.mid=2 outer.inner.leaf=3 outer.inner.nested.x=y outer.inner.inlined=true\n")
+	assert.NotContains(t, buf.String(), "empty")
+}
+
+func TestPrettyHandlerEscapesKeysAndValues(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slog.New(newPrettyHandler(&buf, loggerOptions{})).Info("msg", "a=b", "c=d", "path", "with space")
+
+	assert.Contains(t, buf.String(), ` "a=b"="c=d" path="with space"`)
+}
+
+func TestPrettyHandlerAddSource(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slog.New(newPrettyHandler(&buf, loggerOptions{addSource: true})).Info("msg")
+
+	assert.Regexp(t, `source=extbuild/logging_test\.go:\d+\n$`, buf.String())
+}
+
+func TestPrettyHandlerClonesShareWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	base := newPrettyHandler(&buf, loggerOptions{})
+	handlers := []slog.Handler{
+		base,
+		base.WithAttrs([]slog.Attr{slog.String("k", "v")}),
+		base.WithGroup("g").WithAttrs([]slog.Attr{slog.Int("n", 1)}),
+	}
+	for _, h := range handlers {
+		assert.Same(t, base.out, h.(*prettyHandler).out)
+	}
+
+	const perHandler = 50
+	var wg sync.WaitGroup
+	for _, h := range handlers {
+		wg.Add(1)
+		go func(logger *slog.Logger) {
+			defer wg.Done()
+			for range perHandler {
+				logger.Info("concurrent")
+			}
+		}(slog.New(h))
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, perHandler*len(handlers))
+	for _, line := range lines {
+		assert.Regexp(t, `^\d\d:\d\d INF concurrent( k=v| g\.n=1)?$`, line)
+	}
+}
+
+var prettyTimePattern = regexp.MustCompile(`^\d\d:\d\d$`)
+
+// parsePrettyLine converts a prettyHandler line back into the nested map
+// layout expected by testing/slogtest.
+func parsePrettyLine(t *testing.T, line string) map[string]any {
+	t.Helper()
+
+	result := map[string]any{}
+	tokens := splitPrettyTokens(t, line)
+	if len(tokens) > 0 && prettyTimePattern.MatchString(tokens[0]) {
+		result[slog.TimeKey] = tokens[0]
+		tokens = tokens[1:]
+	}
+	require.NotEmpty(t, tokens, "missing level in %q", line)
+	result[slog.LevelKey] = tokens[0]
+	tokens = tokens[1:]
+	if len(tokens) > 0 && !strings.Contains(tokens[0], "=") {
+		result[slog.MessageKey] = tokens[0]
+		tokens = tokens[1:]
+	}
+
+	for _, token := range tokens {
+		key, value := splitPrettyAttr(t, token)
+		parts := strings.Split(key, ".")
+		group := result
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := group[part].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				group[part] = next
+			}
+			group = next
+		}
+		group[parts[len(parts)-1]] = value
+	}
+	return result
+}
+
+func splitPrettyTokens(t *testing.T, line string) []string {
+	t.Helper()
+
+	var tokens []string
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		end := 0
+		for end < len(line) && line[end] != ' ' {
+			if line[end] == '"' {
+				quoted, err := strconv.QuotedPrefix(line[end:])
+				require.NoError(t, err)
+				end += len(quoted)
+				continue
+			}
+			end++
+		}
+		tokens = append(tokens, line[:end])
+		line = line[end:]
+	}
+	return tokens
+}
+
+func splitPrettyAttr(t *testing.T, token string) (string, string) {
+	t.Helper()
+
+	key := token
+	if strings.HasPrefix(token, `"`) {
+		quoted, err := strconv.QuotedPrefix(token)
+		require.NoError(t, err)
+		key, err = strconv.Unquote(quoted)
+		require.NoError(t, err)
+		token = token[len(quoted):]
+	} else {
+		idx := strings.IndexByte(token, '=')
+		require.GreaterOrEqual(t, idx, 0, "attribute %q has no value", token)
+		key, token = token[:idx], token[idx:]
+	}
+
+	value := strings.TrimPrefix(token, "=")
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		require.NoError(t, err)
+		value = unquoted
+	}
+	return key, value
+}
@@ -1,13 +1,32 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
 
+// exitCodeError makes extbuild exit with the exit code of a command it ran
+// instead of 1.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
+func (e *exitCodeError) Unwrap() error { return e.err }
+
 func main() {
-	if err := newRootCommand().Execute(); err != nil {
+	cmd := newRootCommand()
+	cmd.SetArgs(expandLegacyFlags(cmd, os.Args[1:]))
+	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		code := 1
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		}
+		os.Exit(code)
 	}
 }
@@ -37,22 +37,14 @@ func newMatrixCommand() *cobra.Command {
 			}
 			commandLogger(cmd).Info("Detected GitHub event type", "event_type", eventType)
 
-			reducedCIMode, err := distmatrix.ParseReducedCIMode(reducedCIModeRaw)
+			reducedCIMode, err := resolveReducedCIMode(cmd, eventType, reducedCIModeRaw)
 			if err != nil {
 				return err
 			}
-			if eventType == githubEventPullRequest && reducedCIMode == distmatrix.ReducedCIAuto {
-				reducedCIMode = distmatrix.ReducedCIEnabled
-				commandLogger(cmd).Info("Enabled reduced CI mode for pull_request event when mode is auto")
-			}
 
-			data, err := os.ReadFile(inputPath)
-			if err != nil {
-				return fmt.Errorf("read input matrix %q: %w", inputPath, err)
-			}
-			matrix, err := distmatrix.ParseMatrixFile(data)
+			matrix, err := loadMatrixFile(inputPath)
 			if err != nil {
-				return fmt.Errorf("parse input matrix %q: %w", inputPath, err)
+				return err
 			}
 
 			result, err := distmatrix.ComputePlatformMatrices(matrix, distmatrix.ComputeOptions{
@@ -112,3 +104,17 @@ func newMatrixCommand() *cobra.Command {
 
 	return cmd
 }
+
+// resolveReducedCIMode parses the reduced CI mode and enables it for
+// pull_request events when it is left on auto.
+func resolveReducedCIMode(cmd *cobra.Command, eventType, raw string) (distmatrix.ReducedCIMode, error) {
+	reducedCIMode, err := distmatrix.ParseReducedCIMode(raw)
+	if err != nil {
+		return "", err
+	}
+	if eventType == githubEventPullRequest && reducedCIMode == distmatrix.ReducedCIAuto {
+		reducedCIMode = distmatrix.ReducedCIEnabled
+		commandLogger(cmd).Info("Enabled reduced CI mode for pull_request event when mode is auto")
+	}
+	return reducedCIMode, nil
+}
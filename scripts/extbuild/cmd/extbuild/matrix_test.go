@@ -397,7 +397,7 @@ func executeRootCommandWithResult(t *testing.T, args []string) (string, string,
 	var stderr bytes.Buffer
 	cmd.SetOut(&stdout)
 	cmd.SetErr(&stderr)
-	cmd.SetArgs(args)
+	cmd.SetArgs(expandLegacyFlags(cmd, args))
 	err := cmd.Execute()
 	return stdout.String(), stderr.String(), err
 }
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/spf13/cobra"
+)
+
+// legacyMetadataFlags maps the multi-letter single-dash flags accepted by
+// append_extension_metadata.py, which pflag cannot express as shorthands, to
+// their long names.
+var legacyMetadataFlags = map[string]string{
+	"-pf":  "--duckdb-platform-file",
+	"-dv":  "--duckdb-version",
+	"-ev":  "--extension-version",
+	"-evf": "--extension-version-file",
+}
+
+// expandLegacyFlags rewrites the legacy flags of "metadata append" in args,
+// in both the "-dv v1.2.0" and "-dv=v1.2.0" forms, so existing Makefile
+// invocations keep working when they switch from the Python script to
+// extbuild. root resolves the subcommand, which skips the persistent flags in
+// front of it. The arguments of other commands, and everything after "--",
+// are left alone.
+func expandLegacyFlags(root *cobra.Command, args []string) []string {
+	sub, _, err := root.Find(args)
+	if err != nil || sub.CommandPath() != root.Name()+" metadata append" {
+		return args
+	}
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--" {
+			return append(expanded, args[len(expanded):]...)
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if long, ok := legacyMetadataFlags[name]; ok {
+			if hasValue {
+				arg = long + "=" + value
+			} else {
+				arg = long
+			}
+		}
+		expanded = append(expanded, arg)
+	}
+	return expanded
+}
+
+func newMetadataCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metadata",
+		Short: "Read and write the metadata footer of DuckDB extension binaries",
+	}
+	cmd.AddCommand(newMetadataAppendCommand())
+	cmd.AddCommand(newMetadataInspectCommand())
+	cmd.AddCommand(newMetadataSetCommand())
+	return cmd
+}
+
+func newMetadataAppendCommand() *cobra.Command {
+	var (
+		libraryPath          string
+		extensionName        string
+		outPath              string
+		duckdbPlatform       string
+		duckdbPlatformPath   string
+		duckdbVersion        string
+		extensionVersion     string
+		extensionVersionPath string
+		abiType              string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "append",
+		Short: "Append the metadata footer to a shared library (replaces append_extension_metadata.py)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := metadata.CheckABIType(abiType); err != nil {
+				return err
+			}
+			if outPath == "" {
+				outPath = extensionName + ".duckdb_extension"
+			}
+
+			platform, err := valueOrFile(duckdbPlatform, duckdbPlatformPath, "platform", "--duckdb-platform", "--duckdb-platform-file")
+			if err != nil {
+				return err
+			}
+			version, err := valueOrFile(extensionVersion, extensionVersionPath, "extension version", "--extension-version", "--extension-version-file")
+			if err != nil {
+				return err
+			}
+
+			meta := metadata.Metadata{
+				ABIType:          abiType,
+				ExtensionVersion: version,
+				DuckDBVersion:    duckdbVersion,
+				DuckDBPlatform:   platform,
+			}
+			if err := metadata.AppendFile(libraryPath, outPath, meta); err != nil {
+				return fmt.Errorf("create extension binary %q: %w", outPath, err)
+			}
+
+			commandLogger(cmd).Info("Created extension binary",
+				"input", libraryPath,
+				"output", outPath,
+				"abi_type", meta.ABIType,
+				"extension_version", meta.ExtensionVersion,
+				"duckdb_version", meta.DuckDBVersion,
+				"duckdb_platform", meta.DuckDBPlatform,
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&libraryPath, "library-file", "l", "", "Path to the raw shared library")
+	cmd.Flags().StringVarP(&extensionName, "extension-name", "n", "", "Extension name to use")
+	cmd.Flags().StringVarP(&outPath, "out-file", "o", "", "Explicit path for the output file (default <extension-name>.duckdb_extension)")
+	cmd.Flags().StringVarP(&duckdbPlatform, "duckdb-platform", "p", "", "The DuckDB platform to encode")
+	cmd.Flags().StringVar(&duckdbPlatformPath, "duckdb-platform-file", "", "The file containing the DuckDB platform to encode (alias -pf)")
+	cmd.Flags().StringVar(&duckdbVersion, "duckdb-version", "", "The DuckDB version, or C API version depending on the ABI type, to encode (alias -dv)")
+	cmd.Flags().StringVar(&extensionVersion, "extension-version", "", "The extension version to encode (alias -ev)")
+	cmd.Flags().StringVar(&extensionVersionPath, "extension-version-file", "", "The file containing the extension version to encode (alias -evf)")
+	cmd.Flags().StringVar(&abiType, "abi-type", metadata.ABITypeCStruct, "The ABI type to encode: "+strings.Join(metadata.ABITypes, "|"))
+	_ = cmd.MarkFlagRequired("library-file")
+	_ = cmd.MarkFlagRequired("extension-name")
+	_ = cmd.MarkFlagRequired("duckdb-version")
+
+	return cmd
+}
+
+func newMetadataInspectCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "inspect <extension-file>",
+		Short: "Decode the metadata footer of a .duckdb_extension or .duckdb_extension.wasm file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			info, err := metadata.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("inspect %q: %w", path, err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				signature := "empty"
+				if info.Signed {
+					signature = "filled"
+				}
+				_, _ = fmt.Fprintf(out, "file:              %s\n", path)
+				_, _ = fmt.Fprintf(out, "format:            %s\n", info.Format)
+				_, _ = fmt.Fprintf(out, "duckdb_platform:   %s\n", info.DuckDBPlatform)
+				_, _ = fmt.Fprintf(out, "duckdb_version:    %s\n", info.DuckDBVersion)
+				_, _ = fmt.Fprintf(out, "extension_version: %s\n", info.ExtensionVersion)
+				_, _ = fmt.Fprintf(out, "abi_type:          %s\n", info.ABIType)
+				_, _ = fmt.Fprintf(out, "signature:         %s\n", signature)
+			case "json":
+				payload, err := json.MarshalIndent(struct {
+					Path string `json:"path"`
+					metadata.Info
+				}{Path: path, Info: info}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render metadata: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			default:
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+
+	return cmd
+}
+
+func newMetadataSetCommand() *cobra.Command {
+	var (
+		extensionVersion string
+		duckdbPlatform   string
+		abiType          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set <extension-file>",
+		Short: "Replace metadata footer fields of an already stamped extension binary",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			var changes metadata.Changes
+			if cmd.Flags().Changed("extension-version") {
+				changes.ExtensionVersion = &extensionVersion
+			}
+			if cmd.Flags().Changed("duckdb-platform") {
+				changes.DuckDBPlatform = &duckdbPlatform
+			}
+			if cmd.Flags().Changed("abi-type") {
+				if err := metadata.CheckABIType(abiType); err != nil {
+					return err
+				}
+				changes.ABIType = &abiType
+			}
+
+			previous, err := metadata.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("update %q: %w", path, err)
+			}
+			info, changed, err := metadata.UpdateFile(path, changes)
+			if err != nil {
+				return fmt.Errorf("update %q: %w", path, err)
+			}
+
+			logger := commandLogger(cmd)
+			if !changed {
+				logger.Info("Extension metadata is already up to date", "file", path)
+				return nil
+			}
+			if previous.Signed {
+				logger.Warn("Cleared the signature of the updated extension, sign it again before distributing it", "file", path)
+			}
+			logger.Info("Updated extension metadata",
+				"file", path,
+				"abi_type", info.ABIType,
+				"extension_version", info.ExtensionVersion,
+				"duckdb_platform", info.DuckDBPlatform,
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&extensionVersion, "extension-version", "", "The extension version to encode")
+	cmd.Flags().StringVar(&duckdbPlatform, "duckdb-platform", "", "The DuckDB platform to encode")
+	cmd.Flags().StringVar(&abiType, "abi-type", "", "The ABI type to encode: "+strings.Join(metadata.ABITypes, "|"))
+	cmd.MarkFlagsOneRequired("extension-version", "duckdb-platform", "abi-type")
+
+	return cmd
+}
+
+// valueOrFile returns value when set, or the trimmed content of path
+// otherwise, mirroring how append_extension_metadata.py resolves the platform
+// and extension version.
+func valueOrFile(value, path, what, valueFlag, fileFlag string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if path == "" {
+		return "", fmt.Errorf("neither %s nor %s is set, please specify the %s using either", valueFlag, fileFlag, what)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s from file: %w", what, err)
+	}
+	value = strings.TrimSpace(string(data))
+	if value == "" {
+		return "", errors.New(what + " file is empty: " + path)
+	}
+	return value, nil
+}
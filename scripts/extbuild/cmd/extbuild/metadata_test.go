@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandLegacyFlags(t *testing.T) {
+	t.Parallel()
+
+	got := expandLegacyFlags(newRootCommand(), []string{
+		"metadata", "append", "-l", "lib.so", "-n", "quack",
+		"-dv", "v1.2.0", "-evf=configure/extension_version.txt", "-pf", "configure/platform.txt",
+		"--", "-ev",
+	})
+	assert.Equal(t, []string{
+		"metadata", "append", "-l", "lib.so", "-n", "quack",
+		"--duckdb-version", "v1.2.0", "--extension-version-file=configure/extension_version.txt", "--duckdb-platform-file", "configure/platform.txt",
+		"--", "-ev",
+	}, got)
+
+	got = expandLegacyFlags(newRootCommand(), []string{
+		"--log-level", "debug", "--log-source", "--log-format=json", "metadata", "append", "-dv", "v1.2.0", "-pf", "platform.txt",
+	})
+	assert.Equal(t, []string{
+		"--log-level", "debug", "--log-source", "--log-format=json", "metadata", "append", "--duckdb-version", "v1.2.0", "--duckdb-platform-file", "platform.txt",
+	}, got)
+
+	for _, args := range [][]string{
+		{"retry", "--", "tool", "-dv", "x"},
+		{"metadata", "set", "quack.duckdb_extension", "-ev", "v1.0.0"},
+		{"--log-level", "debug", "metadata", "set", "quack.duckdb_extension", "-ev", "v1.0.0"},
+		{"no-such-command", "-dv", "x"},
+	} {
+		assert.Equal(t, args, expandLegacyFlags(newRootCommand(), args))
+	}
+}
+
+func TestMetadataAppendSubcommandMatchesPythonScript(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	platformPath := filepath.Join(dir, "platform.txt")
+	versionPath := filepath.Join(dir, "extension_version.txt")
+	require.NoError(t, os.WriteFile(platformPath, []byte("osx_arm64\n"), 0o600))
+	require.NoError(t, os.WriteFile(versionPath, []byte("  v0.2.0-3-gabcdef1\n"), 0o600))
+	outPath := filepath.Join(dir, "quack.duckdb_extension")
+
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"--log-level", "debug", "metadata", "append",
+		"-l", metadataTestdataPath(t, "libquack.so"),
+		"-o", outPath,
+		"-n", "quack",
+		"-dv", "v1.2.0",
+		"-evf", versionPath,
+		"-pf", platformPath,
+		"--abi-type", "C_STRUCT_UNSTABLE",
+	})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	want, err := os.ReadFile(metadataTestdataPath(t, "quack_unstable.duckdb_extension.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestMetadataAppendSubcommandRequiresPlatform(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"metadata", "append",
+		"--library-file", metadataTestdataPath(t, "libquack.so"),
+		"--out-file", filepath.Join(t.TempDir(), "quack.duckdb_extension"),
+		"--extension-name", "quack",
+		"--duckdb-version", "v1.2.0",
+		"--extension-version", "v0.1.0",
+	})
+	require.ErrorContains(t, err, "neither --duckdb-platform nor --duckdb-platform-file is set")
+}
+
+func TestMetadataAppendSubcommandRejectsEmptyVersionFile(t *testing.T) {
+	t.Parallel()
+
+	versionPath := filepath.Join(t.TempDir(), "extension_version.txt")
+	require.NoError(t, os.WriteFile(versionPath, []byte("\n"), 0o600))
+
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"metadata", "append",
+		"--library-file", metadataTestdataPath(t, "libquack.so"),
+		"--out-file", filepath.Join(t.TempDir(), "quack.duckdb_extension"),
+		"--extension-name", "quack",
+		"--duckdb-version", "v1.2.0",
+		"--duckdb-platform", "linux_amd64",
+		"--extension-version-file", versionPath,
+	})
+	require.ErrorContains(t, err, "extension version file is empty")
+}
+
+func metadataTestdataPath(t *testing.T, name string) string {
+	t.Helper()
+	return filepath.Join(moduleRootPath(t), "testdata", "metadata", name)
+}
+
+func TestMetadataInspectSubcommandText(t *testing.T) {
+	t.Parallel()
+
+	path := metadataTestdataPath(t, "quack.duckdb_extension.wasm.golden")
+	stdout := executeRootCommand(t, []string{"metadata", "inspect", path})
+	assert.Equal(t, "file:              "+path+"\n"+
+		"format:            wasm\n"+
+		"duckdb_platform:   wasm_eh\n"+
+		"duckdb_version:    v1.2.0\n"+
+		"extension_version: v0.1.0\n"+
+		"abi_type:          C_STRUCT\n"+
+		"signature:         empty\n", stdout)
+}
+
+func TestMetadataInspectSubcommandJSON(t *testing.T) {
+	t.Parallel()
+
+	path := metadataTestdataPath(t, "quack_unstable.duckdb_extension.golden")
+	stdout := executeRootCommand(t, []string{"metadata", "inspect", "--format", "json", path})
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, map[string]any{
+		"path":              path,
+		"format":            "native",
+		"duckdb_platform":   "osx_arm64",
+		"duckdb_version":    "v1.2.0",
+		"extension_version": "v0.2.0-3-gabcdef1",
+		"abi_type":          "C_STRUCT_UNSTABLE",
+		"signed":            false,
+	}, got)
+}
+
+func TestMetadataInspectSubcommandRejectsPlainLibrary(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"metadata", "inspect", metadataTestdataPath(t, "libquack.so")})
+	require.ErrorContains(t, err, "no DuckDB extension metadata footer")
+}
+
+func TestMetadataSetSubcommandPromotesNightlyBuild(t *testing.T) {
+	t.Parallel()
+
+	golden, err := os.ReadFile(metadataTestdataPath(t, "quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, golden, 0o600))
+
+	_, _, err = executeRootCommandWithResult(t, []string{"metadata", "set", path, "--extension-version", "v1.0.0", "--abi-type", "C_STRUCT_UNSTABLE"})
+	require.NoError(t, err)
+
+	stdout := executeRootCommand(t, []string{"metadata", "inspect", "--format", "json", path})
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, "v1.0.0", got["extension_version"])
+	assert.Equal(t, "C_STRUCT_UNSTABLE", got["abi_type"])
+	assert.Equal(t, "linux_amd64", got["duckdb_platform"])
+}
+
+func TestMetadataSubcommandsRejectUnknownABIType(t *testing.T) {
+	t.Parallel()
+
+	golden, err := os.ReadFile(metadataTestdataPath(t, "quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, golden, 0o600))
+
+	_, _, err = executeRootCommandWithResult(t, []string{"metadata", "set", path, "--abi-type", "C_STRUCT_UNSTALBE"})
+	require.EqualError(t, err, `invalid ABI type: "C_STRUCT_UNSTALBE" (must be C_STRUCT|C_STRUCT_UNSTABLE|CPP)`)
+	unchanged, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, golden, unchanged)
+
+	outPath := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	_, _, err = executeRootCommandWithResult(t, []string{
+		"metadata", "append", "-l", metadataTestdataPath(t, "libquack.so"), "-n", "quack", "-o", outPath,
+		"--duckdb-version", "v1.2.0", "--extension-version", "v0.1.0", "--duckdb-platform", "linux_amd64", "--abi-type", "cpp",
+	})
+	require.EqualError(t, err, `invalid ABI type: "cpp" (must be C_STRUCT|C_STRUCT_UNSTABLE|CPP)`)
+	assert.NoFileExists(t, outPath)
+}
+
+func TestMetadataSetSubcommandRequiresAField(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"metadata", "set", metadataTestdataPath(t, "quack.duckdb_extension.golden")})
+	require.ErrorContains(t, err, "at least one of the flags in the group [extension-version duckdb-platform abi-type] is required")
+}
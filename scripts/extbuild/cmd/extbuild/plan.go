@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/duckdb/extension-ci-tools/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+// planFlags are the flags that select the event, inputs and matrix a plan is
+// resolved for.
+type planFlags struct {
+	inputsJSON string
+	inputsPath string
+	matrixPath string
+}
+
+func (f *planFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.inputsJSON, "inputs", "", "Workflow inputs as a JSON object")
+	cmd.Flags().StringVar(&f.inputsPath, "inputs-file", "", "Path to a JSON file with the workflow inputs")
+	cmd.Flags().StringVar(&f.matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.MarkFlagsMutuallyExclusive("inputs", "inputs-file")
+}
+
+// build resolves the plan for event.
+func (f *planFlags) build(cmd *cobra.Command, event plan.Event) (plan.Plan, error) {
+	in := inputs.Inputs{}
+	if f.inputsJSON != "" || f.inputsPath != "" {
+		var err error
+		in, err = loadWorkflowInputs(f.inputsJSON, f.inputsPath)
+		if err != nil {
+			return plan.Plan{}, err
+		}
+	}
+
+	reducedCIMode, err := resolveReducedCIMode(cmd, event.Type, in.String("reduced_ci_mode"))
+	if err != nil {
+		return plan.Plan{}, err
+	}
+
+	matrix, err := loadMatrixFile(f.matrixPath)
+	if err != nil {
+		return plan.Plan{}, err
+	}
+	matrices, err := distmatrix.ComputePlatformMatrices(matrix, distmatrix.ComputeOptions{
+		Exclude:       in.String("exclude_archs"),
+		OptIn:         in.String("opt_in_archs"),
+		ReducedCIMode: reducedCIMode,
+		RunnerJSON:    in.String("runners"),
+	})
+	if err != nil {
+		return plan.Plan{}, fmt.Errorf("compute platform matrices: %w", err)
+	}
+
+	return plan.Build(plan.Options{
+		Event:         event,
+		Inputs:        in,
+		ReducedCIMode: reducedCIMode,
+		Matrices:      matrices,
+	})
+}
+
+func newPlanCommand() *cobra.Command {
+	var (
+		flags      planFlags
+		eventPath  string
+		duckdbArch string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Print the jobs and steps the distribution pipeline would run for an event",
+		Long: `Resolves the jobs of the distribution pipeline and their ordered steps, each
+with its command, environment and working directory. With --format shell the
+command steps are rendered as a bash script, and --duckdb-arch narrows the plan
+to the jobs of one matrix entry.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" && format != "shell" {
+				return fmt.Errorf("invalid format: %q (must be text|json|shell)", format)
+			}
+
+			event, err := readGitHubEventFile(eventPath)
+			if err != nil {
+				return fmt.Errorf("read GitHub event %q: %w", eventPath, err)
+			}
+			commandLogger(cmd).Info("Detected GitHub event type", "event_type", event.Type)
+
+			p, err := flags.build(cmd, event)
+			if err != nil {
+				return err
+			}
+			if duckdbArch != "" {
+				p = p.ForArch(duckdbArch)
+				if len(p.Jobs) == 0 {
+					return fmt.Errorf("duckdb_arch %q is not part of the computed matrix", duckdbArch)
+				}
+			}
+
+			var rendered string
+			switch format {
+			case "text":
+				rendered = plan.RenderText(p)
+			case "json":
+				rendered, err = plan.RenderJSON(p)
+				if err != nil {
+					return fmt.Errorf("render plan: %w", err)
+				}
+			case "shell":
+				rendered = plan.RenderShell(p)
+			}
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), rendered)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&eventPath, "event", "", "Path to a GitHub event payload JSON file")
+	flags.register(cmd)
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "Only include the jobs for this duckdb_arch")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json|shell")
+	_ = cmd.MarkFlagRequired("event")
+
+	return cmd
+}
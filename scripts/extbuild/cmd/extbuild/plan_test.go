@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanSubcommandPushEvent(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"plan",
+		"--event", fixturePath(t, "extension_template_push.json"),
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4","exclude_archs":"windows_amd64_mingw"}`,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, stdout, "event: push (refs/heads/main)\nreduced CI mode: auto\n")
+	assert.Contains(t, stdout, "\nmacos (osx_arm64)\n  runs-on: ")
+	assert.Contains(t, stdout, "     env: DUCKDB_GIT_VERSION=v1.5.4\n     $ make set_duckdb_version\n")
+	assert.Contains(t, stdout, "linux_amd64 duckdb-extensions-nightly true false\n")
+	assert.NotContains(t, stdout, "(windows_amd64_mingw)")
+}
+
+func TestPlanSubcommandPullRequestUsesReducedCI(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"plan",
+		"--event", fixturePath(t, "extension_template_pull_request.json"),
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4"}`,
+		"--format", "json",
+	})
+	require.NoError(t, err)
+
+	var p plan.Plan
+	require.NoError(t, json.Unmarshal([]byte(stdout), &p))
+	assert.Equal(t, plan.Event{Type: githubEventPullRequest}, p.Event)
+	assert.Equal(t, "enabled", p.ReducedCIMode)
+	for _, job := range p.Jobs {
+		assert.NotEqual(t, "macos", job.ID, "osx is skipped in reduced CI mode")
+	}
+}
+
+func TestPlanSubcommandShellForArch(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"plan",
+		"--event", fixturePath(t, "extension_template_push.json"),
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4"}`,
+		"--duckdb-arch", "wasm_eh",
+		"--format", "shell",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, stdout, "\n# Job wasm (wasm_eh), runs on ubuntu-latest\n")
+	assert.Contains(t, stdout, "\n# Job deploy (wasm_eh), runs on ubuntu-latest\n")
+	assert.NotContains(t, stdout, "generate_matrix")
+	assert.NotContains(t, stdout, "wasm_mvp")
+
+	_, _, err = executeRootCommandWithResult(t, []string{
+		"plan",
+		"--event", fixturePath(t, "extension_template_push.json"),
+		"--matrix", matrixConfigPath(t),
+		"--duckdb-arch", "solaris_sparc",
+	})
+	require.EqualError(t, err, `duckdb_arch "solaris_sparc" is not part of the computed matrix`)
+}
+
+func TestPlanSubcommandRequiresEvent(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"plan", "--matrix", matrixConfigPath(t)})
+	require.ErrorContains(t, err, `required flag(s) "event" not set`)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/duckdb/extension-ci-tools/internal/retry"
+	"github.com/spf13/cobra"
+)
+
+func newRetryCommand() *cobra.Command {
+	var (
+		attempts          int
+		delay             time.Duration
+		maxDelay          time.Duration
+		timeout           time.Duration
+		patterns          []string
+		noDefaultPatterns bool
+		anyFailure        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "retry [flags] -- command [args...]",
+		Short: "Run a command again when it fails with a transient error",
+		Long: `Runs a command and, when it fails with output that matches a transient error
+pattern, such as a connection reset or a failed vcpkg download, runs it again
+up to --attempts times. The delay between attempts starts at --delay, doubles
+up to --max-delay and has jitter of up to half of it. --timeout limits each
+attempt, and an attempt that runs into it is retried.
+
+--pattern adds regular expressions to the default patterns, which
+--no-default-patterns drops. --any-failure retries every failure. Interrupt
+and terminate signals are passed to the command, which is not retried after
+one of them. extbuild exits with the exit code of the last attempt, 128+N
+when a signal N ended it.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exprs := patterns
+			if !noDefaultPatterns {
+				exprs = append(append([]string(nil), retry.DefaultPatterns...), patterns...)
+			}
+			compiled, err := retry.CompilePatterns(exprs)
+			if err != nil {
+				return err
+			}
+			if anyFailure {
+				compiled = nil
+			} else if len(compiled) == 0 {
+				return errors.New("no transient error patterns: pass --pattern or --any-failure")
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(signals)
+
+			err = retry.Run(cmp.Or(cmd.Context(), context.Background()), args[0], args[1:], retry.Options{
+				Attempts: attempts,
+				Delay:    delay,
+				MaxDelay: maxDelay,
+				Timeout:  timeout,
+				Patterns: compiled,
+				Signals:  signals,
+				Stdin:    cmd.InOrStdin(),
+				Stdout:   cmd.OutOrStdout(),
+				Stderr:   cmd.ErrOrStderr(),
+				Logger:   commandLogger(cmd),
+			})
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				cmd.SilenceUsage = true
+				return &exitCodeError{code: childExitCode(exitErr), err: err}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().IntVar(&attempts, "attempts", 3, "Maximum number of attempts")
+	cmd.Flags().DurationVar(&delay, "delay", 10*time.Second, "Delay before the second attempt")
+	cmd.Flags().DurationVar(&maxDelay, "max-delay", 2*time.Minute, "Maximum delay between attempts")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Timeout of each attempt, 0 for none")
+	cmd.Flags().StringArrayVar(&patterns, "pattern", nil, "Regular expression of a transient error in the output (repeatable)")
+	cmd.Flags().BoolVar(&noDefaultPatterns, "no-default-patterns", false, "Only use the --pattern expressions")
+	cmd.Flags().BoolVar(&anyFailure, "any-failure", false, "Retry every failure regardless of the output")
+	return cmd
+}
+
+// childExitCode returns the exit code of a command, using the shell
+// convention of 128+N for a command ended by signal N.
+func childExitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+}
+
+func TestRetrySubcommandWiresFlags(t *testing.T) {
+	requireShell(t)
+
+	_, stderr, err := executeRootCommandWithResult(t, []string{
+		"retry", "--attempts", "2", "--delay", "1ms", "--no-default-patterns", "--pattern", "napping",
+		"--", "sh", "-c", "echo quack server is napping >&2; exit 4",
+	})
+	require.EqualError(t, err, `all 2 attempts failed, the last output matched "napping": exit status 4`)
+	assert.Equal(t, 2, strings.Count(stderr, "quack server is napping"))
+
+	_, stderr, err = executeRootCommandWithResult(t, []string{
+		"retry", "--attempts", "3", "--delay", "1ms", "--any-failure", "sh", "-c", "echo failed >&2; exit 4",
+	})
+	require.ErrorContains(t, err, "all 3 attempts failed, the last any failure is retried")
+	assert.Equal(t, 3, strings.Count(stderr, "failed\n"))
+}
+
+func TestRetrySubcommandPropagatesExitCode(t *testing.T) {
+	requireShell(t)
+
+	_, _, err := executeRootCommandWithResult(t, []string{"retry", "--", "sh", "-c", "exit 2"})
+	var exitErr *exitCodeError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.code)
+
+	_, _, err = executeRootCommandWithResult(t, []string{"retry", "--", "sh", "-c", "kill -TERM $$"})
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 143, exitErr.code)
+}
+
+func TestRetrySubcommandRejectsInvalidFlags(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{"retry"})
+	require.EqualError(t, err, "requires at least 1 arg(s), only received 0")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"retry", "--pattern", "(", "true"})
+	require.EqualError(t, err, "invalid pattern \"(\": error parsing regexp: missing closing ): `(`")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"retry", "--no-default-patterns", "true"})
+	require.EqualError(t, err, "no transient error patterns: pass --pattern or --any-failure")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"retry", "--attempts", "0", "true"})
+	require.EqualError(t, err, "invalid attempts: 0 (must be at least 1)")
+}
@@ -5,13 +5,40 @@ import (
 )
 
 func newRootCommand() *cobra.Command {
+	var logging logFlags
+
 	cmd := &cobra.Command{
 		Use:   "extbuild",
 		Short: "DuckDB extension CI helper CLI",
-		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
-			attachCommandLogger(cmd)
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return attachCommandLogger(cmd, logging)
 		},
 	}
+
+	cmd.PersistentFlags().StringVar(&logging.level, "log-level", "", "Log level: debug|info|warn|error (default info, env "+envLogLevel+")")
+	cmd.PersistentFlags().StringVar(&logging.format, "log-format", "", "Log format: pretty|text|json|github (default pretty, env "+envLogFormat+")")
+	cmd.PersistentFlags().BoolVar(&logging.source, "log-source", false, "Include source locations in log records (env "+envLogSource+")")
+
 	cmd.AddCommand(newMatrixCommand())
+	cmd.AddCommand(newWorkflowCommand())
+	cmd.AddCommand(newInputsCommand())
+	cmd.AddCommand(newPlanCommand())
+	cmd.AddCommand(newRunStepCommand())
+	cmd.AddCommand(newMetadataCommand())
+	cmd.AddCommand(newSignCommand())
+	cmd.AddCommand(newVerifyCommand())
+	cmd.AddCommand(newVerifyPlatformCommand())
+	cmd.AddCommand(newAuditCommand())
+	cmd.AddCommand(newSizeCommand())
+	cmd.AddCommand(newCompatCommand())
+	cmd.AddCommand(newConfigureCommand())
+	cmd.AddCommand(newVersionCommand())
+	cmd.AddCommand(newCMakeArgsCommand())
+	cmd.AddCommand(newDockerCommand())
+	cmd.AddCommand(newToolchainsCommand())
+	cmd.AddCommand(newVCPKGCommand())
+	cmd.AddCommand(newTestConfigCommand())
+	cmd.AddCommand(newTestCommand())
+	cmd.AddCommand(newRetryCommand())
 	return cmd
 }
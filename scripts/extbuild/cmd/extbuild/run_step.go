@@ -0,0 +1,130 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+
+	"github.com/duckdb/extension-ci-tools/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+func newRunStepCommand() *cobra.Command {
+	var (
+		flags      planFlags
+		eventPath  string
+		jobID      string
+		duckdbArch string
+		stepID     string
+		dryRun     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run-step",
+		Short: "Run one step of the distribution plan with its environment and working directory",
+		Long: `Resolves the distribution plan like extbuild plan does and runs a single command
+step of it with bash, so workflow jobs run the planned commands instead of
+duplicating them inline. The event defaults to GITHUB_EVENT_PATH. Without --job
+the build job of --duckdb-arch is used.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			event := plan.Event{Type: githubEventUnknown}
+			if path := cmp.Or(eventPath, os.Getenv("GITHUB_EVENT_PATH")); path != "" {
+				var err error
+				event, err = readGitHubEventFile(path)
+				if err != nil {
+					return fmt.Errorf("read GitHub event %q: %w", path, err)
+				}
+			}
+
+			p, err := flags.build(cmd, event)
+			if err != nil {
+				return err
+			}
+			job, err := selectJob(p, jobID, duckdbArch)
+			if err != nil {
+				return err
+			}
+			step, ok := job.Step(stepID)
+			if !ok {
+				ids := make([]string, 0, len(job.Steps))
+				for _, s := range job.Steps {
+					ids = append(ids, s.ID)
+				}
+				return fmt.Errorf("job %s has no step %q (steps: %v)", jobLabel(job), stepID, ids)
+			}
+			if step.Command == "" {
+				return fmt.Errorf("step %q of job %s uses %s and only runs in GitHub Actions", step.ID, jobLabel(job), step.Uses)
+			}
+
+			if dryRun {
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), plan.RenderShell(plan.Plan{
+					Event: p.Event,
+					Jobs:  []plan.Job{{ID: job.ID, DuckDBArch: job.DuckDBArch, Runner: job.Runner, Steps: []plan.Step{step}}},
+				}))
+				return nil
+			}
+
+			commandLogger(cmd).Info("Running step", "job", jobLabel(job), "step", step.ID, "command", step.Command)
+			ctx := cmp.Or(cmd.Context(), context.Background())
+			run := exec.CommandContext(ctx, "bash", "-eo", "pipefail", "-c", step.Command)
+			run.Dir = step.WorkDir
+			run.Env = os.Environ()
+			for _, key := range slices.Sorted(maps.Keys(step.Env)) {
+				run.Env = append(run.Env, key+"="+step.Env[key])
+			}
+			run.Stdin = cmd.InOrStdin()
+			run.Stdout = cmd.OutOrStdout()
+			run.Stderr = cmd.ErrOrStderr()
+			if err := run.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					cmd.SilenceUsage = true
+				}
+				return fmt.Errorf("step %q of job %s failed: %w", step.ID, jobLabel(job), err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&eventPath, "event", "", "Path to a GitHub event payload JSON file (default $GITHUB_EVENT_PATH)")
+	flags.register(cmd)
+	cmd.Flags().StringVar(&jobID, "job", "", "Job ID, e.g. linux or deploy (default the build job of --duckdb-arch)")
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "duckdb_arch of the matrix entry the job runs for")
+	cmd.Flags().StringVar(&stepID, "step", "", "Step ID, e.g. configure, build or test")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the step as a shell script instead of running it")
+	_ = cmd.MarkFlagRequired("step")
+
+	return cmd
+}
+
+// selectJob finds the job to run a step of. Without an ID the first job for
+// duckdbArch is used, which is its build job.
+func selectJob(p plan.Plan, jobID, duckdbArch string) (plan.Job, error) {
+	if jobID == "" {
+		if duckdbArch == "" {
+			return plan.Job{}, errors.New("--job or --duckdb-arch is required")
+		}
+		if jobs := p.ForArch(duckdbArch).Jobs; len(jobs) > 0 {
+			return jobs[0], nil
+		}
+		return plan.Job{}, fmt.Errorf("duckdb_arch %q is not part of the computed matrix", duckdbArch)
+	}
+	job, ok := p.Job(jobID, duckdbArch)
+	if !ok {
+		return plan.Job{}, fmt.Errorf("the plan has no job %s", jobLabel(plan.Job{ID: jobID, DuckDBArch: duckdbArch}))
+	}
+	return job, nil
+}
+
+func jobLabel(job plan.Job) string {
+	if job.DuckDBArch == "" {
+		return job.ID
+	}
+	return job.ID + " (" + job.DuckDBArch + ")"
+}
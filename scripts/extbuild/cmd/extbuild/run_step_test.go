@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStepSubcommandRunsCommand(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	matrix := matrixConfigPath(t)
+	t.Chdir(t.TempDir())
+	t.Setenv("GITHUB_EVENT_PATH", "")
+
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"run-step",
+		"--matrix", matrix,
+		"--inputs", `{"extension_name":"quack","post_build_command":"echo built > marker.txt"}`,
+		"--duckdb-arch", "linux_amd64",
+		"--step", "post-build",
+	})
+	require.NoError(t, err)
+	data, err := os.ReadFile("marker.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "built\n", string(data))
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"run-step",
+		"--matrix", matrix,
+		"--inputs", `{"extension_name":"quack","post_build_command":"exit 3"}`,
+		"--duckdb-arch", "linux_amd64",
+		"--step", "post-build",
+	})
+	require.EqualError(t, err, `step "post-build" of job linux (linux_amd64) failed: exit status 3`)
+	assert.Empty(t, stdout)
+}
+
+func TestRunStepSubcommandDryRun(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"run-step",
+		"--event", fixturePath(t, "extension_template_push.json"),
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack","duckdb_version":"v1.5.4"}`,
+		"--job", "deploy",
+		"--duckdb-arch", "osx_arm64",
+		"--step", "checkout-duckdb",
+		"--dry-run",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "# Job deploy (osx_arm64), runs on ubuntu-latest\n\n# 1. Checkout DuckDB to version [checkout-duckdb]\n(\n  cd duckdb\n  git checkout v1.5.4\n)\n")
+}
+
+func TestRunStepSubcommandRejectsUnknownSteps(t *testing.T) {
+	t.Parallel()
+
+	args := []string{
+		"run-step",
+		"--event", fixturePath(t, "extension_template_push.json"),
+		"--matrix", matrixConfigPath(t),
+		"--inputs", `{"extension_name":"quack"}`,
+	}
+
+	_, _, err := executeRootCommandWithResult(t, append(args, "--duckdb-arch", "wasm_eh", "--step", "upload"))
+	require.EqualError(t, err, `step "upload" of job wasm (wasm_eh) uses actions/upload-artifact and only runs in GitHub Actions`)
+
+	_, _, err = executeRootCommandWithResult(t, append(args, "--duckdb-arch", "wasm_eh", "--step", "launch"))
+	require.ErrorContains(t, err, `job wasm (wasm_eh) has no step "launch" (steps: [configure build verify-platform audit-symbols audit-wasm upload])`)
+
+	_, _, err = executeRootCommandWithResult(t, append(args, "--job", "linux", "--step", "build"))
+	require.EqualError(t, err, "the plan has no job linux")
+
+	_, _, err = executeRootCommandWithResult(t, append(args, "--step", "build"))
+	require.EqualError(t, err, "--job or --duckdb-arch is required")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/spf13/cobra"
+)
+
+// envSigningKey holds the PEM encoded private key in the deploy workflow.
+const envSigningKey = "DUCKDB_EXTENSION_SIGNING_PK"
+
+func newSignCommand() *cobra.Command {
+	var privateKeyPath string
+
+	cmd := &cobra.Command{
+		Use:   "sign <extension-file>",
+		Short: "Sign an extension binary and write the signature into its footer",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			var (
+				data []byte
+				err  error
+			)
+			switch {
+			case privateKeyPath != "":
+				data, err = os.ReadFile(privateKeyPath)
+				if err != nil {
+					return fmt.Errorf("read private key: %w", err)
+				}
+			case os.Getenv(envSigningKey) != "":
+				data = []byte(os.Getenv(envSigningKey))
+			default:
+				return fmt.Errorf("no private key: set --private-key or %s", envSigningKey)
+			}
+			key, err := metadata.ParsePrivateKeyPEM(data)
+			if err != nil {
+				return fmt.Errorf("parse private key: %w", err)
+			}
+
+			if err := metadata.SignFile(path, key); err != nil {
+				return fmt.Errorf("sign %q: %w", path, err)
+			}
+			commandLogger(cmd).Info("Signed extension", "file", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&privateKeyPath, "private-key", "", "Path to a PEM encoded 2048-bit RSA private key (default $"+envSigningKey+")")
+
+	return cmd
+}
+
+func newVerifyCommand() *cobra.Command {
+	var publicKeyPaths []string
+
+	cmd := &cobra.Command{
+		Use:   "verify <extension-file>",
+		Short: "Verify the signature of an extension binary against trusted public keys",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			keyPaths, keys, err := loadPublicKeys(publicKeyPaths)
+			if err != nil {
+				return err
+			}
+			commandLogger(cmd).Debug("Loaded trusted public keys", "count", len(keys))
+
+			index, err := metadata.VerifyFile(path, keys)
+			if err != nil {
+				return fmt.Errorf("verify %q: %w", path, err)
+			}
+			commandLogger(cmd).Info("Signature is valid", "file", path, "public_key", keyPaths[index])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&publicKeyPaths, "public-key", nil, "PEM encoded RSA public key, or directory of *.pem public keys, to trust (repeatable)")
+	_ = cmd.MarkFlagRequired("public-key")
+
+	return cmd
+}
+
+// loadPublicKeys loads the trusted keys from files and from the *.pem files of
+// directories, and returns them with the path each key was read from.
+func loadPublicKeys(paths []string) ([]string, []*rsa.PublicKey, error) {
+	var files []string
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read public key: %w", err)
+		}
+		if !stat.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read public key directory: %w", err)
+		}
+		var dirFiles []string
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".pem") {
+				dirFiles = append(dirFiles, filepath.Join(path, entry.Name()))
+			}
+		}
+		slices.Sort(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	if len(files) == 0 {
+		return nil, nil, errors.New("no trusted public keys found")
+	}
+
+	keys := make([]*rsa.PublicKey, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read public key: %w", err)
+		}
+		key, err := metadata.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse public key %q: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+	return files, keys, nil
+}
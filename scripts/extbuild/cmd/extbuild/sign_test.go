@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerifySubcommands(t *testing.T) {
+	t.Parallel()
+
+	golden, err := os.ReadFile(metadataTestdataPath(t, "quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, golden, 0o600))
+
+	_, _, err = executeRootCommandWithResult(t, []string{"sign", path, "--private-key", signingTestdataPath(t, "test_private.pem")})
+	require.NoError(t, err)
+
+	_, stderr, err := executeRootCommandWithResult(t, []string{"verify", path, "--public-key", signingTestdataPath(t, "trusted")})
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "Signature is valid")
+	assert.Contains(t, stderr, filepath.Join("trusted", "public.pem"))
+
+	_, _, err = executeRootCommandWithResult(t, []string{"verify", path, "--public-key", signingTestdataPath(t, "untrusted/public.pem")})
+	require.ErrorContains(t, err, "signature does not match any trusted public key")
+}
+
+func TestSignSubcommandReadsKeyFromEnvironment(t *testing.T) {
+	key, err := os.ReadFile(signingTestdataPath(t, "test_private.pem"))
+	require.NoError(t, err)
+	t.Setenv(envSigningKey, string(key))
+
+	golden, err := os.ReadFile(metadataTestdataPath(t, "quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, golden, 0o600))
+
+	_, _, err = executeRootCommandWithResult(t, []string{"sign", path})
+	require.NoError(t, err)
+
+	signed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	want, err := os.ReadFile(signingTestdataPath(t, "quack.duckdb_extension.signed.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, want, signed)
+}
+
+func TestSignSubcommandRequiresKey(t *testing.T) {
+	t.Setenv(envSigningKey, "")
+
+	_, _, err := executeRootCommandWithResult(t, []string{"sign", metadataTestdataPath(t, "quack.duckdb_extension.golden")})
+	require.ErrorContains(t, err, "no private key: set --private-key or "+envSigningKey)
+}
+
+func TestVerifySubcommandRejectsEmptyKeyDirectory(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"verify", signingTestdataPath(t, "quack.duckdb_extension.signed.golden"),
+		"--public-key", t.TempDir(),
+	})
+	require.ErrorContains(t, err, "no trusted public keys found")
+}
+
+func signingTestdataPath(t *testing.T, name string) string {
+	t.Helper()
+	return filepath.Join(moduleRootPath(t), "testdata", "signing", name)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/sizereport"
+	"github.com/spf13/cobra"
+)
+
+func newSizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "size",
+		Short: "Track the size of extension binaries",
+	}
+	cmd.AddCommand(newSizeReportCommand())
+	return cmd
+}
+
+func newSizeReportCommand() *cobra.Command {
+	var (
+		withSections bool
+		format       string
+		outPath      string
+		baselinePath string
+		budgetPath   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report <extension-file>...",
+		Short: "Report raw and gzip sizes per duckdb_arch and check them against a budget",
+		Long: `Measures each extension binary and reports its raw and gzip-compressed size
+under the duckdb_arch of its metadata footer. With --baseline and --budget,
+every binary is compared with the same extension and duckdb_arch in the
+baseline report, and the command fails when one grew past its limit.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+
+			report, err := sizereport.Build(args, withSections)
+			if err != nil {
+				return err
+			}
+			if baselinePath != "" {
+				baseline, err := readSizeFile(baselinePath, sizereport.ParseReport)
+				if err != nil {
+					return fmt.Errorf("read baseline %q: %w", baselinePath, err)
+				}
+				budget, err := readSizeFile(budgetPath, sizereport.ParseBudget)
+				if err != nil {
+					return fmt.Errorf("read budget %q: %w", budgetPath, err)
+				}
+				report.Comparisons = budget.Compare(report, baseline)
+			}
+
+			if outPath != "" {
+				payload, err := sizereport.RenderJSON(report)
+				if err != nil {
+					return fmt.Errorf("render report: %w", err)
+				}
+				if err := os.WriteFile(outPath, []byte(payload), 0o644); err != nil {
+					return fmt.Errorf("write report %q: %w", outPath, err)
+				}
+			}
+
+			rendered := sizereport.RenderText(report)
+			if format == "json" {
+				rendered, err = sizereport.RenderJSON(report)
+				if err != nil {
+					return fmt.Errorf("render report: %w", err)
+				}
+			}
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), rendered)
+
+			exceeded := 0
+			for _, c := range report.Comparisons {
+				if c.Exceeded {
+					exceeded++
+				}
+			}
+			if exceeded > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d compared extension binaries grew past their size budget", exceeded, len(report.Comparisons))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&withSections, "sections", false, "Break the size down by ELF, Mach-O, PE or wasm section")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	cmd.Flags().StringVarP(&outPath, "output", "o", "", "Also write the JSON report to this file, e.g. to store it as the next baseline")
+	cmd.Flags().StringVar(&baselinePath, "baseline", "", "JSON report of a previous build to compare with")
+	cmd.Flags().StringVar(&budgetPath, "budget", "", "Budget JSON file with the allowed growth per duckdb_arch")
+	cmd.MarkFlagsRequiredTogether("baseline", "budget")
+
+	return cmd
+}
+
+func readSizeFile[T any](path string, parse func([]byte) (T, error)) (T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return parse(data)
+}
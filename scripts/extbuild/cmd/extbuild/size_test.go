@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeReportSubcommand(t *testing.T) {
+	t.Parallel()
+
+	path := stampTestLibrary(t, "libquack_linux_amd64.so", "linux_amd64", metadata.ABITypeCStruct)
+	outPath := filepath.Join(t.TempDir(), "sizes.json")
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"size", "report", path, "--sections", "--output", outPath})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "quack      linux_amd64")
+	assert.Contains(t, stdout, ".text")
+
+	var written map[string]any
+	data, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Len(t, written["extensions"], 1)
+}
+
+func TestSizeReportSubcommandBudget(t *testing.T) {
+	t.Parallel()
+
+	path := stampTestLibrary(t, "libquack_linux_amd64.so", "linux_amd64", metadata.ABITypeCStruct)
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	budgetPath := filepath.Join(moduleRootPath(t), "..", "..", "config", "size_budget.json")
+
+	writeBaseline := func(gzipBytes int) {
+		require.NoError(t, os.WriteFile(baselinePath, []byte(`{"extensions": [
+			{"extension": "quack", "duckdb_arch": "linux_amd64", "path": "old", "raw_bytes": 1, "gzip_bytes": `+strconv.Itoa(gzipBytes)+`}
+		]}`), 0o644))
+	}
+
+	writeBaseline(1 << 20)
+	stdout, _, err := executeRootCommandWithResult(t, []string{"size", "report", path, "--baseline", baselinePath, "--budget", budgetPath})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "limit 10.0%) ok")
+
+	writeBaseline(100)
+	_, _, err = executeRootCommandWithResult(t, []string{"size", "report", path, "--baseline", baselinePath, "--budget", budgetPath})
+	require.EqualError(t, err, "1 of 1 compared extension binaries grew past their size budget")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"size", "report", path, "--baseline", baselinePath})
+	require.ErrorContains(t, err, "if any flags in the group [baseline budget] are set they must all be set")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+	"github.com/duckdb/extension-ci-tools/internal/testpolicy"
+	"github.com/spf13/cobra"
+)
+
+func newTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Decide how the extension tests run",
+	}
+	cmd.AddCommand(newTestShouldRunCommand())
+	return cmd
+}
+
+// contextFromEnv maps LINUX_CI_IN_DOCKER, which the Linux jobs set to 1 in
+// the build container and to 0 on the runner, to a test context.
+func contextFromEnv() string {
+	switch os.Getenv("LINUX_CI_IN_DOCKER") {
+	case "1":
+		return string(distmatrix.TestModeDocker)
+	case "0":
+		return string(distmatrix.TestModeNative)
+	default:
+		return ""
+	}
+}
+
+func newTestShouldRunCommand() *cobra.Command {
+	var (
+		duckdbArch string
+		matrixPath string
+		capi       bool
+		runContext string
+		skipTests  bool
+		config     string
+		format     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "should-run",
+		Short: "Print whether the tests of a duckdb_arch run here",
+		Long: `Prints true when the tests of --duckdb-arch should run in --context and
+false otherwise, with the reason in the log. The matrix entry of the arch
+says where its tests can run: test_mode for C++ extensions, c_api_test_mode
+with --c-api for the Python test runner of C API extensions. Either is
+native, docker or none.
+
+--context defaults to docker when LINUX_CI_IN_DOCKER is 1 and to native when
+it is 0. Without a context, tests run wherever the arch can be tested at all.
+--skip-tests and the skip_archs of test_config skip the tests of any arch.
+An arch that is not in the distribution matrix, such as the platform of a
+local build, has no policy and its tests run.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			var testContext distmatrix.TestMode
+			switch distmatrix.TestMode(runContext) {
+			case "", distmatrix.TestModeNative, distmatrix.TestModeDocker:
+				testContext = distmatrix.TestMode(runContext)
+			default:
+				return fmt.Errorf("invalid context: %q (must be native|docker)", runContext)
+			}
+			if duckdbArch == "" {
+				duckdbArch = os.Getenv("DUCKDB_PLATFORM")
+			}
+			if duckdbArch == "" {
+				return errors.New("--duckdb-arch or DUCKDB_PLATFORM is required")
+			}
+			if config == "" {
+				config = os.Getenv(envTestConfig)
+			}
+
+			parsed, err := testconfig.Parse(config)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("parse test_config: %w", err)
+			}
+			matrix, err := loadMatrixFile(matrixPath)
+			if err != nil {
+				return err
+			}
+
+			d := testpolicy.Decide(testpolicy.Options{
+				Matrix:     matrix,
+				DuckDBArch: duckdbArch,
+				CAPI:       capi,
+				Context:    testContext,
+				SkipTests:  skipTests,
+				TestConfig: parsed,
+			})
+			commandLogger(cmd).Info("Test decision", "duckdb_arch", d.DuckDBArch, "run", d.Run, "reason", d.Reason)
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				_, _ = fmt.Fprintln(out, d.Run)
+			case "json":
+				payload, err := json.MarshalIndent(d, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render test decision: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "The duckdb_arch to decide for (env DUCKDB_PLATFORM)")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.Flags().BoolVar(&capi, "c-api", false, "Use the test mode of C API extensions")
+	cmd.Flags().StringVar(&runContext, "context", contextFromEnv(), "Where the tests would run: native|docker, empty for anywhere")
+	cmd.Flags().BoolVar(&skipTests, "skip-tests", false, "Skip the tests, like the skip_tests input")
+	cmd.Flags().StringVar(&config, "test-config", "", "The test_config JSON object (env "+envTestConfig+")")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	return cmd
+}
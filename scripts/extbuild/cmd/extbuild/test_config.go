@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+	"github.com/spf13/cobra"
+)
+
+const envTestConfig = "TEST_CONFIG"
+
+func newTestConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test-config",
+		Short: "Work with the test_config input",
+	}
+	cmd.AddCommand(newTestConfigExportCommand())
+	return cmd
+}
+
+func newTestConfigExportCommand() *cobra.Command {
+	var (
+		config string
+		shell  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the test_env_variables of test_config as statements for a shell",
+		Long: `Parses the test_config input and prints a statement per test_env_variables
+entry that sets it in --shell:
+
+  bash        export NAME='value'
+  pwsh        $env:NAME = 'value'
+  docker-env  NAME=value, for docker run --env-file
+
+Values are quoted so that the shell takes them literally. Variable names must
+be valid shell identifiers and values must be strings; a Docker env file has no
+quoting, so docker-env also rejects values with a line break. Nothing is
+printed when test_config is invalid.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(testconfig.Shells, shell) {
+				return fmt.Errorf("invalid shell: %q (must be %s)", shell, strings.Join(testconfig.Shells, "|"))
+			}
+			if config == "" {
+				config = os.Getenv(envTestConfig)
+			}
+
+			parsed, err := testconfig.Parse(config)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("parse test_config: %w", err)
+			}
+			for _, field := range testconfig.UnknownFields(config) {
+				commandLogger(cmd).Warn("test_config field is not known and is ignored", "field", field)
+			}
+			if err := parsed.Export(cmd.OutOrStdout(), shell); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&config, "test-config", "", "The test_config JSON object (env "+envTestConfig+")")
+	cmd.Flags().StringVar(&shell, "shell", "bash", "Output format: "+strings.Join(testconfig.Shells, "|"))
+	return cmd
+}
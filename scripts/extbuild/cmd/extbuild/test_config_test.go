@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestConfigExportSubcommand(t *testing.T) {
+	config := `{"test_env_variables": {"QUACK_MODE": "very 'loud'", "A": "1"}, "timeout": "1h"}`
+
+	stdout, stderr, err := executeRootCommandWithResult(t, []string{"test-config", "export", "--test-config", config})
+	require.NoError(t, err)
+	assert.Equal(t, "export A='1'\nexport QUACK_MODE='very '\\''loud'\\'''\n", stdout)
+	assert.Contains(t, stderr, "timeout")
+
+	t.Setenv("TEST_CONFIG", config)
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test-config", "export", "--shell", "pwsh"})
+	require.NoError(t, err)
+	assert.Equal(t, "$env:A = '1'\n$env:QUACK_MODE = 'very ''loud'''\n", stdout)
+
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test-config", "export", "--shell", "docker-env"})
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\nQUACK_MODE=very 'loud'\n", stdout)
+
+	t.Setenv("TEST_CONFIG", "")
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test-config", "export"})
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+}
+
+func TestTestConfigExportSubcommandRejectsInvalidConfigs(t *testing.T) {
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"test-config", "export", "--test-config", `{"test_env_variables": {"A": "1", "RETRIES": 3}}`,
+	})
+	require.EqualError(t, err, "parse test_config: test_env_variables.RETRIES must be a string, got a number")
+	assert.Empty(t, stdout)
+
+	_, _, err = executeRootCommandWithResult(t, []string{
+		"test-config", "export", "--shell", "docker-env", "--test-config", `{"test_env_variables": {"A": "1\nB=2"}}`,
+	})
+	require.ErrorContains(t, err, "test_env_variables.A: value contains a line break")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"test-config", "export", "--shell", "zsh"})
+	require.EqualError(t, err, `invalid shell: "zsh" (must be bash|pwsh|docker-env)`)
+}
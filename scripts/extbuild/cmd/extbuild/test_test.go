@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestShouldRunSubcommand(t *testing.T) {
+	t.Setenv("LINUX_CI_IN_DOCKER", "1")
+	t.Setenv("TEST_CONFIG", "")
+
+	stdout, stderr, err := executeRootCommandWithResult(t, []string{"test", "should-run", "--duckdb-arch", "linux_amd64", "--matrix", matrixConfigPath(t)})
+	require.NoError(t, err)
+	assert.Equal(t, "true\n", stdout)
+	assert.Contains(t, stderr, "tests run in docker")
+
+	stdout, stderr, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--duckdb-arch", "linux_amd64", "--c-api", "--matrix", matrixConfigPath(t)})
+	require.NoError(t, err)
+	assert.Equal(t, "false\n", stdout)
+	assert.Contains(t, stderr, "tests cannot run for this duckdb_arch")
+
+	t.Setenv("LINUX_CI_IN_DOCKER", "0")
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--duckdb-arch", "linux_amd64", "--matrix", matrixConfigPath(t)})
+	require.NoError(t, err)
+	assert.Equal(t, "false\n", stdout)
+
+	t.Setenv("LINUX_CI_IN_DOCKER", "")
+	t.Setenv("DUCKDB_PLATFORM", "osx_arm64")
+	t.Setenv("TEST_CONFIG", `{"skip_archs": ["osx_arm64"]}`)
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--c-api", "--matrix", matrixConfigPath(t), "--format", "json"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"duckdb_arch": "osx_arm64", "test_mode": "", "run": false, "reason": "test_config skips this duckdb_arch"}`, stdout)
+
+	stdout, _, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--test-config", "{}", "--matrix", matrixConfigPath(t), "--format", "json"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"duckdb_arch": "osx_arm64", "test_mode": "native", "run": true, "reason": "tests run natively"}`, stdout)
+}
+
+func TestTestShouldRunSubcommandRejectsInvalidFlags(t *testing.T) {
+	t.Setenv("DUCKDB_PLATFORM", "")
+
+	_, _, err := executeRootCommandWithResult(t, []string{"test", "should-run", "--matrix", matrixConfigPath(t)})
+	require.EqualError(t, err, "--duckdb-arch or DUCKDB_PLATFORM is required")
+
+	_, _, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--duckdb-arch", "osx_arm64", "--context", "vm"})
+	require.EqualError(t, err, `invalid context: "vm" (must be native|docker)`)
+
+	_, _, err = executeRootCommandWithResult(t, []string{"test", "should-run", "--duckdb-arch", "osx_arm64", "--test-config", `{"test_env_variables": {"A": 1}}`})
+	require.EqualError(t, err, "parse test_config: test_env_variables.A must be a string, got a number")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/toolchain"
+	"github.com/spf13/cobra"
+)
+
+func newToolchainsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "toolchains",
+		Short: "List and resolve the extra toolchains of the build images",
+	}
+	cmd.AddCommand(newToolchainsListCommand())
+	cmd.AddCommand(newToolchainsResolveCommand())
+	return cmd
+}
+
+func newToolchainsListCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the known extra toolchains and the targets they can be installed on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				for _, tc := range toolchain.Registry {
+					_, _ = fmt.Fprintf(out, "%-20s %-28s %s\n", tc.Name, strings.Join(tc.Targets, ","), tc.Description)
+				}
+			case "json":
+				payload, err := json.MarshalIndent(toolchain.Registry, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render toolchains: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			default:
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	return cmd
+}
+
+// toolchainResolution is the outcome of toolchains resolve.
+type toolchainResolution struct {
+	DuckDBArch  string          `json:"duckdb_arch,omitempty"`
+	Toolchains  []string        `json:"toolchains"`
+	Unsupported []string        `json:"unsupported,omitempty"`
+	BuildArg    string          `json:"build_arg"`
+	Install     map[string]bool `json:"install"`
+}
+
+func newToolchainsResolveCommand() *cobra.Command {
+	var (
+		extraToolchains string
+		enableRust      bool
+		duckdbArch      string
+		format          string
+		outPath         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Normalize extra_toolchains and print the Docker build-arg and install flags",
+		Long: `Normalizes an extra_toolchains value: names are trimmed and lower-cased,
+';' and ',' both separate them, duplicates are dropped and unknown names are
+rejected. --enable-rust adds rust like the deprecated enable_rust input.
+
+With --duckdb-arch only the toolchains that can be installed for that arch are
+kept; the others are reported in a warning. The result holds the
+extra_toolchains build argument of the Dockerfiles and an install flag per
+known toolchain, which --format github prints as NAME=true|false output lines
+for the install steps of a workflow job.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains([]string{"text", "json", "github"}, format) {
+				return fmt.Errorf("invalid format: %q (must be text|json|github)", format)
+			}
+			names, err := toolchain.Requested(extraToolchains, enableRust)
+			if err != nil {
+				return err
+			}
+
+			result := toolchainResolution{DuckDBArch: duckdbArch, Toolchains: names}
+			if duckdbArch != "" {
+				result.Toolchains, result.Unsupported = toolchain.ForArch(names, duckdbArch)
+				if len(result.Unsupported) > 0 {
+					commandLogger(cmd).Warn("Toolchains are not available for this arch and are skipped",
+						"duckdb_arch", duckdbArch, "toolchains", strings.Join(result.Unsupported, ";"))
+				}
+			}
+			result.BuildArg = toolchain.BuildArg(result.Toolchains)
+			result.Install = map[string]bool{}
+			for _, name := range toolchain.Names() {
+				result.Install[name] = slices.Contains(result.Toolchains, name)
+			}
+
+			var content string
+			switch format {
+			case "text":
+				content = fmt.Sprintf("toolchains: %s\nbuild_arg: %s\n", strings.Join(result.Toolchains, " "), result.BuildArg)
+			case "json":
+				payload, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render toolchains: %w", err)
+				}
+				content = string(payload) + "\n"
+			case "github":
+				var b strings.Builder
+				fmt.Fprintf(&b, "build_arg=%s\n", result.BuildArg)
+				fmt.Fprintf(&b, "toolchains=%s\n", strings.Join(result.Toolchains, ";"))
+				for _, name := range toolchain.Names() {
+					fmt.Fprintf(&b, "%s=%t\n", name, result.Install[name])
+				}
+				content = b.String()
+			}
+
+			if outPath == "" {
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), content)
+				return nil
+			}
+			// $GITHUB_OUTPUT is shared by all steps of a job, so it is appended to.
+			f, err := os.OpenFile(outPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return fmt.Errorf("open output file %q: %w", outPath, err)
+			}
+			if _, err := f.WriteString(content); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("write output file %q: %w", outPath, err)
+			}
+			return f.Close()
+		},
+	}
+
+	cmd.Flags().StringVar(&extraToolchains, "extra-toolchains", "", "The extra_toolchains input, ';' or ',' separated")
+	cmd.Flags().BoolVar(&enableRust, "enable-rust", false, "Add rust, like the deprecated enable_rust input")
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "Keep only the toolchains available for this duckdb_arch")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json|github")
+	cmd.Flags().StringVar(&outPath, "out", "", "File to append the output to instead of stdout, such as $GITHUB_OUTPUT")
+	return cmd
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolchainsResolveSubcommandGitHubOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(path, []byte("earlier=1\n"), 0o644))
+
+	_, stderr, err := executeRootCommandWithResult(t, []string{
+		"toolchains", "resolve", "--extra-toolchains", ";Python3; omp,parser_tools;", "--enable-rust",
+		"--duckdb-arch", "linux_amd64_musl", "--format", "github", "--out", path,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "omp")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `earlier=1
+build_arg=;parser_tools;rust;python3;
+toolchains=parser_tools;rust;python3
+parser_tools=true
+rust=true
+fortran=false
+omp=false
+go=false
+python3=true
+unixodbc=false
+multimedia=false
+cuda=false
+downgraded_aws_cli=false
+`, string(data))
+}
+
+func TestToolchainsResolveSubcommandJSON(t *testing.T) {
+	stdout, _, err := executeRootCommandWithResult(t, []string{"toolchains", "resolve", "--extra-toolchains", "omp;go", "--format", "json"})
+	require.NoError(t, err)
+
+	var got toolchainResolution
+	require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, []string{"omp", "go"}, got.Toolchains)
+	assert.Equal(t, ";omp;go;", got.BuildArg)
+	assert.True(t, got.Install["omp"])
+	assert.False(t, got.Install["rust"])
+}
+
+func TestToolchainsResolveSubcommandRejectsUnknownNames(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{"toolchains", "resolve", "--extra-toolchains", "rust;rsut"})
+	require.ErrorContains(t, err, `unknown toolchain "rsut" (known: parser_tools, rust,`)
+}
+
+func TestToolchainsListSubcommand(t *testing.T) {
+	stdout, _, err := executeRootCommandWithResult(t, []string{"toolchains", "list"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "python3              linux_amd64_musl,linux_arm64_musl")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/vcpkgdeps"
+	"github.com/spf13/cobra"
+)
+
+const envVCPKGExtraDependencies = "VCPKG_EXTRA_DEPENDENCIES"
+
+func newVCPKGCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vcpkg",
+		Short: "Resolve the vcpkg packages of a build",
+	}
+	cmd.AddCommand(newVCPKGExtraDepsCommand())
+	return cmd
+}
+
+func newVCPKGExtraDepsCommand() *cobra.Command {
+	var (
+		dependencies string
+		duckdbArch   string
+		matrixPath   string
+		mode         string
+		format       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "extra-deps",
+		Short: "Print the install commands of the vcpkg_extra_dependencies of an arch",
+		Long: `Parses the vcpkg_extra_dependencies input, a JSON object mapping duckdb_arch
+values to vcpkg package specs such as openssl, curl[ssl,http2] or
+zlib:x64-linux-release, and prints one install command per package of --arch.
+
+Port, feature and triplet names are validated before anything is printed, so
+the commands are safe to pipe into a shell. Keys that are not a duckdb_arch of
+the distribution matrix are reported in a warning. --mode docker installs
+into the build image of the Linux jobs, --mode host with the vcpkg on the
+PATH.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if mode != "docker" && mode != "host" {
+				return fmt.Errorf("invalid mode: %q (must be docker|host)", mode)
+			}
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			if dependencies == "" {
+				dependencies = os.Getenv(envVCPKGExtraDependencies)
+			}
+
+			deps, err := vcpkgdeps.Parse(dependencies)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+
+			matrix, err := loadMatrixFile(matrixPath)
+			if err != nil {
+				return err
+			}
+			var arches []string
+			for _, platform := range matrix {
+				for _, entry := range platform.Include {
+					arches = append(arches, entry.DuckDBArch)
+				}
+			}
+			for _, arch := range deps.UnknownArches(arches) {
+				commandLogger(cmd).Warn("vcpkg_extra_dependencies names a duckdb_arch that is not in the distribution matrix", "duckdb_arch", arch)
+			}
+
+			commands := make([]string, 0, len(deps[duckdbArch]))
+			for _, dep := range deps[duckdbArch] {
+				if mode == "docker" {
+					commands = append(commands, vcpkgdeps.DockerCommand(dep, duckdbArch))
+				} else {
+					commands = append(commands, vcpkgdeps.HostCommand(dep))
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				for _, command := range commands {
+					_, _ = fmt.Fprintln(out, command)
+				}
+			case "json":
+				payload, err := json.MarshalIndent(struct {
+					DuckDBArch   string                 `json:"duckdb_arch"`
+					Dependencies []vcpkgdeps.Dependency `json:"dependencies"`
+					Commands     []string               `json:"commands"`
+				}{duckdbArch, deps[duckdbArch], commands}, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render dependencies: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dependencies, "dependencies", "", "The vcpkg_extra_dependencies JSON object (env "+envVCPKGExtraDependencies+")")
+	cmd.Flags().StringVar(&duckdbArch, "arch", "", "The duckdb_arch to print the install commands for")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "config/distribution_matrix.json", "Input distribution matrix JSON file")
+	cmd.Flags().StringVar(&mode, "mode", "host", "Where to install: docker|host")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+	_ = cmd.MarkFlagRequired("arch")
+
+	return cmd
+}
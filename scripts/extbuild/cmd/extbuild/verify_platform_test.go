@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPlatformSubcommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		platform string
+		args     []string
+		wantErr  []string
+	}{
+		{
+			name:     "consistent",
+			platform: "linux_amd64",
+			args:     []string{"--duckdb-arch", "linux_amd64", "--matrix", matrixConfigPath(t)},
+		},
+		{
+			name:     "musl footer on a linux binary",
+			platform: "linux_amd64_musl",
+		},
+		{
+			name:     "stamped for another operating system",
+			platform: "osx_arm64",
+			wantErr:  []string{`footer duckdb_platform is "osx_arm64" but the binary targets linux/amd64 (elf)`},
+		},
+		{
+			name:     "uploaded under another arch",
+			platform: "linux_amd64",
+			args:     []string{"--duckdb-arch", "linux_arm64"},
+			wantErr:  []string{`matrix duckdb_arch is "linux_arm64" but the footer duckdb_platform is "linux_amd64"`},
+		},
+		{
+			name:     "arch outside the matrix",
+			platform: "linux_amd64_gcc4",
+			args:     []string{"--matrix", matrixConfigPath(t)},
+			wantErr:  []string{`duckdb_arch "linux_amd64_gcc4" is not in the distribution matrix`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := stampTestLibrary(t, "libquack_linux_amd64.so", tc.platform, metadata.ABITypeCStruct)
+			_, _, err := executeRootCommandWithResult(t, append([]string{"verify-platform", path}, tc.args...))
+			if len(tc.wantErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tc.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestVerifyPlatformSubcommandRequiresFooter(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := executeRootCommandWithResult(t, []string{"verify-platform", objfileTestdataPath(t, "libquack_linux_amd64.so")})
+	require.ErrorContains(t, err, "no DuckDB extension metadata footer")
+}
+
+// stampTestLibrary appends a footer for platform and abiType to one of the
+// test shared libraries.
+func stampTestLibrary(t *testing.T, library, platform, abiType string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, metadata.AppendFile(objfileTestdataPath(t, library), path, metadata.Metadata{
+		ABIType:          abiType,
+		ExtensionVersion: "v0.1.0",
+		DuckDBVersion:    "v1.2.0",
+		DuckDBPlatform:   platform,
+	}))
+	return path
+}
+
+func objfileTestdataPath(t *testing.T, name string) string {
+	t.Helper()
+	return filepath.Join(moduleRootPath(t), "testdata", "objfile", name)
+}
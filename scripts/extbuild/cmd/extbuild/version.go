@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/duckdb/extension-ci-tools/internal/version"
+	"github.com/spf13/cobra"
+)
+
+const envExtensionVersion = "EXTENSION_VERSION"
+
+func newVersionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Derive the extension version from git",
+	}
+	cmd.AddCommand(newVersionDetectCommand())
+	return cmd
+}
+
+func newVersionDetectCommand() *cobra.Command {
+	var (
+		dir      string
+		patterns []string
+		override string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "detect",
+		Short: "Print the extension version of HEAD with git describe semantics",
+		Long: `Derives the extension version of HEAD from the release tags of the repository:
+
+  - a release tag on HEAD is the version; the highest one wins,
+  - otherwise the release tag git describe finds gives TAG-N-gHASH,
+  - without any release tag the version is the short commit hash.
+
+Uncommitted changes to tracked files add a -dirty suffix. Release tags are the
+tags matching a --match glob pattern. The version must fit the 32-byte metadata
+field.
+
+extbuild configure, which the build uses, keeps to the exact tag on HEAD or
+the short commit hash instead.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format: %q (must be text|json)", format)
+			}
+			if override == "" {
+				override = os.Getenv(envExtensionVersion)
+			}
+
+			result, err := version.Detect(version.Options{Dir: dir, TagPatterns: patterns, Override: override})
+			if err != nil {
+				return fmt.Errorf("detect extension version: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "text":
+				_, _ = fmt.Fprintln(out, result.Version)
+			case "json":
+				payload, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("render version: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(payload))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Git repository to read")
+	cmd.Flags().StringArrayVar(&patterns, "match", version.DefaultTagPatterns, "Glob pattern of the release tags, like git describe --match (repeatable)")
+	cmd.Flags().StringVar(&override, "override", "", "Use this version instead of reading git (env "+envExtensionVersion+")")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text|json")
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionDetectSubcommand(t *testing.T) {
+	t.Setenv(envExtensionVersion, "")
+	dir := gitRepository(t)
+	git(t, dir, "tag", "v1.0.0")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "second")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "third")
+	hash := strings.TrimSpace(git(t, dir, "rev-parse", "--short", "HEAD"))
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"version", "detect", "--dir", dir})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0-2-g"+hash+"\n", stdout)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("a"), 0o644))
+	git(t, dir, "add", "tracked.txt")
+	stdout, _, err = executeRootCommandWithResult(t, []string{"version", "detect", "--dir", dir, "--format", "json"})
+	require.NoError(t, err)
+
+	var result version.Result
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, version.Result{Version: "v1.0.0-2-g" + hash + "-dirty", Tag: "v1.0.0", Distance: 2, Commit: hash, Dirty: true}, result)
+}
+
+func TestVersionDetectSubcommandOverride(t *testing.T) {
+	t.Setenv(envExtensionVersion, "v2.0.0")
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"version", "detect", "--dir", t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0\n", stdout)
+
+	stdout, _, err = executeRootCommandWithResult(t, []string{"version", "detect", "--override", "v3.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "v3.0.0\n", stdout)
+}
+
+func TestVersionDetectSubcommandMatch(t *testing.T) {
+	t.Setenv(envExtensionVersion, "")
+	dir := gitRepository(t)
+	git(t, dir, "tag", "quack-2024.05")
+	git(t, dir, "tag", "v1.0.0")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "second")
+	hash := strings.TrimSpace(git(t, dir, "rev-parse", "--short", "HEAD"))
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{"version", "detect", "--dir", dir, "--match", "quack-*"})
+	require.NoError(t, err)
+	assert.Equal(t, "quack-2024.05-1-g"+hash+"\n", stdout)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/duckdb/extension-ci-tools/internal/workflowgen"
+	"github.com/spf13/cobra"
+)
+
+const defaultCallerWorkflowPath = ".github/workflows/MainDistributionPipeline.yml"
+
+func newWorkflowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workflow",
+		Short: "Manage the caller workflow of an extension repository",
+	}
+	cmd.AddCommand(newWorkflowGenerateCommand())
+	return cmd
+}
+
+func newWorkflowGenerateCommand() *cobra.Command {
+	var (
+		configPath string
+		outPath    string
+		check      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the caller workflow from extbuild.toml or extbuild.yaml",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if configPath == "" {
+				found, err := findWorkflowConfig(".")
+				if err != nil {
+					return err
+				}
+				configPath = found
+			}
+
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				return fmt.Errorf("read config %q: %w", configPath, err)
+			}
+			cfg, err := workflowgen.ParseConfigFile(configPath, data)
+			if err != nil {
+				return fmt.Errorf("parse config %q: %w", configPath, err)
+			}
+			generated := workflowgen.Render(cfg, filepath.Base(configPath))
+
+			if check {
+				if outPath == "" {
+					outPath = defaultCallerWorkflowPath
+				}
+				committed, err := os.ReadFile(outPath)
+				if err != nil {
+					return fmt.Errorf("read workflow %q: %w", outPath, err)
+				}
+				if diff := workflowgen.Diff(string(committed), generated); diff != "" {
+					return fmt.Errorf("workflow %q is out of date with %q (%s), run extbuild workflow generate", outPath, configPath, diff)
+				}
+				commandLogger(cmd).Info("Workflow is up to date", "workflow", outPath, "config", configPath)
+				return nil
+			}
+
+			if outPath == "" {
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), generated)
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+				return fmt.Errorf("create workflow directory: %w", err)
+			}
+			if err := os.WriteFile(outPath, []byte(generated), 0o644); err != nil {
+				return fmt.Errorf("write workflow %q: %w", outPath, err)
+			}
+			commandLogger(cmd).Info("Wrote workflow", "workflow", outPath, "config", configPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "", "Extension build config (default: first of "+fmt.Sprint(workflowgen.ConfigFileNames)+" in the working directory)")
+	cmd.Flags().StringVar(&outPath, "out", "", "Path to write the workflow to (default: stdout, or "+defaultCallerWorkflowPath+" with --check)")
+	cmd.Flags().BoolVar(&check, "check", false, "Fail if the committed workflow differs from the generated one")
+
+	return cmd
+}
+
+func findWorkflowConfig(dir string) (string, error) {
+	for _, name := range workflowgen.ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("stat config %q: %w", path, err)
+		}
+	}
+	return "", fmt.Errorf("no extension build config found, expected one of %v", workflowgen.ConfigFileNames)
+}
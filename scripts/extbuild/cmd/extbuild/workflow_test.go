@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowGenerateWritesOutputFile(t *testing.T) {
+	t.Parallel()
+
+	outPath := filepath.Join(t.TempDir(), ".github", "workflows", "MainDistributionPipeline.yml")
+	_, _, err := executeRootCommandWithResult(t, []string{
+		"workflow", "generate",
+		"--config", workflowTestdataPath(t, "extbuild.toml"),
+		"--out", outPath,
+	})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	want, err := os.ReadFile(workflowTestdataPath(t, "MainDistributionPipeline.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestWorkflowGenerateCheck(t *testing.T) {
+	t.Parallel()
+
+	stdout, _, err := executeRootCommandWithResult(t, []string{
+		"workflow", "generate", "--check",
+		"--config", workflowTestdataPath(t, "extbuild.toml"),
+		"--out", workflowTestdataPath(t, "MainDistributionPipeline.yml"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+
+	stale := filepath.Join(t.TempDir(), "MainDistributionPipeline.yml")
+	require.NoError(t, os.WriteFile(stale, []byte("name: stale\n"), 0o600))
+	_, _, err = executeRootCommandWithResult(t, []string{
+		"workflow", "generate", "--check",
+		"--config", workflowTestdataPath(t, "extbuild.toml"),
+		"--out", stale,
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "is out of date")
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestWorkflowGenerateFindsConfigInWorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile(workflowTestdataPath(t, "extbuild.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extbuild.yaml"), data, 0o600))
+	t.Chdir(dir)
+
+	stdout := executeRootCommand(t, []string{"workflow", "generate"})
+	assert.Contains(t, stdout, "generated by extbuild from extbuild.yaml")
+	assert.Contains(t, stdout, "uses: duckdb/extension-ci-tools/.github/workflows/_extension_distribution.yml@v1.5.4")
+}
+
+func TestWorkflowGenerateWithoutConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	_, _, err := executeRootCommandWithResult(t, []string{"workflow", "generate"})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "no extension build config found")
+}
+
+func workflowTestdataPath(t *testing.T, name string) string {
+	t.Helper()
+	return filepath.Join(moduleRootPath(t), "testdata", "workflow", name)
+}
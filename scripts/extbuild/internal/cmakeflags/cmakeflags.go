@@ -0,0 +1,409 @@
+// Package cmakeflags assembles the CMake configure command line that
+// makefiles/duckdb_extension.Makefile runs for a build, so flag mistakes can
+// be debugged without running make.
+package cmakeflags
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// BuildType is a build target of duckdb_extension.Makefile.
+type BuildType string
+
+const (
+	BuildDebug     BuildType = "debug"
+	BuildRelease   BuildType = "release"
+	BuildRelAssert BuildType = "relassert"
+	BuildRelDebug  BuildType = "reldebug"
+)
+
+// ParseBuildType validates a build type name.
+func ParseBuildType(s string) (BuildType, error) {
+	switch bt := BuildType(s); bt {
+	case BuildDebug, BuildRelease, BuildRelAssert, BuildRelDebug:
+		return bt, nil
+	}
+	return "", fmt.Errorf("invalid build type %q (must be debug|release|relassert|reldebug)", s)
+}
+
+// TestDeps is the BUILD_EXTENSION_TEST_DEPS mode.
+type TestDeps string
+
+const (
+	TestDepsDefault TestDeps = "default"
+	TestDepsFull    TestDeps = "full"
+	TestDepsNone    TestDeps = "none"
+)
+
+// wasmPlatforms are built by the wasm_* targets instead of the build type
+// targets.
+var wasmPlatforms = []string{"wasm_mvp", "wasm_eh", "wasm_threads"}
+
+// Options are the inputs of the configure command line. Each field is named
+// after the Makefile variable it replaces.
+type Options struct {
+	// DuckDBPlatform is DUCKDB_PLATFORM, the duckdb_arch being built.
+	DuckDBPlatform string
+	BuildType      BuildType
+
+	// OSXBuildArch, VCPKGTargetTriplet and VCPKGHostTriplet come from the
+	// distribution matrix entry.
+	OSXBuildArch       string
+	VCPKGTargetTriplet string
+	VCPKGHostTriplet   string
+	VCPKGToolchainPath string
+
+	// ProjDir is PROJ_DIR, the extension directory with a trailing slash.
+	ProjDir      string
+	DuckDBSrcDir string
+	EMSDK        string
+
+	ExtConfig             string
+	ExtraExtensionConfigs string
+	ExtensionFlags        []string
+	ExtFlags              []string
+	ExtReleaseFlags       []string
+	ExtDebugFlags         []string
+
+	CoreExtensions           string
+	BuildExtensionTestDeps   TestDeps
+	DefaultTestExtensionDeps string
+	FullTestExtensionDeps    string
+
+	NewExtensionBuild      bool
+	UseMergedVCPKGManifest bool
+	Ninja                  bool
+
+	ExtensionStaticBuild       bool
+	EnableExtensionAutoloading bool
+	EnableExtensionAutoinstall bool
+
+	CustomLinker        string
+	OverrideGitDescribe string
+	PrebuiltLibrary     string
+	BuildExtensionsOnly string
+
+	CrashOnAssert         bool
+	BuildBenchmark        bool
+	TreatWarningsAsErrors bool
+	DisableSanitizer      bool
+	DisableUBSan          bool
+	ThreadSan             bool
+}
+
+// Defaults returns the options the Makefile uses when none of its variables
+// are set.
+func Defaults() Options {
+	return Options{
+		BuildType:              BuildRelease,
+		DuckDBSrcDir:           "./duckdb",
+		BuildExtensionTestDeps: TestDepsDefault,
+		ExtensionStaticBuild:   true,
+	}
+}
+
+// Wasm reports whether the platform is built by one of the wasm_* targets.
+func (o Options) Wasm() bool {
+	return slices.Contains(wasmPlatforms, o.DuckDBPlatform)
+}
+
+// BuildDir returns the CMake build directory of the target.
+func (o Options) BuildDir() string {
+	if o.Wasm() {
+		return "build/" + o.DuckDBPlatform
+	}
+	return "build/" + string(o.BuildType)
+}
+
+// Command returns the configure command the Makefile target of the build type
+// runs, or the wasm_* target for wasm platforms, as arguments. Arguments are
+// in the Makefile's order, including the ones it always passes even when
+// their variable is empty.
+func (o Options) Command() ([]string, error) {
+	if _, err := ParseBuildType(string(o.BuildType)); err != nil && !o.Wasm() {
+		return nil, err
+	}
+	switch o.BuildExtensionTestDeps {
+	case TestDepsDefault, TestDepsFull, TestDepsNone:
+	default:
+		return nil, fmt.Errorf("unknown option passed to BUILD_EXTENSION_TEST_DEPS variable: %s", o.BuildExtensionTestDeps)
+	}
+
+	if o.Wasm() {
+		return o.wasmCommand(), nil
+	}
+
+	args := []string{"cmake"}
+	args = append(args, o.generator()...)
+	args = append(args, o.buildFlags()...)
+	switch o.BuildType {
+	case BuildDebug:
+		args = append(args, o.ExtDebugFlags...)
+	default:
+		args = append(args, o.ExtReleaseFlags...)
+	}
+	args = append(args, o.manifestFlags()...)
+
+	switch o.BuildType {
+	case BuildDebug:
+		args = append(args, "-DCMAKE_BUILD_TYPE=Debug", "-S", o.DuckDBSrcDir)
+	case BuildRelease:
+		args = append(args, "-DCMAKE_BUILD_TYPE=Release", "-S", o.DuckDBSrcDir)
+	case BuildRelAssert:
+		args = append(args, "-DCMAKE_BUILD_TYPE=RelWithDebInfo", "-S", o.DuckDBSrcDir, "-DFORCE_ASSERT=1")
+	case BuildRelDebug:
+		args = append(args, "-DCMAKE_BUILD_TYPE=RelWithDebInfo", "-S", o.DuckDBSrcDir)
+	}
+	return append(args, "-B", o.BuildDir()), nil
+}
+
+// wasmCommand mirrors the wasm_mvp, wasm_eh and wasm_threads targets.
+func (o Options) wasmCommand() []string {
+	args := []string{"emcmake", "cmake"}
+	args = append(args, o.generator()...)
+	args = append(args, o.extensionConfigFlag())
+	args = append(args, o.manifestFlags()...)
+	args = append(args, "-DWASM_LOADABLE_EXTENSIONS=1", "-DBUILD_EXTENSIONS_ONLY=1")
+	args = append(args, o.toolchainFlags()...)
+	args = append(args, "-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE="+o.EMSDK+"/upstream/emscripten/cmake/Modules/Platform/Emscripten.cmake")
+	args = append(args, o.buildFlags()...)
+	args = append(args, "-B"+o.BuildDir(), "-DCMAKE_CXX_FLAGS="+wasmCXXFlags(o.DuckDBPlatform), "-S", o.DuckDBSrcDir,
+		"-DDUCKDB_EXPLICIT_PLATFORM="+o.DuckDBPlatform, "-DDUCKDB_CUSTOM_PLATFORM="+o.DuckDBPlatform)
+	return args
+}
+
+// wasmCXXFlags returns WASM_CXX_*_FLAGS. WASM_CXX_THREADS_FLAGS expands the
+// undefined WASM_COMPILE_TIME_EH_FLAGS, so threads builds do not get the
+// exception flags of wasm_eh.
+func wasmCXXFlags(platform string) string {
+	switch platform {
+	case "wasm_eh":
+		return " -fwasm-exceptions -DWEBDB_FAST_EXCEPTIONS=1"
+	case "wasm_threads":
+		return " -DWITH_WASM_THREADS=1 -DWITH_WASM_SIMD=1 -DWITH_WASM_BULK_MEMORY=1 -pthread"
+	default:
+		return ""
+	}
+}
+
+func (o Options) generator() []string {
+	if o.Ninja {
+		return []string{"-G", "Ninja", "-DFORCE_COLORED_OUTPUT=1"}
+	}
+	return nil
+}
+
+// buildFlags mirrors BUILD_FLAGS.
+func (o Options) buildFlags() []string {
+	args := []string{"-DEXTENSION_STATIC_BUILD=" + boolFlag(o.ExtensionStaticBuild)}
+	args = append(args, o.ExtensionFlags...)
+	args = append(args, o.extensionConfigFlag())
+	args = append(args, o.ExtFlags...)
+	if core := o.coreExtensions(); core != "" {
+		args = append(args, "-DCORE_EXTENSIONS="+core)
+	}
+	// The Makefile compares OSX_BUILD_ARCH with a literal "", so the flag is
+	// passed on every platform.
+	args = append(args, "-DOSX_BUILD_ARCH="+o.OSXBuildArch)
+	if target := o.rustTarget(); target != "" {
+		args = append(args, "-DRust_CARGO_TARGET="+target)
+	}
+	args = append(args, o.toolchainFlags()...)
+	args = append(args,
+		"-DDUCKDB_EXPLICIT_PLATFORM="+o.DuckDBPlatform,
+		"-DCUSTOM_LINKER="+o.CustomLinker,
+		"-DOVERRIDE_GIT_DESCRIBE="+o.OverrideGitDescribe,
+		"-DUNITTEST_ROOT_DIRECTORY="+o.ProjDir,
+		"-DBENCHMARK_ROOT_DIRECTORY="+o.ProjDir,
+		"-DENABLE_UNITTEST_CPP_TESTS=FALSE",
+		"-DENABLE_EXTENSION_AUTOLOADING="+boolFlag(o.EnableExtensionAutoloading),
+		"-DENABLE_EXTENSION_AUTOINSTALL="+boolFlag(o.EnableExtensionAutoinstall),
+	)
+	if o.PrebuiltLibrary != "" {
+		args = append(args, "-DPREBUILT_BINARY="+o.PrebuiltLibrary)
+	}
+	if o.BuildExtensionsOnly != "" {
+		args = append(args, "-DBUILD_EXTENSIONS_ONLY="+o.BuildExtensionsOnly)
+	}
+	if o.CrashOnAssert {
+		args = append(args, "-DCRASH_ON_ASSERT=1")
+	}
+	if o.BuildBenchmark {
+		args = append(args, "-DBUILD_BENCHMARKS=1")
+	}
+	if o.TreatWarningsAsErrors {
+		args = append(args, "-DTREAT_WARNINGS_AS_ERRORS=1")
+	}
+	if o.DisableSanitizer {
+		args = append(args, "-DENABLE_SANITIZER=FALSE", "-DENABLE_UBSAN=0")
+	}
+	if o.DisableUBSan {
+		args = append(args, "-DENABLE_UBSAN=0")
+	}
+	if o.ThreadSan {
+		args = append(args, "-DENABLE_THREAD_SANITIZER=1")
+	}
+	return append(args, "-DBUILD_EXTENSION_TEST_DEPS="+string(o.BuildExtensionTestDeps))
+}
+
+func (o Options) extensionConfigFlag() string {
+	configs := o.ExtConfig
+	if o.ExtraExtensionConfigs != "" {
+		configs = o.ExtraExtensionConfigs + ";" + o.ExtConfig
+	}
+	return "-DDUCKDB_EXTENSION_CONFIGS=" + configs
+}
+
+// coreExtensions appends the test dependencies of the BUILD_EXTENSION_TEST_DEPS
+// mode to CORE_EXTENSIONS the way the Makefile does, which leaves a leading
+// separator when CORE_EXTENSIONS is empty.
+func (o Options) coreExtensions() string {
+	core := o.CoreExtensions
+	if o.BuildExtensionTestDeps != TestDepsNone && o.DefaultTestExtensionDeps != "" {
+		core += ";" + o.DefaultTestExtensionDeps
+	}
+	if o.BuildExtensionTestDeps == TestDepsFull && o.FullTestExtensionDeps != "" {
+		core += ";" + o.FullTestExtensionDeps
+	}
+	return core
+}
+
+func (o Options) rustTarget() string {
+	switch {
+	case o.DuckDBPlatform == "windows_amd64_mingw" || o.DuckDBPlatform == "windows_amd64_rtools":
+		return "x86_64-pc-windows-gnu"
+	case o.OSXBuildArch == "arm64":
+		return "aarch64-apple-darwin"
+	case o.OSXBuildArch == "x86_64":
+		return "x86_64-apple-darwin"
+	default:
+		return ""
+	}
+}
+
+// toolchainFlags mirrors TOOLCHAIN_FLAGS.
+func (o Options) toolchainFlags() []string {
+	var args []string
+	if o.VCPKGToolchainPath != "" {
+		args = append(args, "-DVCPKG_BUILD=1", "-DCMAKE_TOOLCHAIN_FILE="+o.VCPKGToolchainPath)
+	}
+	if o.VCPKGTargetTriplet != "" {
+		args = append(args, "-DVCPKG_TARGET_TRIPLET="+o.VCPKGTargetTriplet)
+	}
+	if o.VCPKGHostTriplet != "" {
+		args = append(args, "-DVCPKG_HOST_TRIPLET="+o.VCPKGHostTriplet)
+	}
+	return args
+}
+
+// manifestFlags mirrors VCPKG_MANIFEST_FLAGS.
+func (o Options) manifestFlags() []string {
+	switch {
+	case o.NewExtensionBuild:
+		return []string{"-DVCPKG_MANIFEST_DIR=" + o.ProjDir + "build"}
+	case o.UseMergedVCPKGManifest:
+		return []string{"-DVCPKG_MANIFEST_DIR=" + o.ProjDir + "build/extension_configuration"}
+	case o.VCPKGToolchainPath != "":
+		return []string{"-DVCPKG_MANIFEST_DIR=" + o.ProjDir}
+	default:
+		return nil
+	}
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// FromEnv reads the options from the Makefile variables in the environment,
+// starting from Defaults. Switches such as CRASH_ON_ASSERT are on when set to
+// 1, like the Makefile's ifeq checks.
+func FromEnv(getenv func(string) string) (Options, error) {
+	o := Defaults()
+	str := func(name string, dst *string) {
+		if v := getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	words := func(name string) []string { return strings.Fields(getenv(name)) }
+	on := func(name string) bool { return getenv(name) == "1" }
+
+	str("DUCKDB_PLATFORM", &o.DuckDBPlatform)
+	str("OSX_BUILD_ARCH", &o.OSXBuildArch)
+	str("VCPKG_TARGET_TRIPLET", &o.VCPKGTargetTriplet)
+	str("VCPKG_HOST_TRIPLET", &o.VCPKGHostTriplet)
+	str("VCPKG_TOOLCHAIN_PATH", &o.VCPKGToolchainPath)
+	str("PROJ_DIR", &o.ProjDir)
+	str("DUCKDB_SRCDIR", &o.DuckDBSrcDir)
+	str("EMSDK", &o.EMSDK)
+	str("EXT_CONFIG", &o.ExtConfig)
+	str("EXTRA_EXTENSION_CONFIGS", &o.ExtraExtensionConfigs)
+	o.ExtensionFlags = words("EXTENSION_FLAGS")
+	o.ExtFlags = words("EXT_FLAGS")
+	o.ExtReleaseFlags = words("EXT_RELEASE_FLAGS")
+	o.ExtDebugFlags = words("EXT_DEBUG_FLAGS")
+	str("CORE_EXTENSIONS", &o.CoreExtensions)
+	if v := getenv("BUILD_EXTENSION_TEST_DEPS"); v != "" {
+		o.BuildExtensionTestDeps = TestDeps(v)
+	}
+	str("DEFAULT_TEST_EXTENSION_DEPS", &o.DefaultTestExtensionDeps)
+	str("FULL_TEST_EXTENSION_DEPS", &o.FullTestExtensionDeps)
+	o.NewExtensionBuild = getenv("DUCKDB_NEW_EXTENSION_BUILD") != ""
+	o.UseMergedVCPKGManifest = on("USE_MERGED_VCPKG_MANIFEST")
+	o.Ninja = getenv("GEN") == "ninja"
+	str("CUSTOM_LINKER", &o.CustomLinker)
+	str("OVERRIDE_GIT_DESCRIBE", &o.OverrideGitDescribe)
+	str("DUCKDB_PREBUILT_LIBRARY", &o.PrebuiltLibrary)
+	str("BUILD_EXTENSIONS_ONLY", &o.BuildExtensionsOnly)
+	o.CrashOnAssert = on("CRASH_ON_ASSERT")
+	o.BuildBenchmark = on("BUILD_BENCHMARK")
+	o.TreatWarningsAsErrors = on("TREAT_WARNINGS_AS_ERRORS")
+	o.DisableSanitizer = on("DISABLE_SANITIZER")
+	o.DisableUBSan = on("DISABLE_UBSAN")
+	o.ThreadSan = on("THREADSAN")
+
+	for name, dst := range map[string]*bool{
+		"EXTENSION_STATIC_BUILD":       &o.ExtensionStaticBuild,
+		"ENABLE_EXTENSION_AUTOLOADING": &o.EnableExtensionAutoloading,
+		"ENABLE_EXTENSION_AUTOINSTALL": &o.EnableExtensionAutoinstall,
+	} {
+		switch v := getenv(name); v {
+		case "":
+		case "0", "1":
+			*dst = v == "1"
+		default:
+			return Options{}, fmt.Errorf("%s: invalid value %q (must be 0 or 1)", name, v)
+		}
+	}
+	if o.ProjDir != "" && !strings.HasSuffix(o.ProjDir, "/") {
+		o.ProjDir = path.Clean(o.ProjDir) + "/"
+	}
+	return o, nil
+}
+
+// Render joins the command into a line for POSIX shells, quoting arguments
+// that need it.
+func Render(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r)) {
+			return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+		}
+	}
+	return value
+}
@@ -0,0 +1,195 @@
+package cmakeflags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The golden files hold the configure command printed by make -n for
+// makefiles/duckdb_extension.Makefile with PROJ_DIR=/work/quack/ and the
+// variables of each case in the environment.
+func TestCommandMatchesMakefile(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]string{
+		"PROJ_DIR":   "/work/quack",
+		"EXT_CONFIG": "/work/quack/extension_config.cmake",
+	}
+	tests := []struct {
+		golden    string
+		buildType BuildType
+		env       map[string]string
+	}{
+		{
+			golden:    "osx_arm64_relassert.golden",
+			buildType: BuildRelAssert,
+			env: map[string]string{
+				"DUCKDB_PLATFORM":              "osx_arm64",
+				"OSX_BUILD_ARCH":               "arm64",
+				"GEN":                          "ninja",
+				"VCPKG_TOOLCHAIN_PATH":         "/vcpkg/scripts/buildsystems/vcpkg.cmake",
+				"VCPKG_TARGET_TRIPLET":         "arm64-osx-release",
+				"ENABLE_EXTENSION_AUTOLOADING": "1",
+				"ENABLE_EXTENSION_AUTOINSTALL": "1",
+			},
+		},
+		{
+			golden:    "linux_amd64_release.golden",
+			buildType: BuildRelease,
+			env: map[string]string{
+				"DUCKDB_PLATFORM":             "linux_amd64",
+				"VCPKG_TOOLCHAIN_PATH":        "/vcpkg/scripts/buildsystems/vcpkg.cmake",
+				"VCPKG_TARGET_TRIPLET":        "x64-linux-release",
+				"VCPKG_HOST_TRIPLET":          "x64-linux-release",
+				"EXTRA_EXTENSION_CONFIGS":     "/work/extra.cmake",
+				"EXT_FLAGS":                   "-DFOO=1 -DBAR=2",
+				"EXT_RELEASE_FLAGS":           "-DRELEASE_ONLY=1",
+				"DISABLE_SANITIZER":           "1",
+				"CORE_EXTENSIONS":             "json;parquet",
+				"DEFAULT_TEST_EXTENSION_DEPS": "tpch",
+				"TREAT_WARNINGS_AS_ERRORS":    "1",
+			},
+		},
+		{
+			golden:    "windows_amd64_mingw_debug.golden",
+			buildType: BuildDebug,
+			env: map[string]string{
+				"DUCKDB_PLATFORM":             "windows_amd64_mingw",
+				"VCPKG_TOOLCHAIN_PATH":        "C:/vcpkg/scripts/buildsystems/vcpkg.cmake",
+				"VCPKG_TARGET_TRIPLET":        "x64-mingw-static",
+				"VCPKG_HOST_TRIPLET":          "x64-mingw-static",
+				"USE_MERGED_VCPKG_MANIFEST":   "1",
+				"EXT_DEBUG_FLAGS":             "-DDEBUG_ONLY=1",
+				"BUILD_EXTENSION_TEST_DEPS":   "full",
+				"DEFAULT_TEST_EXTENSION_DEPS": "tpch",
+				"FULL_TEST_EXTENSION_DEPS":    "tpcds;icu",
+				"EXTENSION_STATIC_BUILD":      "0",
+			},
+		},
+		{
+			golden:    "wasm_threads.golden",
+			buildType: BuildRelease,
+			env: map[string]string{
+				"DUCKDB_PLATFORM":      "wasm_threads",
+				"EMSDK":                "/emsdk",
+				"VCPKG_TOOLCHAIN_PATH": "/vcpkg/scripts/buildsystems/vcpkg.cmake",
+				"VCPKG_TARGET_TRIPLET": "wasm32-emscripten",
+			},
+		},
+		{
+			golden:    "linux_arm64_reldebug.golden",
+			buildType: BuildRelDebug,
+			env: map[string]string{
+				"DUCKDB_PLATFORM":             "linux_arm64",
+				"DUCKDB_NEW_EXTENSION_BUILD":  "1",
+				"CRASH_ON_ASSERT":             "1",
+				"THREADSAN":                   "1",
+				"DISABLE_UBSAN":               "1",
+				"BUILD_BENCHMARK":             "1",
+				"DUCKDB_PREBUILT_LIBRARY":     "/opt/libduckdb.a",
+				"BUILD_EXTENSIONS_ONLY":       "1",
+				"CUSTOM_LINKER":               "mold",
+				"OVERRIDE_GIT_DESCRIBE":       "v1.5.4-0-g1234567",
+				"BUILD_EXTENSION_TEST_DEPS":   "none",
+				"DEFAULT_TEST_EXTENSION_DEPS": "tpch",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			t.Parallel()
+			opts, err := FromEnv(getenv(base, tt.env))
+			require.NoError(t, err)
+			opts.BuildType = tt.buildType
+
+			got, err := opts.Command()
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "cmakeflags", tt.golden))
+			require.NoError(t, err)
+			assert.Equal(t, shellWords(t, string(data)), got)
+		})
+	}
+}
+
+func TestCommandRejectsInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	opts := Defaults()
+	opts.BuildType = "fast"
+	_, err := opts.Command()
+	require.EqualError(t, err, `invalid build type "fast" (must be debug|release|relassert|reldebug)`)
+
+	opts = Defaults()
+	opts.BuildExtensionTestDeps = "some"
+	_, err = opts.Command()
+	require.EqualError(t, err, "unknown option passed to BUILD_EXTENSION_TEST_DEPS variable: some")
+}
+
+func TestFromEnvRejectsInvalidSwitches(t *testing.T) {
+	t.Parallel()
+
+	_, err := FromEnv(getenv(map[string]string{"EXTENSION_STATIC_BUILD": "yes"}))
+	require.EqualError(t, err, `EXTENSION_STATIC_BUILD: invalid value "yes" (must be 0 or 1)`)
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	got := Render([]string{"cmake", "-DCORE_EXTENSIONS=json;parquet", "-DCUSTOM_LINKER=", "-DCMAKE_CXX_FLAGS= -pthread", "", "-S", "./duckdb"})
+	assert.Equal(t, `cmake '-DCORE_EXTENSIONS=json;parquet' -DCUSTOM_LINKER= '-DCMAKE_CXX_FLAGS= -pthread' '' -S ./duckdb`, got)
+}
+
+func getenv(envs ...map[string]string) func(string) string {
+	return func(name string) string {
+		value := ""
+		for _, env := range envs {
+			if v, ok := env[name]; ok {
+				value = v
+			}
+		}
+		return value
+	}
+}
+
+// shellWords splits a command line printed by make into arguments the way a
+// POSIX shell does for the single and double quotes the Makefile uses.
+func shellWords(t *testing.T, line string) []string {
+	t.Helper()
+	var (
+		words []string
+		word  strings.Builder
+		quote rune
+		inArg bool
+	)
+	for _, r := range strings.TrimSpace(line) {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				words = append(words, word.String())
+				word.Reset()
+				inArg = false
+			}
+		default:
+			word.WriteRune(r)
+			inArg = true
+		}
+	}
+	require.Zero(t, quote, "unterminated quote in %q", line)
+	if inArg {
+		words = append(words, word.String())
+	}
+	return words
+}
@@ -0,0 +1,122 @@
+// Package compat decides whether a DuckDB release can load an extension
+// binary, following the rules the abi_type of the metadata footer implies for
+// its duckdb_version field.
+package compat
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+)
+
+// Version is a DuckDB or C API version such as v1.2.0 or v1.3.0-dev42.
+type Version struct {
+	Major, Minor, Patch int
+	PreRelease          string
+}
+
+// ParseVersion parses a vMAJOR.MINOR.PATCH version with an optional
+// pre-release suffix. The leading v is optional.
+func ParseVersion(s string) (Version, error) {
+	core, pre, _ := strings.Cut(strings.TrimPrefix(s, "v"), "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q (must be vMAJOR.MINOR.PATCH)", s)
+	}
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q (must be vMAJOR.MINOR.PATCH)", s)
+		}
+		numbers[i] = n
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], PreRelease: pre}, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare orders versions like semantic versioning does: a pre-release sorts
+// before the release it leads up to.
+func (v Version) Compare(o Version) int {
+	if c := cmp.Or(cmp.Compare(v.Major, o.Major), cmp.Compare(v.Minor, o.Minor), cmp.Compare(v.Patch, o.Patch)); c != 0 {
+		return c
+	}
+	switch {
+	case v.PreRelease == o.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case o.PreRelease == "":
+		return -1
+	default:
+		return strings.Compare(v.PreRelease, o.PreRelease)
+	}
+}
+
+// Result is the decision for one extension and DuckDB version.
+type Result struct {
+	ABIType       string `json:"abi_type"`
+	Requires      string `json:"requires"`
+	DuckDBVersion string `json:"duckdb_version"`
+	Loadable      bool   `json:"loadable"`
+	Reason        string `json:"reason"`
+}
+
+// Check decides whether DuckDB duckdbVersion can load an extension with the
+// footer m:
+//
+//   - C_STRUCT extensions store the minimum C API version they need. Every
+//     release of the same major version from that version on provides it.
+//   - C_STRUCT_UNSTABLE extensions use the unstable C API, which is pinned to
+//     the exact DuckDB version they were built against.
+//   - CPP extensions link the DuckDB C++ API and only load in the exact
+//     DuckDB version they were built against. Footers written before the
+//     abi_type field existed have it empty and are CPP extensions.
+func Check(m metadata.Metadata, duckdbVersion string) (Result, error) {
+	abiType := cmp.Or(m.ABIType, metadata.ABITypeCPP)
+	r := Result{ABIType: abiType, Requires: m.DuckDBVersion, DuckDBVersion: duckdbVersion}
+	switch abiType {
+	case metadata.ABITypeCStruct:
+		required, err := ParseVersion(m.DuckDBVersion)
+		if err != nil {
+			return Result{}, fmt.Errorf("footer C API version: %w", err)
+		}
+		provided, err := ParseVersion(duckdbVersion)
+		if err != nil {
+			return Result{}, fmt.Errorf("DuckDB version: %w", err)
+		}
+		switch {
+		case required.Major > 0 && required.Major != provided.Major:
+			r.Reason = fmt.Sprintf("the extension needs C API %s, and the stable C API only stays compatible within major version %d", required, required.Major)
+		case required.Compare(provided) > 0:
+			r.Reason = fmt.Sprintf("the extension needs at least C API %s, which DuckDB %s predates", required, provided)
+		default:
+			r.Loadable = true
+			r.Reason = fmt.Sprintf("the extension needs at least C API %s, which DuckDB %s provides", required, provided)
+		}
+	case metadata.ABITypeCStructUnstable, metadata.ABITypeCPP:
+		api := "unstable C API"
+		if abiType == metadata.ABITypeCPP {
+			api = "C++ API"
+		}
+		if m.DuckDBVersion == duckdbVersion {
+			r.Loadable = true
+			r.Reason = fmt.Sprintf("the extension uses the %s of DuckDB %s, which is the version loading it", api, m.DuckDBVersion)
+		} else {
+			r.Reason = fmt.Sprintf("the extension uses the %s, which pins it to DuckDB %s exactly", api, m.DuckDBVersion)
+		}
+	default:
+		return Result{}, fmt.Errorf("invalid ABI type %q (must be %s|%s|%s)", m.ABIType, metadata.ABITypeCStruct, metadata.ABITypeCStructUnstable, metadata.ABITypeCPP)
+	}
+	return r, nil
+}
@@ -0,0 +1,91 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	v, err := ParseVersion("v1.3.0-dev42")
+	require.NoError(t, err)
+	assert.Equal(t, Version{Major: 1, Minor: 3, PreRelease: "dev42"}, v)
+	assert.Equal(t, "v1.3.0-dev42", v.String())
+
+	v, err = ParseVersion("1.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.1", v.String())
+
+	for _, invalid := range []string{"", "v1.2", "v1.2.x", "main", "v1.-2.0"} {
+		_, err := ParseVersion(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	t.Parallel()
+
+	order := []string{"v0.10.3", "v1.2.0-dev1", "v1.2.0-dev2", "v1.2.0", "v1.2.1", "v1.10.0"}
+	for i := range order[1:] {
+		a, err := ParseVersion(order[i])
+		require.NoError(t, err)
+		b, err := ParseVersion(order[i+1])
+		require.NoError(t, err)
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+		assert.Equal(t, 0, a.Compare(a))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		abiType    string
+		requires   string
+		duckdb     string
+		loadable   bool
+		wantReason string
+	}{
+		{name: "C API on a later release", abiType: metadata.ABITypeCStruct, requires: "v1.2.0", duckdb: "v1.5.4", loadable: true, wantReason: "the extension needs at least C API v1.2.0, which DuckDB v1.5.4 provides"},
+		{name: "C API on the same release", abiType: metadata.ABITypeCStruct, requires: "v1.2.0", duckdb: "v1.2.0", loadable: true},
+		{name: "C API on an older release", abiType: metadata.ABITypeCStruct, requires: "v1.2.0", duckdb: "v1.1.3", wantReason: "the extension needs at least C API v1.2.0, which DuckDB v1.1.3 predates"},
+		{name: "C API on a dev build of its release", abiType: metadata.ABITypeCStruct, requires: "v1.2.0", duckdb: "v1.2.0-dev5"},
+		{name: "C API on the next major", abiType: metadata.ABITypeCStruct, requires: "v1.2.0", duckdb: "v2.0.0", wantReason: "the stable C API only stays compatible within major version 1"},
+		{name: "pre-stable C API", abiType: metadata.ABITypeCStruct, requires: "v0.0.1", duckdb: "v1.5.4", loadable: true},
+		{name: "unstable C API on its release", abiType: metadata.ABITypeCStructUnstable, requires: "v1.5.4", duckdb: "v1.5.4", loadable: true},
+		{name: "unstable C API on a patch release", abiType: metadata.ABITypeCStructUnstable, requires: "v1.5.3", duckdb: "v1.5.4", wantReason: "the extension uses the unstable C API, which pins it to DuckDB v1.5.3 exactly"},
+		{name: "C++ on its release", abiType: metadata.ABITypeCPP, requires: "v1.5.4", duckdb: "v1.5.4", loadable: true, wantReason: "the extension uses the C++ API of DuckDB v1.5.4, which is the version loading it"},
+		{name: "C++ on a dev build", abiType: metadata.ABITypeCPP, requires: "6536a77232", duckdb: "v1.5.4"},
+		{name: "footer without ABI type", abiType: "", requires: "v0.10.3", duckdb: "v0.10.3", loadable: true, wantReason: "C++ API"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := Check(metadata.Metadata{ABIType: tc.abiType, DuckDBVersion: tc.requires}, tc.duckdb)
+			require.NoError(t, err)
+			assert.Equal(t, tc.loadable, r.Loadable, r.Reason)
+			assert.Contains(t, r.Reason, tc.wantReason)
+		})
+	}
+}
+
+func TestCheckRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	_, err := Check(metadata.Metadata{ABIType: "RUST", DuckDBVersion: "v1.2.0"}, "v1.2.0")
+	require.ErrorContains(t, err, `invalid ABI type "RUST"`)
+
+	_, err = Check(metadata.Metadata{ABIType: metadata.ABITypeCStruct, DuckDBVersion: "abcdef"}, "v1.2.0")
+	require.ErrorContains(t, err, `footer C API version: invalid version "abcdef"`)
+
+	_, err = Check(metadata.Metadata{ABIType: metadata.ABITypeCStruct, DuckDBVersion: "v1.2.0"}, "main")
+	require.ErrorContains(t, err, `DuckDB version: invalid version "main"`)
+}
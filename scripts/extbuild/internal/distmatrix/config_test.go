@@ -89,3 +89,23 @@ func TestRunnerOverrideAliasesCoverAllRunnerEntriesInDistributionMatrix(t *testi
 
 	assert.Equal(t, expectedAliases, actualAliases)
 }
+
+func TestParseMatrixFileRejectsInvalidTestModes(t *testing.T) {
+	t.Parallel()
+
+	const inputJSON = `{
+  "linux": {
+    "include": [
+      {
+        "duckdb_arch": "linux_amd64",
+        "runner": "ubuntu-24.04",
+        "test_mode": "docker",
+        "c_api_test_mode": "container"
+      }
+    ]
+  }
+}`
+
+	_, err := ParseMatrixFile([]byte(inputJSON))
+	require.EqualError(t, err, `platform linux entry linux_amd64: invalid test mode: "container" (must be native|docker|none)`)
+}
@@ -31,6 +31,17 @@ type Entry struct {
 	VCPKGHostTriplet   string `json:"vcpkg_host_triplet"`
 	RunInReducedCIMode bool   `json:"run_in_reduced_ci_mode"`
 	OptIn              bool   `json:"opt_in"`
+
+	// MaxGLIBCVersion and MaxGLIBCXXVersion cap the symbol versions a Linux
+	// extension binary may require, see extbuild audit glibc.
+	MaxGLIBCVersion   string `json:"max_glibc_version,omitempty"`
+	MaxGLIBCXXVersion string `json:"max_glibcxx_version,omitempty"`
+
+	// TestMode is where the tests of C++ extensions run, CAPITestMode where
+	// the Python test runner of C API extensions runs. Empty means none, see
+	// extbuild test should-run.
+	TestMode     TestMode `json:"test_mode,omitempty"`
+	CAPITestMode TestMode `json:"c_api_test_mode,omitempty"`
 }
 
 type PlatformMatrix struct {
@@ -44,8 +55,23 @@ type PlatformOutput struct {
 
 	VCPKGTargetTriplet string `json:"vcpkg_target_triplet,omitempty"`
 	VCPKGHostTriplet   string `json:"vcpkg_host_triplet,omitempty"`
+
+	TestMode     TestMode `json:"test_mode,omitempty"`
+	CAPITestMode TestMode `json:"c_api_test_mode,omitempty"`
 }
 
+// TestMode says where the tests of an arch can run in CI.
+type TestMode string
+
+const (
+	// TestModeNative runs the tests on the runner itself.
+	TestModeNative TestMode = "native"
+	// TestModeDocker runs the tests in the build container of the arch.
+	TestModeDocker TestMode = "docker"
+	// TestModeNone skips the tests, e.g. for cross-compiled arches.
+	TestModeNone TestMode = "none"
+)
+
 type ReducedCIMode string
 
 const (
@@ -81,6 +107,11 @@ func ParseMatrixFile(data []byte) (MatrixFile, error) {
 			if strings.TrimSpace(entry.Runner) == "" {
 				return nil, fmt.Errorf("platform %s entry %s has empty runner", platform, entry.DuckDBArch)
 			}
+			for _, mode := range []TestMode{entry.TestMode, entry.CAPITestMode} {
+				if _, err := ParseTestMode(string(mode)); err != nil {
+					return nil, fmt.Errorf("platform %s entry %s: %w", platform, entry.DuckDBArch, err)
+				}
+			}
 		}
 	}
 	return matrix, nil
@@ -157,6 +188,30 @@ func sortedPlatforms(m map[string]PlatformMatrix) []string {
 	return platforms
 }
 
+// DuckDBArchs returns every duckdb_arch of the matrix file, sorted.
+func (m MatrixFile) DuckDBArchs() []string {
+	var archs []string
+	for _, cfg := range m {
+		for _, entry := range cfg.Include {
+			archs = append(archs, entry.DuckDBArch)
+		}
+	}
+	slices.Sort(archs)
+	return archs
+}
+
+// Entry returns the matrix entry for a duckdb_arch.
+func (m MatrixFile) Entry(duckdbArch string) (Entry, bool) {
+	for _, cfg := range m {
+		for _, entry := range cfg.Include {
+			if entry.DuckDBArch == duckdbArch {
+				return entry, true
+			}
+		}
+	}
+	return Entry{}, false
+}
+
 func sortedMatrixPlatforms(m MatrixFile) []string {
 	platforms := make([]string, 0, len(m))
 	for platform := range m {
@@ -215,6 +270,20 @@ func ParseReducedCIMode(mode string) (ReducedCIMode, error) {
 	}
 }
 
+// ParseTestMode parses a test mode. Empty maps to none.
+func ParseTestMode(mode string) (TestMode, error) {
+	switch mode {
+	case "", string(TestModeNone):
+		return TestModeNone, nil
+	case string(TestModeNative):
+		return TestModeNative, nil
+	case string(TestModeDocker):
+		return TestModeDocker, nil
+	default:
+		return "", fmt.Errorf("invalid test mode: %q (must be native|docker|none)", mode)
+	}
+}
+
 func normalizePlatforms(platforms []string) ([]string, error) {
 	clean := normalizeValues(platforms)
 	if len(clean) == 0 {
@@ -298,6 +367,8 @@ func toPlatformOutput(entry Entry) PlatformOutput {
 		OSXBuildArch:       entry.OSXBuildArch,
 		VCPKGTargetTriplet: entry.VCPKGTargetTriplet,
 		VCPKGHostTriplet:   entry.VCPKGHostTriplet,
+		TestMode:           entry.TestMode,
+		CAPITestMode:       entry.CAPITestMode,
 	}
 }
 
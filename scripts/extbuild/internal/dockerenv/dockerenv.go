@@ -0,0 +1,185 @@
+// Package dockerenv assembles the Docker --env-file the Linux build
+// containers of _extension_distribution.yml run with.
+package dockerenv
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+)
+
+// BuildDir is where the extension repository is mounted in the container.
+const BuildDir = "/duckdb_build_dir"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Variable is a single line of the env file. The values of secret variables
+// are masked when logged.
+type Variable struct {
+	Name   string
+	Value  string
+	Secret bool
+}
+
+// LogValue implements slog.LogValuer.
+func (v Variable) LogValue() slog.Value {
+	if v.Secret && v.Value != "" {
+		return slog.StringValue(v.Name + "=***")
+	}
+	return slog.StringValue(v.Name + "=" + v.Value)
+}
+
+// secretSources maps the vcpkg cache credentials of the container to the
+// environment variables the workflow passes the repository secrets in.
+var secretSources = []struct {
+	name   string
+	source string
+}{
+	{name: "AWS_ACCESS_KEY_ID", source: "VCPKG_CACHING_AWS_ACCESS_KEY_ID"},
+	{name: "AWS_SECRET_ACCESS_KEY", source: "VCPKG_CACHING_AWS_SECRET_ACCESS_KEY"},
+	{name: "AWS_ENDPOINT_URL", source: "VCPKG_CACHING_AWS_ENDPOINT_URL"},
+	{name: "AWS_DEFAULT_REGION", source: "VCPKG_CACHING_AWS_DEFAULT_REGION"},
+}
+
+// Options are the inputs of the env file.
+type Options struct {
+	Inputs inputs.Inputs
+	// DuckDBArch and VCPKGTargetTriplet come from the matrix entry.
+	DuckDBArch         string
+	VCPKGTargetTriplet string
+	// Getenv reads VCPKG_BINARY_SOURCES and the VCPKG_CACHING_AWS_* secrets.
+	Getenv func(string) string
+}
+
+// Build returns the variables of the env file, in the order of the heredoc
+// the workflow used to write. The test_env_variables of test_config follow;
+// one that names an existing variable replaces its value instead of adding a
+// second line. Every variable is validated.
+func Build(opts Options) ([]Variable, error) {
+	in := opts.Inputs
+	if in == nil {
+		in = inputs.Inputs{}
+	}
+	getenv := opts.Getenv
+	if getenv == nil {
+		getenv = func(string) string { return "" }
+	}
+	vcpkgInstalled := fmt.Sprintf("%s/build/%s/vcpkg_installed/%s", BuildDir, in.String("build_type"), opts.VCPKGTargetTriplet)
+	buildShell := "0"
+	if in.Bool("build_duckdb_shell") {
+		buildShell = "1"
+	}
+
+	vars := []Variable{
+		{Name: "VCPKG_BINARY_SOURCES", Value: getenv("VCPKG_BINARY_SOURCES")},
+		{Name: "USE_MERGED_VCPKG_MANIFEST", Value: in.String("use_merged_vcpkg_manifest")},
+	}
+	for _, secret := range secretSources {
+		vars = append(vars, Variable{Name: secret.name, Value: getenv(secret.source), Secret: true})
+	}
+	vars = append(vars,
+		Variable{Name: "AWS_REQUEST_CHECKSUM_CALCULATION", Value: "when_required"},
+		Variable{Name: "VCPKG_TARGET_TRIPLET", Value: opts.VCPKGTargetTriplet},
+		Variable{Name: "VCPKG_OVERLAY_TRIPLETS", Value: BuildDir + "/" + in.String("vcpkg_overlay_triplets")},
+		Variable{Name: "VCPKG_OVERLAY_PORTS", Value: BuildDir + "/" + in.String("vcpkg_overlay_ports")},
+		Variable{Name: "CUDAARCHS", Value: in.String("cuda_archs")},
+		Variable{Name: "VCPKG_CUDA_VERSION", Value: in.String("cuda_version")},
+		Variable{Name: "BUILD_SHELL", Value: buildShell},
+		Variable{Name: "OPENSSL_ROOT_DIR", Value: vcpkgInstalled},
+		Variable{Name: "OPENSSL_DIR", Value: vcpkgInstalled},
+		Variable{Name: "OPENSSL_USE_STATIC_LIBS", Value: "true"},
+		Variable{Name: "DUCKDB_PLATFORM", Value: opts.DuckDBArch},
+		Variable{Name: "DUCKDB_GIT_VERSION", Value: in.String("duckdb_version")},
+		Variable{Name: "ENABLE_EXTENSION_AUTOINSTALL", Value: "1"},
+		Variable{Name: "ENABLE_EXTENSION_AUTOLOADING", Value: "1"},
+		Variable{Name: "EXTENSION_NAME", Value: in.String("extension_name")},
+		Variable{Name: "EXTENSION_CANONICAL", Value: in.String("extension_canonical")},
+		Variable{Name: "LINUX_CI_IN_DOCKER", Value: "1"},
+		Variable{Name: "GITHUB_ACTIONS", Value: "true"},
+		Variable{Name: "CI", Value: "true"},
+		Variable{Name: "CCACHE_MAXSIZE", Value: "1G"},
+		Variable{Name: "CCACHE_NOCOMPRESS", Value: "1"},
+		Variable{Name: "SUBSET_EXTENSIONS_TESTS", Value: in.String("extensions_test_selection")},
+	)
+
+	testConfig, err := in.TestConfig()
+	if err != nil {
+		return nil, err
+	}
+	vars = merge(vars, testConfig.Env())
+	// The Makefiles only see the skip_archs of test_config through SKIP_TESTS
+	// in the container.
+	if testConfig.Skips(opts.DuckDBArch) {
+		vars = merge(vars, [][2]string{{"SKIP_TESTS", "1"}})
+	}
+
+	for _, v := range vars {
+		if err := validate(v); err != nil {
+			return nil, err
+		}
+	}
+	return vars, nil
+}
+
+func merge(vars []Variable, env [][2]string) []Variable {
+	index := make(map[string]int, len(vars))
+	for i, v := range vars {
+		index[v.Name] = i
+	}
+	for _, kv := range env {
+		if i, ok := index[kv[0]]; ok {
+			vars[i].Value = kv[1]
+			continue
+		}
+		index[kv[0]] = len(vars)
+		vars = append(vars, Variable{Name: kv[0], Value: kv[1]})
+	}
+	return vars
+}
+
+// validate rejects variables Docker would misread. An env file has no
+// quoting: every line is NAME=VALUE taken literally, so a line break would
+// start a new variable. Errors never include the value.
+func validate(v Variable) error {
+	if !namePattern.MatchString(v.Name) {
+		return fmt.Errorf("invalid variable name %q (must match %s)", v.Name, namePattern)
+	}
+	if strings.ContainsAny(v.Value, "\r\n") {
+		return fmt.Errorf("variable %s: value contains a line break", v.Name)
+	}
+	if strings.ContainsRune(v.Value, 0) {
+		return fmt.Errorf("variable %s: value contains a NUL byte", v.Name)
+	}
+	return nil
+}
+
+// Write writes vars as an env file, one NAME=VALUE line per variable.
+func Write(w io.Writer, vars []Variable) error {
+	var b strings.Builder
+	for _, v := range vars {
+		if err := validate(v); err != nil {
+			return err
+		}
+		b.WriteString(v.Name + "=" + v.Value + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMasks writes a GitHub Actions add-mask command for the value of every
+// non-empty secret variable, so later log output of any step redacts it.
+func WriteMasks(w io.Writer, vars []Variable) error {
+	for _, v := range vars {
+		if !v.Secret || v.Value == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "::add-mask::%s\n", v.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package dockerenv
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions(in inputs.Inputs) Options {
+	env := map[string]string{
+		"VCPKG_BINARY_SOURCES":                "clear;http,https://vcpkg-cache.duckdb.org,read",
+		"VCPKG_CACHING_AWS_ACCESS_KEY_ID":     "AKIAQUACK",
+		"VCPKG_CACHING_AWS_SECRET_ACCESS_KEY": "s3cr3t",
+	}
+	return Options{
+		Inputs:             in,
+		DuckDBArch:         "linux_amd64",
+		VCPKGTargetTriplet: "x64-linux-release",
+		Getenv:             func(name string) string { return env[name] },
+	}
+}
+
+// The expected file is what the heredoc of the "Create env file for docker"
+// step wrote for the same inputs, followed by the jq test_env_variables lines.
+func TestWriteMatchesWorkflowHeredoc(t *testing.T) {
+	t.Parallel()
+
+	vars, err := Build(testOptions(inputs.Inputs{
+		"extension_name": "quack",
+		"duckdb_version": "v1.2.0",
+		"build_type":     "release",
+		"test_config":    `{"test_env_variables": {"QUACK_MODE": "loud", "A_FLAG": "a=b c"}}`,
+	}))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, vars))
+	assert.Equal(t, `VCPKG_BINARY_SOURCES=clear;http,https://vcpkg-cache.duckdb.org,read
+USE_MERGED_VCPKG_MANIFEST=
+AWS_ACCESS_KEY_ID=AKIAQUACK
+AWS_SECRET_ACCESS_KEY=s3cr3t
+AWS_ENDPOINT_URL=
+AWS_DEFAULT_REGION=
+AWS_REQUEST_CHECKSUM_CALCULATION=when_required
+VCPKG_TARGET_TRIPLET=x64-linux-release
+VCPKG_OVERLAY_TRIPLETS=/duckdb_build_dir/extension-ci-tools/toolchains
+VCPKG_OVERLAY_PORTS=/duckdb_build_dir/extension-ci-tools/vcpkg_ports
+CUDAARCHS=
+VCPKG_CUDA_VERSION=13
+BUILD_SHELL=1
+OPENSSL_ROOT_DIR=/duckdb_build_dir/build/release/vcpkg_installed/x64-linux-release
+OPENSSL_DIR=/duckdb_build_dir/build/release/vcpkg_installed/x64-linux-release
+OPENSSL_USE_STATIC_LIBS=true
+DUCKDB_PLATFORM=linux_amd64
+DUCKDB_GIT_VERSION=v1.2.0
+ENABLE_EXTENSION_AUTOINSTALL=1
+ENABLE_EXTENSION_AUTOLOADING=1
+EXTENSION_NAME=quack
+EXTENSION_CANONICAL=
+LINUX_CI_IN_DOCKER=1
+GITHUB_ACTIONS=true
+CI=true
+CCACHE_MAXSIZE=1G
+CCACHE_NOCOMPRESS=1
+SUBSET_EXTENSIONS_TESTS=regular
+A_FLAG=a=b c
+QUACK_MODE=loud
+`, out.String())
+}
+
+func TestBuildMergesTestEnvIntoExistingVariables(t *testing.T) {
+	t.Parallel()
+
+	vars, err := Build(testOptions(inputs.Inputs{
+		"build_duckdb_shell": false,
+		"test_config":        `{"test_env_variables": {"CCACHE_MAXSIZE": "5G"}}`,
+	}))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, vars))
+	assert.Equal(t, 1, strings.Count(out.String(), "CCACHE_MAXSIZE="))
+	assert.Contains(t, out.String(), "\nCCACHE_MAXSIZE=5G\n")
+	assert.Contains(t, out.String(), "\nBUILD_SHELL=0\n")
+	assert.True(t, strings.HasSuffix(out.String(), "SUBSET_EXTENSIONS_TESTS=regular\n"))
+}
+
+func TestBuildSkipsTestsOfSkippedArchs(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions(inputs.Inputs{"test_config": `{"skip_archs": ["linux_arm64"]}`})
+	vars, err := Build(opts)
+	require.NoError(t, err)
+	assert.NotContains(t, vars, Variable{Name: "SKIP_TESTS", Value: "1"})
+
+	opts.DuckDBArch = "linux_arm64"
+	vars, err = Build(opts)
+	require.NoError(t, err)
+	assert.Contains(t, vars, Variable{Name: "SKIP_TESTS", Value: "1"})
+}
+
+func TestBuildRejectsInvalidVariables(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   inputs.Inputs
+		want string
+	}{
+		{
+			name: "newline in input",
+			in:   inputs.Inputs{"extension_name": "quack\nEVIL=1"},
+			want: "variable EXTENSION_NAME: value contains a line break",
+		},
+		{
+			name: "newline in test env",
+			in:   inputs.Inputs{"test_config": `{"test_env_variables": {"QUACK": "a\r\nb"}}`},
+			want: "variable QUACK: value contains a line break",
+		},
+		{
+			name: "invalid test env name",
+			in:   inputs.Inputs{"test_config": `{"test_env_variables": {"QUACK MODE": "loud"}}`},
+			want: `invalid variable name "QUACK MODE" (must match ^[A-Za-z_][A-Za-z0-9_]*$)`,
+		},
+		{
+			name: "non-string test env value",
+			in:   inputs.Inputs{"test_config": `{"test_env_variables": {"QUACK": 1}}`},
+			want: "parse test_config: test_env_variables.QUACK must be a string, got a number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Build(testOptions(tt.in))
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	opts := testOptions(nil)
+	opts.Getenv = func(name string) string {
+		if name == "VCPKG_CACHING_AWS_SECRET_ACCESS_KEY" {
+			return "line1\nline2"
+		}
+		return ""
+	}
+	_, err := Build(opts)
+	require.EqualError(t, err, "variable AWS_SECRET_ACCESS_KEY: value contains a line break")
+}
+
+func TestSecretsAreMasked(t *testing.T) {
+	t.Parallel()
+
+	vars, err := Build(testOptions(nil))
+	require.NoError(t, err)
+
+	var masks bytes.Buffer
+	require.NoError(t, WriteMasks(&masks, vars))
+	assert.Equal(t, "::add-mask::AKIAQUACK\n::add-mask::s3cr3t\n", masks.String())
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	for _, v := range vars {
+		logger.Info("variable", "variable", v)
+	}
+	assert.NotContains(t, logs.String(), "s3cr3t")
+	assert.Contains(t, logs.String(), "AWS_SECRET_ACCESS_KEY=***")
+	assert.Contains(t, logs.String(), "DUCKDB_PLATFORM=linux_amd64")
+}
@@ -0,0 +1,63 @@
+package inputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+)
+
+// Inputs holds the values passed to _extension_distribution.yml, typically
+// obtained through ${{ toJSON(inputs) }}.
+type Inputs map[string]any
+
+func Parse(data []byte) (Inputs, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var in Inputs
+	if err := decoder.Decode(&in); err != nil {
+		return nil, fmt.Errorf("parse inputs: %w", err)
+	}
+	if err := decoder.Decode(new(struct{})); err != io.EOF {
+		return nil, errors.New("parse inputs: invalid JSON: multiple top-level values")
+	}
+	if in == nil {
+		return nil, errors.New("parse inputs: expected a JSON object")
+	}
+	return in, nil
+}
+
+// String returns a string input, falling back to its workflow default when the
+// input is absent or has a different type.
+func (in Inputs) String(name string) string {
+	if value, ok := in[name].(string); ok {
+		return value
+	}
+	if value, ok := DistributionInputs[name].Default.(string); ok {
+		return value
+	}
+	return ""
+}
+
+// Bool returns a boolean input, falling back to its workflow default when the
+// input is absent or has a different type.
+func (in Inputs) Bool(name string) bool {
+	if value, ok := in[name].(bool); ok {
+		return value
+	}
+	value, _ := DistributionInputs[name].Default.(bool)
+	return value
+}
+
+// TestConfig parses the test_config input.
+func (in Inputs) TestConfig() (testconfig.Config, error) {
+	config, err := testconfig.Parse(in.String("test_config"))
+	if err != nil {
+		return testconfig.Config{}, fmt.Errorf("parse test_config: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,49 @@
+package inputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RenderText formats issues one per line for terminal output.
+func RenderText(issues []Issue) string {
+	var b strings.Builder
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "%s: %s: %s\n", issue.Severity, issue.Input, issue.Message)
+	}
+	return b.String()
+}
+
+// RenderGitHubAnnotations formats issues as GitHub Actions workflow commands
+// so they show up as annotations on the workflow run.
+func RenderGitHubAnnotations(issues []Issue) string {
+	var b strings.Builder
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "::%s title=Invalid workflow input %s::%s\n", issue.Severity, escapeProperty(issue.Input), escapeData(issue.Message))
+	}
+	return b.String()
+}
+
+func RenderJSON(issues []Issue) (string, error) {
+	if issues == nil {
+		issues = []Issue{}
+	}
+	payload, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payload) + "\n", nil
+}
+
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
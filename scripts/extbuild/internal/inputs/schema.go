@@ -0,0 +1,58 @@
+package inputs
+
+type Kind string
+
+const (
+	KindString  Kind = "string"
+	KindBoolean Kind = "boolean"
+)
+
+// Definition describes a single workflow_call input.
+type Definition struct {
+	Kind     Kind
+	Required bool
+	Default  any
+}
+
+// DistributionInputs mirrors the workflow_call inputs of
+// .github/workflows/_extension_distribution.yml.
+var DistributionInputs = map[string]Definition{
+	"extension_name":               {Kind: KindString, Required: true},
+	"extension_canonical":          {Kind: KindString, Default: ""},
+	"duckdb_version":               {Kind: KindString, Required: true},
+	"ci_tools_version":             {Kind: KindString, Required: true},
+	"exclude_archs":                {Kind: KindString, Default: ""},
+	"opt_in_archs":                 {Kind: KindString, Default: ""},
+	"artifact_postfix":             {Kind: KindString, Default: ""},
+	"vcpkg_url":                    {Kind: KindString, Default: "https://github.com/microsoft/vcpkg.git"},
+	"vcpkg_commit":                 {Kind: KindString, Default: "84bab45d415d22042bd0b9081aea57f362da3f35"},
+	"vcpkg_binary_sources":         {Kind: KindString, Default: ""},
+	"vcpkg_extra_dependencies":     {Kind: KindString, Default: ""},
+	"build_duckdb_shell":           {Kind: KindBoolean, Default: true},
+	"override_repository":          {Kind: KindString, Default: ""},
+	"override_ref":                 {Kind: KindString, Default: ""},
+	"override_ci_tools_repository": {Kind: KindString, Default: "duckdb/extension-ci-tools"},
+	"override_duckdb_repository":   {Kind: KindString, Default: ""},
+	"set_caller_as_duckdb":         {Kind: KindBoolean, Default: false},
+	"extra_toolchains":             {Kind: KindString, Default: ""},
+	"use_merged_vcpkg_manifest":    {Kind: KindString, Default: ""},
+	"rust_logs":                    {Kind: KindBoolean, Default: false},
+	"extension_tag":                {Kind: KindString, Default: ""},
+	"duckdb_tag":                   {Kind: KindString, Default: ""},
+	"skip_tests":                   {Kind: KindBoolean, Default: false},
+	"save_cache":                   {Kind: KindBoolean, Default: true},
+	"enable_rust":                  {Kind: KindBoolean, Default: false},
+	"test_config":                  {Kind: KindString, Default: "{}"},
+	"build_type":                   {Kind: KindString, Default: "release"},
+	"upload_all_extensions":        {Kind: KindBoolean, Default: false},
+	"extra_extension_config":       {Kind: KindString, Default: ""},
+	"reduced_ci_mode":              {Kind: KindString, Default: "auto"},
+	"run_disk_clean_step":          {Kind: KindBoolean, Default: true},
+	"extensions_test_selection":    {Kind: KindString, Default: "regular"},
+	"runners":                      {Kind: KindString, Default: "{}"},
+	"post_build_command":           {Kind: KindString, Default: ""},
+	"vcpkg_overlay_ports":          {Kind: KindString, Default: "extension-ci-tools/vcpkg_ports"},
+	"vcpkg_overlay_triplets":       {Kind: KindString, Default: "extension-ci-tools/toolchains"},
+	"cuda_archs":                   {Kind: KindString, Default: ""},
+	"cuda_version":                 {Kind: KindString, Default: "13"},
+}
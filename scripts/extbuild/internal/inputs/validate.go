@@ -0,0 +1,244 @@
+package inputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+	"github.com/duckdb/extension-ci-tools/internal/toolchain"
+	"github.com/duckdb/extension-ci-tools/internal/vcpkgdeps"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single validation finding for one workflow input.
+type Issue struct {
+	Input    string   `json:"input"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+var (
+	validBuildTypes               = []string{"release", "debug", "relassert", "reldebug"}
+	validExtensionsTestSelections = []string{"regular", "complete"}
+
+	// cudaArchPattern matches a single CMAKE_CUDA_ARCHITECTURES entry such as
+	// 75, 90a or 86-real.
+	cudaArchPattern      = regexp.MustCompile(`^[1-9][0-9]{1,2}[af]?(-real|-virtual)?$`)
+	cudaArchSpecialNames = []string{"all", "all-major", "native"}
+)
+
+// Validate checks inputs against the input schema, the known toolchains and
+// the distribution matrix. The returned issues are sorted by input name.
+func Validate(in Inputs, matrix distmatrix.MatrixFile) []Issue {
+	v := validator{}
+
+	v.checkSchema(in)
+	v.checkOneOf("build_type", in.String("build_type"), validBuildTypes)
+	v.checkOneOf("extensions_test_selection", in.String("extensions_test_selection"), validExtensionsTestSelections)
+	v.checkToolchains(in.String("extra_toolchains"))
+	v.checkCUDAArchs(in.String("cuda_archs"))
+
+	if _, err := distmatrix.ParseReducedCIMode(in.String("reduced_ci_mode")); err != nil {
+		v.add("reduced_ci_mode", SeverityError, "%v", err)
+	}
+	if _, err := distmatrix.ParseRunnerOverrides(in.String("runners")); err != nil {
+		v.add("runners", SeverityError, "%v", err)
+	}
+
+	knownArchs := matrix.DuckDBArchs()
+	v.checkArchList("exclude_archs", in.String("exclude_archs"), knownArchs)
+	v.checkArchList("opt_in_archs", in.String("opt_in_archs"), knownArchs)
+	v.checkTestConfig(in.String("test_config"), knownArchs)
+	v.checkVCPKGExtraDependencies(in, matrix, knownArchs)
+
+	slices.SortStableFunc(v.issues, func(a, b Issue) int {
+		return strings.Compare(a.Input, b.Input)
+	})
+	return v.issues
+}
+
+// HasErrors reports whether any issue has error severity.
+func HasErrors(issues []Issue) bool {
+	return slices.ContainsFunc(issues, func(issue Issue) bool {
+		return issue.Severity == SeverityError
+	})
+}
+
+type validator struct {
+	issues []Issue
+}
+
+func (v *validator) add(input string, severity Severity, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		Input:    input,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) checkSchema(in Inputs) {
+	for name, value := range in {
+		def, ok := DistributionInputs[name]
+		if !ok {
+			v.add(name, SeverityWarning, "unknown input, it is ignored by _extension_distribution.yml")
+			continue
+		}
+		switch value.(type) {
+		case string:
+			if def.Kind != KindString {
+				v.add(name, SeverityError, "must be a %s, got a string", def.Kind)
+			}
+		case bool:
+			if def.Kind != KindBoolean {
+				v.add(name, SeverityError, "must be a %s, got a boolean", def.Kind)
+			}
+		default:
+			v.add(name, SeverityError, "must be a %s, got %s", def.Kind, jsonTypeName(value))
+		}
+	}
+
+	for name, def := range DistributionInputs {
+		if def.Required && strings.TrimSpace(in.String(name)) == "" {
+			v.add(name, SeverityError, "is required")
+		}
+	}
+}
+
+func (v *validator) checkOneOf(input, value string, valid []string) {
+	if !slices.Contains(valid, value) {
+		v.add(input, SeverityError, "invalid value %q (must be %s)", value, strings.Join(valid, "|"))
+	}
+}
+
+func (v *validator) checkToolchains(raw string) {
+	for _, name := range splitList(raw) {
+		if _, ok := toolchain.Lookup(toolchain.Normalize(name)); !ok {
+			v.add("extra_toolchains", SeverityError, "unknown toolchain %q (known: %s)", name, strings.Join(toolchain.Names(), ", "))
+		}
+	}
+}
+
+func (v *validator) checkTestConfig(raw string, knownArchs []string) {
+	config, err := testconfig.Parse(raw)
+	if err != nil {
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			v.add("test_config", SeverityError, "%v", err)
+		}
+		return
+	}
+	for _, field := range testconfig.UnknownFields(raw) {
+		v.add("test_config", SeverityWarning, "unknown field %q is ignored (known: %s)", field, strings.Join(testconfig.Fields, ", "))
+	}
+	for _, arch := range config.SkipArchs {
+		if !slices.Contains(knownArchs, arch) {
+			v.add("test_config", SeverityError, "skip_archs: unknown duckdb_arch %q", arch)
+		}
+	}
+}
+
+func (v *validator) checkCUDAArchs(raw string) {
+	for _, arch := range splitList(raw) {
+		if !cudaArchPattern.MatchString(arch) && !slices.Contains(cudaArchSpecialNames, arch) {
+			v.add("cuda_archs", SeverityError, "invalid CUDA architecture %q (expected e.g. 75, 90a, 86-real or all)", arch)
+		}
+	}
+}
+
+func (v *validator) checkArchList(input, raw string, knownArchs []string) {
+	for _, arch := range splitList(raw) {
+		if !slices.Contains(knownArchs, arch) {
+			v.add(input, SeverityError, "unknown duckdb_arch %q", arch)
+		}
+	}
+}
+
+func (v *validator) checkVCPKGExtraDependencies(in Inputs, matrix distmatrix.MatrixFile, knownArchs []string) {
+	raw := in.String("vcpkg_extra_dependencies")
+	if strings.TrimSpace(raw) == "" {
+		return
+	}
+
+	var deps map[string][]string
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	if err := decoder.Decode(&deps); err != nil {
+		v.add("vcpkg_extra_dependencies", SeverityError, "must be a JSON object mapping duckdb_arch to a list of ports: %v", err)
+		return
+	}
+
+	selected := map[string]struct{}{}
+	if result, err := distmatrix.ComputePlatformMatrices(matrix, distmatrix.ComputeOptions{
+		Exclude:       in.String("exclude_archs"),
+		OptIn:         in.String("opt_in_archs"),
+		ReducedCIMode: distmatrix.ReducedCIDisabled,
+	}); err == nil {
+		for _, platform := range result {
+			for _, entry := range platform.Include {
+				selected[entry.DuckDBArch] = struct{}{}
+			}
+		}
+	}
+
+	archs := make([]string, 0, len(deps))
+	for arch := range deps {
+		archs = append(archs, arch)
+	}
+	slices.Sort(archs)
+	for _, arch := range archs {
+		if !slices.Contains(knownArchs, arch) {
+			v.add("vcpkg_extra_dependencies", SeverityError, "unknown duckdb_arch %q", arch)
+			continue
+		}
+		if _, ok := selected[arch]; !ok {
+			v.add("vcpkg_extra_dependencies", SeverityWarning, "duckdb_arch %q is not built with the current exclude_archs and opt_in_archs", arch)
+		}
+		for i, spec := range deps[arch] {
+			if _, err := vcpkgdeps.ParseDependency(spec); err != nil {
+				v.add("vcpkg_extra_dependencies", SeverityError, "%s[%d]: %v", arch, i, err)
+			}
+		}
+	}
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == ',' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number, float64:
+		return "a number"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
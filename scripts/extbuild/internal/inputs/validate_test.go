@@ -0,0 +1,213 @@
+package inputs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	matrix := mustLoadDistributionMatrix(t)
+
+	tests := []struct {
+		name   string
+		inputs string
+		want   []Issue
+	}{
+		{
+			name:   "minimal inputs are valid",
+			inputs: `{}`,
+		},
+		{
+			name: "valid inputs from the extension template",
+			inputs: `{
+				"build_type": "relassert",
+				"extra_toolchains": "parser_tools;fortran;omp;go;python3;downgraded_aws_cli;",
+				"opt_in_archs": "windows_arm64;linux_amd64_musl;",
+				"test_config": "{\"test_env_variables\": {\"A\": \"1\"}}",
+				"vcpkg_extra_dependencies": "{\"linux_amd64\": [\"openssl\"]}",
+				"cuda_archs": "75;80;86-real;90a",
+				"skip_tests": false
+			}`,
+		},
+		{
+			name:   "unknown toolchain",
+			inputs: `{"extra_toolchains": "rust;rsut"}`,
+			want: []Issue{
+				{Input: "extra_toolchains", Severity: SeverityError, Message: `unknown toolchain "rsut" (known: parser_tools, rust, fortran, omp, go, python3, unixodbc, multimedia, cuda, downgraded_aws_cli)`},
+			},
+		},
+		{
+			name:   "malformed test config",
+			inputs: `{"test_config": "{\"test_env_variables\": "}`,
+			want: []Issue{
+				{Input: "test_config", Severity: SeverityError, Message: "invalid JSON object: "},
+			},
+		},
+		{
+			name:   "non string test env variable",
+			inputs: `{"test_config": "{\"test_env_variables\": {\"RETRIES\": 3}}"}`,
+			want: []Issue{
+				{Input: "test_config", Severity: SeverityError, Message: "test_env_variables.RETRIES must be a string, got a number"},
+			},
+		},
+		{
+			name:   "test config skip archs and unknown fields",
+			inputs: `{"test_config": "{\"skip_archs\": [\"linux_amd64\", \"linux_riscv\"], \"timeout\": \"1h\"}"}`,
+			want: []Issue{
+				{Input: "test_config", Severity: SeverityWarning, Message: `unknown field "timeout" is ignored (known: test_env_variables, skip_archs)`},
+				{Input: "test_config", Severity: SeverityError, Message: `skip_archs: unknown duckdb_arch "linux_riscv"`},
+			},
+		},
+		{
+			name:   "vcpkg dependencies for unknown and unselected archs",
+			inputs: `{"vcpkg_extra_dependencies": "{\"linux_amd64\": [\"openssl\"], \"linux_riscv\": [\"zlib\"], \"windows_arm64\": [\"zlib\"]}"}`,
+			want: []Issue{
+				{Input: "vcpkg_extra_dependencies", Severity: SeverityError, Message: `unknown duckdb_arch "linux_riscv"`},
+				{Input: "vcpkg_extra_dependencies", Severity: SeverityWarning, Message: `duckdb_arch "windows_arm64" is not built with the current exclude_archs and opt_in_archs`},
+			},
+		},
+		{
+			name:   "invalid vcpkg port",
+			inputs: `{"vcpkg_extra_dependencies": "{\"linux_amd64\": [\"openssl\", \"OpenSSL[tools]\"]}"}`,
+			want: []Issue{
+				{Input: "vcpkg_extra_dependencies", Severity: SeverityError, Message: `linux_amd64[1]: invalid dependency "OpenSSL[tools]": invalid port name "OpenSSL" (must be lowercase letters, digits and dashes)`},
+			},
+		},
+		{
+			name:   "malformed vcpkg dependencies",
+			inputs: `{"vcpkg_extra_dependencies": "[\"openssl\"]"}`,
+			want: []Issue{
+				{Input: "vcpkg_extra_dependencies", Severity: SeverityError, Message: "must be a JSON object mapping duckdb_arch to a list of ports: json: cannot unmarshal array"},
+			},
+		},
+		{
+			name:   "invalid build type",
+			inputs: `{"build_type": "Release"}`,
+			want: []Issue{
+				{Input: "build_type", Severity: SeverityError, Message: `invalid value "Release" (must be release|debug|relassert|reldebug)`},
+			},
+		},
+		{
+			name:   "invalid cuda archs",
+			inputs: `{"cuda_archs": "75;sm_80;all-major"}`,
+			want: []Issue{
+				{Input: "cuda_archs", Severity: SeverityError, Message: `invalid CUDA architecture "sm_80" (expected e.g. 75, 90a, 86-real or all)`},
+			},
+		},
+		{
+			name:   "unknown archs in exclude and opt in lists",
+			inputs: `{"exclude_archs": "linux_amd64;osx_universal", "opt_in_archs": "windows_arm64,wasm_simd"}`,
+			want: []Issue{
+				{Input: "exclude_archs", Severity: SeverityError, Message: `unknown duckdb_arch "osx_universal"`},
+				{Input: "opt_in_archs", Severity: SeverityError, Message: `unknown duckdb_arch "wasm_simd"`},
+			},
+		},
+		{
+			name:   "schema types and unknown inputs",
+			inputs: `{"skip_tests": "true", "build_type": 1, "enable_go": true}`,
+			want: []Issue{
+				{Input: "build_type", Severity: SeverityError, Message: "must be a string, got a number"},
+				{Input: "enable_go", Severity: SeverityWarning, Message: "unknown input, it is ignored by _extension_distribution.yml"},
+				{Input: "skip_tests", Severity: SeverityError, Message: "must be a boolean, got a string"},
+			},
+		},
+		{
+			name:   "invalid reduced ci mode and runners",
+			inputs: `{"reduced_ci_mode": "on", "runners": "[]"}`,
+			want: []Issue{
+				{Input: "reduced_ci_mode", Severity: SeverityError, Message: `invalid reduced CI mode: "on" (must be auto|enabled|disabled)`},
+				{Input: "runners", Severity: SeverityError, Message: "parse runner overrides: json: cannot unmarshal array"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			in, err := Parse([]byte(withRequiredInputs(t, tc.inputs)))
+			require.NoError(t, err)
+			got := Validate(in, matrix)
+			assertIssuesMatch(t, tc.want, got)
+			assert.Equal(t, HasErrors(tc.want), HasErrors(got))
+		})
+	}
+}
+
+func TestValidateRequiredInputs(t *testing.T) {
+	t.Parallel()
+
+	issues := Validate(Inputs{}, mustLoadDistributionMatrix(t))
+	assert.ElementsMatch(t, []Issue{
+		{Input: "ci_tools_version", Severity: SeverityError, Message: "is required"},
+		{Input: "duckdb_version", Severity: SeverityError, Message: "is required"},
+		{Input: "extension_name", Severity: SeverityError, Message: "is required"},
+	}, issues)
+}
+
+func TestParseRejectsNonObjects(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"", "null", "[]", "{} {}"} {
+		_, err := Parse([]byte(raw))
+		assert.Error(t, err, "input %q", raw)
+	}
+}
+
+func TestRenderGitHubAnnotations(t *testing.T) {
+	t.Parallel()
+
+	got := RenderGitHubAnnotations([]Issue{
+		{Input: "test_config", Severity: SeverityError, Message: "100% broken\nreally"},
+		{Input: "enable_go", Severity: SeverityWarning, Message: "unknown input"},
+	})
+	assert.Equal(t, "::error title=Invalid workflow input test_config::100%25 broken%0Areally\n"+
+		"::warning title=Invalid workflow input enable_go::unknown input\n", got)
+}
+
+// assertIssuesMatch compares issues, treating the expected messages as
+// prefixes so that wrapped encoding/json errors don't need to be spelled out.
+func assertIssuesMatch(t *testing.T, want, got []Issue) {
+	t.Helper()
+
+	require.Len(t, got, len(want), "issues: %v", got)
+	for i := range want {
+		assert.Equal(t, want[i].Input, got[i].Input)
+		assert.Equal(t, want[i].Severity, got[i].Severity)
+		assert.True(t, strings.HasPrefix(got[i].Message, want[i].Message), "message %q does not start with %q", got[i].Message, want[i].Message)
+	}
+}
+
+func withRequiredInputs(t *testing.T, raw string) string {
+	t.Helper()
+
+	in, err := Parse([]byte(raw))
+	require.NoError(t, err)
+	for _, name := range []string{"extension_name", "duckdb_version", "ci_tools_version"} {
+		if _, ok := in[name]; !ok {
+			in[name] = "quack"
+		}
+	}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func mustLoadDistributionMatrix(t *testing.T) distmatrix.MatrixFile {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "config", "distribution_matrix.json"))
+	require.NoError(t, err)
+	matrix, err := distmatrix.ParseMatrixFile(data)
+	require.NoError(t, err)
+	return matrix
+}
@@ -0,0 +1,180 @@
+// Package metadata reads and writes the footer DuckDB expects at the end of a
+// loadable extension binary.
+//
+// The footer is laid out as a WebAssembly custom section so that wasm
+// extensions stay valid modules:
+//
+//	custom section header (22 bytes)
+//	8 fields of 32 bytes, NUL padded, stored from FIELD8 down to FIELD1
+//	signature space (256 bytes)
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	// FieldSize is the size of each NUL padded metadata field.
+	FieldSize = 32
+	// FieldCount is the number of metadata fields, including the unused ones.
+	FieldCount = 8
+	// SignatureSize is the size of the space reserved for the signature.
+	SignatureSize = 256
+	// MagicValue is stored in FIELD1 and identifies a DuckDB extension.
+	MagicValue = "4"
+
+	// HeaderSize is the size of the WebAssembly custom section header.
+	HeaderSize = 22
+	// FooterSize is the total number of bytes appended to the library.
+	FooterSize = HeaderSize + FieldCount*FieldSize + SignatureSize
+)
+
+// ABI types understood by DuckDB's extension loader.
+const (
+	ABITypeCStruct         = "C_STRUCT"
+	ABITypeCStructUnstable = "C_STRUCT_UNSTABLE"
+	ABITypeCPP             = "CPP"
+)
+
+// ABITypes lists the ABI types understood by DuckDB's extension loader.
+var ABITypes = []string{ABITypeCStruct, ABITypeCStructUnstable, ABITypeCPP}
+
+// CheckABIType rejects ABI types DuckDB's extension loader does not know, as
+// it refuses to load extensions stamped with them.
+func CheckABIType(abiType string) error {
+	if !slices.Contains(ABITypes, abiType) {
+		return fmt.Errorf("invalid ABI type: %q (must be %s)", abiType, strings.Join(ABITypes, "|"))
+	}
+	return nil
+}
+
+// SectionName is the name of the WebAssembly custom section holding the footer.
+const SectionName = "duckdb_signature"
+
+// header returns the custom section header: the section id (0), the LEB128
+// encoded section size (531 = 1 + 16 + 2 + 8*32 + 256), the name length and
+// name, and the LEB128 encoded payload size (512 = 8*32 + 256).
+func header() []byte {
+	h := make([]byte, 0, HeaderSize)
+	h = append(h, 0x00, 0x93, 0x04, byte(len(SectionName)))
+	h = append(h, SectionName...)
+	h = append(h, 0x80, 0x04)
+	return h
+}
+
+// Metadata holds the values encoded in the footer.
+type Metadata struct {
+	ABIType          string `json:"abi_type"`
+	ExtensionVersion string `json:"extension_version"`
+	DuckDBVersion    string `json:"duckdb_version"`
+	DuckDBPlatform   string `json:"duckdb_platform"`
+}
+
+// fields returns the footer fields in file order, FIELD8 first.
+func (m Metadata) fields() [FieldCount]string {
+	return [FieldCount]string{
+		"", "", "",
+		m.ABIType,
+		m.ExtensionVersion,
+		m.DuckDBVersion,
+		m.DuckDBPlatform,
+		MagicValue,
+	}
+}
+
+// Validate checks that every value fits in a footer field.
+func (m Metadata) Validate() error {
+	values := []struct {
+		name  string
+		value string
+	}{
+		{name: "abi_type", value: m.ABIType},
+		{name: "extension_version", value: m.ExtensionVersion},
+		{name: "duckdb_version", value: m.DuckDBVersion},
+		{name: "duckdb_platform", value: m.DuckDBPlatform},
+	}
+	var errs []error
+	for _, v := range values {
+		if len(v.value) > FieldSize {
+			errs = append(errs, fmt.Errorf("%s %q is %d bytes long (must be at most %d)", v.name, v.value, len(v.value), FieldSize))
+		}
+		for _, r := range v.value {
+			if r > 0x7f {
+				errs = append(errs, fmt.Errorf("%s %q must be ASCII", v.name, v.value))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Footer returns the encoded footer with an empty signature.
+func (m Metadata) Footer() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	footer := make([]byte, 0, FooterSize)
+	footer = append(footer, header()...)
+	for _, field := range m.fields() {
+		padded := make([]byte, FieldSize)
+		copy(padded, field)
+		footer = append(footer, padded...)
+	}
+	footer = append(footer, make([]byte, SignatureSize)...)
+	return footer, nil
+}
+
+// AppendFile copies the library at libraryPath to outPath with the footer
+// appended. The output is written to a temporary file next to outPath first,
+// so outPath never holds a partially written extension.
+func AppendFile(libraryPath, outPath string, m Metadata) error {
+	footer, err := m.Footer()
+	if err != nil {
+		return err
+	}
+
+	library, err := os.Open(libraryPath)
+	if err != nil {
+		return fmt.Errorf("open library: %w", err)
+	}
+	defer library.Close()
+
+	return writeFileAtomic(outPath, 0o666, func(w io.Writer) error {
+		if _, err := io.Copy(w, library); err != nil {
+			return fmt.Errorf("copy library: %w", err)
+		}
+		if _, err := w.Write(footer); err != nil {
+			return fmt.Errorf("write footer: %w", err)
+		}
+		return nil
+	})
+}
+
+func writeFileAtomic(path string, perm os.FileMode, write func(io.Writer) error) error {
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("move temporary file into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The golden files were produced by scripts/append_extension_metadata.py, e.g.:
+//
+//	python3 scripts/append_extension_metadata.py -l libquack.so -n quack \
+//	  -o quack.duckdb_extension.golden -dv v1.2.0 -ev v0.1.0 -p linux_amd64
+func TestAppendFileMatchesPythonScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		library string
+		golden  string
+		meta    Metadata
+	}{
+		{
+			name:    "native C_STRUCT",
+			library: "libquack.so",
+			golden:  "quack.duckdb_extension.golden",
+			meta:    Metadata{ABIType: ABITypeCStruct, ExtensionVersion: "v0.1.0", DuckDBVersion: "v1.2.0", DuckDBPlatform: "linux_amd64"},
+		},
+		{
+			name:    "native C_STRUCT_UNSTABLE",
+			library: "libquack.so",
+			golden:  "quack_unstable.duckdb_extension.golden",
+			meta:    Metadata{ABIType: ABITypeCStructUnstable, ExtensionVersion: "v0.2.0-3-gabcdef1", DuckDBVersion: "v1.2.0", DuckDBPlatform: "osx_arm64"},
+		},
+		{
+			name:    "wasm",
+			library: "libquack.wasm",
+			golden:  "quack.duckdb_extension.wasm.golden",
+			meta:    Metadata{ABIType: ABITypeCStruct, ExtensionVersion: "v0.1.0", DuckDBVersion: "v1.2.0", DuckDBPlatform: "wasm_eh"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			outPath := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+			require.NoError(t, AppendFile(testdataPath(tc.library), outPath, tc.meta))
+
+			got, err := os.ReadFile(outPath)
+			require.NoError(t, err)
+			want, err := os.ReadFile(testdataPath(tc.golden))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			_, err = os.Stat(outPath + ".tmp")
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestFooterLayout(t *testing.T) {
+	t.Parallel()
+
+	footer, err := Metadata{ABIType: ABITypeCPP, ExtensionVersion: "v1", DuckDBVersion: "v1.2.0", DuckDBPlatform: "linux_amd64"}.Footer()
+	require.NoError(t, err)
+	require.Len(t, footer, FooterSize)
+	assert.Equal(t, 534, FooterSize)
+
+	assert.Equal(t, []byte{0x00, 0x93, 0x04, 0x10}, footer[:4])
+	assert.Equal(t, "duckdb_signature", string(footer[4:20]))
+	assert.Equal(t, []byte{0x80, 0x04}, footer[20:HeaderSize])
+
+	field := func(n int) string {
+		start := HeaderSize + (FieldCount-n)*FieldSize
+		return strings.TrimRight(string(footer[start:start+FieldSize]), "\x00")
+	}
+	assert.Equal(t, MagicValue, field(1))
+	assert.Equal(t, "linux_amd64", field(2))
+	assert.Equal(t, "v1.2.0", field(3))
+	assert.Equal(t, "v1", field(4))
+	assert.Equal(t, ABITypeCPP, field(5))
+	assert.Empty(t, field(6))
+	assert.Equal(t, make([]byte, SignatureSize), footer[FooterSize-SignatureSize:])
+}
+
+func TestFooterRejectsValuesThatDoNotFit(t *testing.T) {
+	t.Parallel()
+
+	_, err := Metadata{
+		ABIType:          ABITypeCStruct,
+		ExtensionVersion: strings.Repeat("v", FieldSize+1),
+		DuckDBVersion:    "v1.2.0",
+		DuckDBPlatform:   "linux_ämd64",
+	}.Footer()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "extension_version")
+	assert.ErrorContains(t, err, "is 33 bytes long (must be at most 32)")
+	assert.ErrorContains(t, err, `duckdb_platform "linux_ämd64" must be ASCII`)
+}
+
+func TestCheckABIType(t *testing.T) {
+	t.Parallel()
+
+	for _, abiType := range ABITypes {
+		require.NoError(t, CheckABIType(abiType))
+	}
+	require.EqualError(t, CheckABIType("C_STRUCTS"), `invalid ABI type: "C_STRUCTS" (must be C_STRUCT|C_STRUCT_UNSTABLE|CPP)`)
+	require.Error(t, CheckABIType(""))
+}
+
+func TestAppendFileLeavesNoOutputOnError(t *testing.T) {
+	t.Parallel()
+
+	outPath := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	err := AppendFile(filepath.Join(t.TempDir(), "missing.so"), outPath, Metadata{ABIType: ABITypeCStruct})
+	require.ErrorContains(t, err, "open library")
+
+	_, err = os.Stat(outPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(outPath + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func testdataPath(name string) string {
+	return filepath.Join("..", "..", "testdata", "metadata", name)
+}
@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNoFooter is returned when a file does not end with a valid footer.
+var ErrNoFooter = errors.New("no DuckDB extension metadata footer")
+
+// Binary formats reported by Info.Format.
+const (
+	FormatNative = "native"
+	FormatWasm   = "wasm"
+)
+
+var wasmMagic = []byte{0x00, 'a', 's', 'm'}
+
+// Info is the decoded footer of an extension binary.
+type Info struct {
+	Metadata
+	Format    string `json:"format"`
+	Signed    bool   `json:"signed"`
+	Signature []byte `json:"-"`
+}
+
+// Parse decodes a footer of exactly FooterSize bytes.
+func Parse(footer []byte) (Info, error) {
+	if len(footer) != FooterSize {
+		return Info{}, fmt.Errorf("%w: footer is %d bytes long (must be %d)", ErrNoFooter, len(footer), FooterSize)
+	}
+	if !bytes.Equal(footer[:HeaderSize], header()) {
+		return Info{}, fmt.Errorf("%w: unexpected custom section header % x", ErrNoFooter, footer[:HeaderSize])
+	}
+
+	var fields [FieldCount]string
+	for i := range fields {
+		start := HeaderSize + i*FieldSize
+		fields[i] = cString(footer[start : start+FieldSize])
+	}
+	if magic := fields[FieldCount-1]; magic != MagicValue {
+		return Info{}, fmt.Errorf("%w: magic field is %q (must be %q)", ErrNoFooter, magic, MagicValue)
+	}
+
+	signature := bytes.Clone(footer[FooterSize-SignatureSize:])
+	return Info{
+		Metadata: Metadata{
+			ABIType:          fields[3],
+			ExtensionVersion: fields[4],
+			DuckDBVersion:    fields[5],
+			DuckDBPlatform:   fields[6],
+		},
+		Signed:    !isZero(signature),
+		Signature: signature,
+	}, nil
+}
+
+// ReadFile decodes the footer at the end of the extension binary at path.
+func ReadFile(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read decodes the footer at the end of an extension binary.
+func Read(r io.ReadSeeker) (Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Info{}, err
+	}
+	if size < FooterSize {
+		return Info{}, fmt.Errorf("%w: file is %d bytes long", ErrNoFooter, size)
+	}
+
+	magic := make([]byte, len(wasmMagic))
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return Info{}, err
+	}
+
+	footer := make([]byte, FooterSize)
+	if _, err := r.Seek(size-FooterSize, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return Info{}, err
+	}
+
+	info, err := Parse(footer)
+	if err != nil {
+		return Info{}, err
+	}
+	info.Format = FormatNative
+	if bytes.Equal(magic, wasmMagic) {
+		info.Format = FormatWasm
+	}
+	return info, nil
+}
+
+// cString returns field up to its first NUL byte, the way DuckDB reads it.
+func cString(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	return string(field)
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
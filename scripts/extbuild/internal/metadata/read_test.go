@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileDecodesGoldenFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		golden string
+		want   Info
+	}{
+		{
+			golden: "quack.duckdb_extension.golden",
+			want: Info{
+				Metadata: Metadata{ABIType: ABITypeCStruct, ExtensionVersion: "v0.1.0", DuckDBVersion: "v1.2.0", DuckDBPlatform: "linux_amd64"},
+				Format:   FormatNative,
+			},
+		},
+		{
+			golden: "quack_unstable.duckdb_extension.golden",
+			want: Info{
+				Metadata: Metadata{ABIType: ABITypeCStructUnstable, ExtensionVersion: "v0.2.0-3-gabcdef1", DuckDBVersion: "v1.2.0", DuckDBPlatform: "osx_arm64"},
+				Format:   FormatNative,
+			},
+		},
+		{
+			golden: "quack.duckdb_extension.wasm.golden",
+			want: Info{
+				Metadata: Metadata{ABIType: ABITypeCStruct, ExtensionVersion: "v0.1.0", DuckDBVersion: "v1.2.0", DuckDBPlatform: "wasm_eh"},
+				Format:   FormatWasm,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.golden, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ReadFile(testdataPath(tc.golden))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want.Metadata, got.Metadata)
+			assert.Equal(t, tc.want.Format, got.Format)
+			assert.False(t, got.Signed)
+			assert.Len(t, got.Signature, SignatureSize)
+		})
+	}
+}
+
+func TestReadDetectsSignature(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(testdataPath("quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+	data[len(data)-1] = 0x2a
+
+	info, err := Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.True(t, info.Signed)
+	assert.Equal(t, byte(0x2a), info.Signature[SignatureSize-1])
+}
+
+func TestReadRejectsFilesWithoutFooter(t *testing.T) {
+	t.Parallel()
+
+	golden, err := os.ReadFile(testdataPath("quack.duckdb_extension.golden"))
+	require.NoError(t, err)
+
+	badMagic := bytes.Clone(golden)
+	badMagic[len(badMagic)-SignatureSize-FieldSize] = '5'
+
+	badHeader := bytes.Clone(golden)
+	badHeader[len(badHeader)-FooterSize+4] = 'D'
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "too short", data: []byte("\x7fELF"), wantErr: "file is 4 bytes long"},
+		{name: "no footer", data: make([]byte, FooterSize), wantErr: "unexpected custom section header"},
+		{name: "bad magic", data: badMagic, wantErr: `magic field is "5" (must be "4")`},
+		{name: "bad header", data: badHeader, wantErr: "unexpected custom section header"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Read(bytes.NewReader(tc.data))
+			require.ErrorIs(t, err, ErrNoFooter)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := ReadFile(filepath.Join(t.TempDir(), "missing.duckdb_extension"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,160 @@
+package metadata
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// hashChunkSize is the chunk size DuckDB hashes extension binaries with
+// before signing the hash of the concatenated chunk hashes.
+const hashChunkSize = 1024 * 1024
+
+var (
+	// ErrUnsigned is returned when verifying a file with an empty signature slot.
+	ErrUnsigned = errors.New("extension is not signed")
+	// ErrInvalidSignature is returned when no trusted key matches the signature.
+	ErrInvalidSignature = errors.New("signature does not match any trusted public key")
+)
+
+// ContentHash computes the two-level SHA-256 hash DuckDB checks the signature
+// against: every 1 MiB chunk of the first size-SignatureSize bytes is hashed,
+// and the concatenated chunk hashes are hashed again. The footer fields are
+// part of the signed content, only the signature slot is not.
+func ContentHash(r io.ReaderAt, size int64) ([]byte, error) {
+	if size < SignatureSize {
+		return nil, fmt.Errorf("%w: file is %d bytes long", ErrNoFooter, size)
+	}
+	signed := size - SignatureSize
+
+	concatenated := make([]byte, 0, (signed/hashChunkSize+1)*sha256.Size)
+	for offset := int64(0); offset < signed; offset += hashChunkSize {
+		chunk := sha256.New()
+		if _, err := io.Copy(chunk, io.NewSectionReader(r, offset, min(hashChunkSize, signed-offset))); err != nil {
+			return nil, err
+		}
+		concatenated = chunk.Sum(concatenated)
+	}
+	sum := sha256.Sum256(concatenated)
+	return sum[:], nil
+}
+
+// SignFile signs the extension binary at path with key and writes the
+// signature into the footer's signature slot. The key must be a 2048-bit RSA
+// key so that the signature fills the slot exactly.
+func SignFile(path string, key *rsa.PrivateKey) error {
+	if key.Size() != SignatureSize {
+		return fmt.Errorf("RSA key is %d bits (must be %d)", key.Size()*8, SignatureSize*8)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := Read(f); err != nil {
+		return err
+	}
+	hash, err := fileContentHash(f)
+	if err != nil {
+		return err
+	}
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hash)
+	if err != nil {
+		return fmt.Errorf("sign extension: %w", err)
+	}
+	return rewriteTail(path, f, signature)
+}
+
+// VerifyFile checks the signature of the extension binary at path against the
+// trusted keys and returns the index of the first key that matches.
+func VerifyFile(path string, keys []*rsa.PublicKey) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+
+	info, err := Read(f)
+	if err != nil {
+		return -1, err
+	}
+	if !info.Signed {
+		return -1, ErrUnsigned
+	}
+	hash, err := fileContentHash(f)
+	if err != nil {
+		return -1, err
+	}
+	for i, key := range keys {
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, info.Signature) == nil {
+			return i, nil
+		}
+	}
+	return -1, ErrInvalidSignature
+}
+
+func fileContentHash(f *os.File) ([]byte, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ContentHash(f, stat.Size())
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded RSA private key in PKCS #1 or
+// PKCS #8 form, as accepted by openssl.
+func ParsePrivateKeyPEM(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is a %T (must be RSA)", key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q (must be RSA PRIVATE KEY or PRIVATE KEY)", block.Type)
+	}
+}
+
+// ParsePublicKeyPEM parses a PEM encoded RSA public key in PKIX form, as
+// embedded in DuckDB, or PKCS #1 form.
+func ParsePublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is a %T (must be RSA)", key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q (must be PUBLIC KEY or RSA PUBLIC KEY)", block.Type)
+	}
+}
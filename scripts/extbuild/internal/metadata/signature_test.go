@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quack.duckdb_extension.signed.golden was signed with openssl the way
+// DuckDB's compute-extension-hash.sh and upload script do:
+//
+//	split -b 1048576 body chunk_; for c in chunk_*; do openssl dgst -binary -sha256 $c >> concat; done
+//	openssl dgst -binary -sha256 concat > hash
+//	openssl pkeyutl -sign -in hash -inkey test_private.pem -pkeyopt digest:sha256 -out signature
+
+func TestSignFileMatchesOpenSSL(t *testing.T) {
+	t.Parallel()
+
+	path := copyGolden(t, "quack.duckdb_extension.golden")
+	require.NoError(t, SignFile(path, testPrivateKey(t)))
+
+	assertSameContent(t, signingTestdataPath("quack.duckdb_extension.signed.golden"), path)
+
+	info, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, info.Signed)
+}
+
+func TestVerifyFile(t *testing.T) {
+	t.Parallel()
+
+	trusted := testPublicKey(t, "trusted/public.pem")
+	other := testPublicKey(t, "trusted/other.pem")
+	untrusted := testPublicKey(t, "untrusted/public.pem")
+	signed := signingTestdataPath("quack.duckdb_extension.signed.golden")
+
+	index, err := VerifyFile(signed, []*rsa.PublicKey{other, trusted})
+	require.NoError(t, err)
+	assert.Equal(t, 1, index)
+
+	_, err = VerifyFile(signed, []*rsa.PublicKey{untrusted})
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyFile(testdataPath("quack.duckdb_extension.golden"), []*rsa.PublicKey{trusted})
+	require.ErrorIs(t, err, ErrUnsigned)
+}
+
+func TestVerifyFileDetectsRestampedFooter(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(signingTestdataPath("quack.duckdb_extension.signed.golden"))
+	require.NoError(t, err)
+	// Overwrite the first byte of FIELD2 (duckdb_platform) but keep the signature.
+	data[len(data)-SignatureSize-2*FieldSize] = 'm'
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	_, err = VerifyFile(path, []*rsa.PublicKey{testPublicKey(t, "trusted/public.pem")})
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestContentHashChunksLargeFiles(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("quack"), (2*hashChunkSize+hashChunkSize/2)/5)
+	data = append(data, make([]byte, SignatureSize)...)
+
+	got, err := ContentHash(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	body := data[:len(data)-SignatureSize]
+	var concatenated []byte
+	for _, chunk := range [][]byte{body[:hashChunkSize], body[hashChunkSize : 2*hashChunkSize], body[2*hashChunkSize:]} {
+		sum := sha256.Sum256(chunk)
+		concatenated = append(concatenated, sum[:]...)
+	}
+	want := sha256.Sum256(concatenated)
+	assert.Equal(t, want[:], got)
+}
+
+func TestParseKeysRejectsUnexpectedPEM(t *testing.T) {
+	t.Parallel()
+
+	public, err := os.ReadFile(signingTestdataPath("trusted/public.pem"))
+	require.NoError(t, err)
+	_, err = ParsePrivateKeyPEM(public)
+	require.ErrorContains(t, err, `unexpected PEM block "PUBLIC KEY"`)
+
+	_, err = ParsePublicKeyPEM([]byte("not a key"))
+	require.ErrorContains(t, err, "no PEM block found")
+}
+
+func testPrivateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	data, err := os.ReadFile(signingTestdataPath("test_private.pem"))
+	require.NoError(t, err)
+	key, err := ParsePrivateKeyPEM(data)
+	require.NoError(t, err)
+	return key
+}
+
+func testPublicKey(t *testing.T, name string) *rsa.PublicKey {
+	t.Helper()
+	data, err := os.ReadFile(signingTestdataPath(name))
+	require.NoError(t, err)
+	key, err := ParsePublicKeyPEM(data)
+	require.NoError(t, err)
+	return key
+}
+
+func signingTestdataPath(name string) string {
+	return filepath.Join("..", "..", "testdata", "signing", name)
+}
@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Changes lists the footer fields to replace. Nil fields are left untouched.
+type Changes struct {
+	ExtensionVersion *string
+	DuckDBPlatform   *string
+	ABIType          *string
+}
+
+func (c Changes) apply(m Metadata) Metadata {
+	if c.ExtensionVersion != nil {
+		m.ExtensionVersion = *c.ExtensionVersion
+	}
+	if c.DuckDBPlatform != nil {
+		m.DuckDBPlatform = *c.DuckDBPlatform
+	}
+	if c.ABIType != nil {
+		m.ABIType = *c.ABIType
+	}
+	return m
+}
+
+// UpdateFile replaces footer fields of an already stamped extension binary and
+// reports whether anything changed. Any change invalidates the signature, so
+// the signature slot is cleared. Files without a valid footer are left alone
+// and ErrNoFooter is returned.
+func UpdateFile(path string, changes Changes) (Info, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, false, err
+	}
+	defer f.Close()
+
+	info, err := Read(f)
+	if err != nil {
+		return Info{}, false, err
+	}
+	updated := changes.apply(info.Metadata)
+	if updated == info.Metadata {
+		return info, false, nil
+	}
+
+	footer, err := updated.Footer()
+	if err != nil {
+		return Info{}, false, err
+	}
+	if err := rewriteTail(path, f, footer); err != nil {
+		return Info{}, false, err
+	}
+
+	info.Metadata = updated
+	info.Signed = false
+	info.Signature = make([]byte, SignatureSize)
+	return info, true, nil
+}
+
+// rewriteTail atomically replaces the last len(tail) bytes of the file at
+// path, which f must have open, and keeps the file permissions.
+func rewriteTail(path string, f *os.File, tail []byte) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(path, stat.Mode().Perm(), func(w io.Writer) error {
+		if _, err := io.CopyN(w, f, stat.Size()-int64(len(tail))); err != nil {
+			return fmt.Errorf("copy extension: %w", err)
+		}
+		if _, err := w.Write(tail); err != nil {
+			return fmt.Errorf("write footer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// The temporary file was created subject to the umask, restore the
+	// original permissions.
+	return os.Chmod(path, stat.Mode().Perm())
+}
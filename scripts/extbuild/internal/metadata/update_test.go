@@ -0,0 +1,99 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateFileReplacesFieldsAndClearsSignature(t *testing.T) {
+	t.Parallel()
+
+	path := copyGolden(t, "quack.duckdb_extension.golden")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] = 0x2a
+	require.NoError(t, os.WriteFile(path, data, 0o755))
+	require.NoError(t, os.Chmod(path, 0o755))
+
+	version := "v0.2.0"
+	info, changed, err := UpdateFile(path, Changes{ExtensionVersion: &version})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, info.Signed)
+
+	// The result must be identical to stamping the library with the new
+	// version in the first place.
+	want := filepath.Join(t.TempDir(), "want.duckdb_extension")
+	require.NoError(t, AppendFile(testdataPath("libquack.so"), want, Metadata{
+		ABIType: ABITypeCStruct, ExtensionVersion: "v0.2.0", DuckDBVersion: "v1.2.0", DuckDBPlatform: "linux_amd64",
+	}))
+	assertSameContent(t, want, path)
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), stat.Mode().Perm())
+}
+
+func TestUpdateFileWithoutChangesKeepsSignature(t *testing.T) {
+	t.Parallel()
+
+	path := copyGolden(t, "quack.duckdb_extension.wasm.golden")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] = 0x2a
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	platform := "wasm_eh"
+	info, changed, err := UpdateFile(path, Changes{DuckDBPlatform: &platform})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.True(t, info.Signed)
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestUpdateFileRefusesFilesWithoutFooter(t *testing.T) {
+	t.Parallel()
+
+	path := copyGolden(t, "libquack.so")
+	abiType := ABITypeCPP
+	_, _, err := UpdateFile(path, Changes{ABIType: &abiType})
+	require.ErrorIs(t, err, ErrNoFooter)
+
+	assertSameContent(t, testdataPath("libquack.so"), path)
+}
+
+func TestUpdateFileRejectsOversizedValues(t *testing.T) {
+	t.Parallel()
+
+	path := copyGolden(t, "quack.duckdb_extension.golden")
+	version := "v0.2.0-with-a-very-long-suffix-that-does-not-fit"
+	_, _, err := UpdateFile(path, Changes{ExtensionVersion: &version})
+	require.ErrorContains(t, err, "must be at most 32")
+
+	assertSameContent(t, testdataPath("quack.duckdb_extension.golden"), path)
+}
+
+func copyGolden(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(testdataPath(name))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func assertSameContent(t *testing.T, want, got string) {
+	t.Helper()
+	wantData, err := os.ReadFile(want)
+	require.NoError(t, err)
+	gotData, err := os.ReadFile(got)
+	require.NoError(t, err)
+	assert.Equal(t, wantData, gotData)
+}
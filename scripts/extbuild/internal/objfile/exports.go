@@ -0,0 +1,221 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/wasm"
+)
+
+// Linkage lists the symbols a binary exports and the shared libraries it
+// loads at runtime. Mach-O symbol names are reported without the leading
+// underscore so that they compare equal to their C names.
+type Linkage struct {
+	Exports   []string `json:"exports"`
+	Libraries []string `json:"libraries"`
+}
+
+// ReadLinkage reads the export table and the needed libraries of the ELF,
+// Mach-O, PE or wasm binary at path. Wasm modules have no needed libraries.
+func ReadLinkage(path string) (Linkage, error) {
+	target, err := IdentifyFile(path)
+	if err != nil {
+		return Linkage{}, err
+	}
+
+	var l Linkage
+	switch target.Format {
+	case FormatELF:
+		l, err = elfLinkage(path)
+	case FormatMachO:
+		l, err = machoLinkage(path)
+	case FormatPE:
+		l, err = peLinkage(path)
+	case FormatWasm:
+		l, err = wasmLinkage(path)
+	}
+	if err != nil {
+		return Linkage{}, err
+	}
+	slices.Sort(l.Exports)
+	l.Exports = slices.Compact(l.Exports)
+	slices.Sort(l.Libraries)
+	l.Libraries = slices.Compact(l.Libraries)
+	return l, nil
+}
+
+func elfLinkage(path string) (Linkage, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return Linkage{}, fmt.Errorf("parse ELF: %w", err)
+	}
+	defer f.Close()
+
+	libraries, err := f.ImportedLibraries()
+	if err != nil {
+		return Linkage{}, fmt.Errorf("read needed libraries: %w", err)
+	}
+	symbols, err := f.DynamicSymbols()
+	if err != nil {
+		return Linkage{}, fmt.Errorf("read dynamic symbols: %w", err)
+	}
+
+	l := Linkage{Libraries: libraries}
+	for _, sym := range symbols {
+		bind, typ := elf.ST_BIND(sym.Info), elf.ST_TYPE(sym.Info)
+		if sym.Section == elf.SHN_UNDEF || elf.ST_VISIBILITY(sym.Other) != elf.STV_DEFAULT {
+			continue
+		}
+		if (bind == elf.STB_GLOBAL || bind == elf.STB_WEAK) && (typ == elf.STT_FUNC || typ == elf.STT_OBJECT) {
+			l.Exports = append(l.Exports, sym.Name)
+		}
+	}
+	return l, nil
+}
+
+func machoLinkage(path string) (Linkage, error) {
+	var files []*macho.File
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		for _, arch := range fat.Arches {
+			files = append(files, arch.File)
+		}
+	} else {
+		f, err := macho.Open(path)
+		if err != nil {
+			return Linkage{}, fmt.Errorf("parse Mach-O: %w", err)
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	const (
+		nStab = 0xe0
+		nType = 0x0e
+		nSect = 0x0e
+		nExt  = 0x01
+	)
+	var l Linkage
+	for _, f := range files {
+		libraries, err := f.ImportedLibraries()
+		if err != nil {
+			return Linkage{}, fmt.Errorf("read load commands: %w", err)
+		}
+		l.Libraries = append(l.Libraries, libraries...)
+		if f.Symtab == nil {
+			continue
+		}
+		for _, sym := range f.Symtab.Syms {
+			if sym.Type&nStab == 0 && sym.Type&nExt != 0 && sym.Type&nType == nSect {
+				l.Exports = append(l.Exports, strings.TrimPrefix(sym.Name, "_"))
+			}
+		}
+	}
+	return l, nil
+}
+
+func peLinkage(path string) (Linkage, error) {
+	f, err := pe.Open(path)
+	if err != nil {
+		return Linkage{}, fmt.Errorf("parse PE: %w", err)
+	}
+	defer f.Close()
+
+	// debug/pe only reports imports as "symbol:library" pairs.
+	imports, err := f.ImportedSymbols()
+	if err != nil {
+		return Linkage{}, fmt.Errorf("read import table: %w", err)
+	}
+	var l Linkage
+	for _, imp := range imports {
+		if _, library, ok := strings.Cut(imp, ":"); ok {
+			l.Libraries = append(l.Libraries, library)
+		}
+	}
+	l.Exports, err = peExports(f)
+	if err != nil {
+		return Linkage{}, fmt.Errorf("read export table: %w", err)
+	}
+	return l, nil
+}
+
+// peExports reads the names of the export directory, which debug/pe does not
+// decode.
+func peExports(f *pe.File) ([]string, error) {
+	var dirs []pe.DataDirectory
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dirs = h.DataDirectory[:h.NumberOfRvaAndSizes]
+	case *pe.OptionalHeader64:
+		dirs = h.DataDirectory[:h.NumberOfRvaAndSizes]
+	}
+	if len(dirs) <= pe.IMAGE_DIRECTORY_ENTRY_EXPORT || dirs[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].Size == 0 {
+		return nil, nil
+	}
+
+	// IMAGE_EXPORT_DIRECTORY: NumberOfNames is at offset 24, AddressOfNames at 32.
+	dir, err := peRead(f, dirs[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].VirtualAddress, 40)
+	if err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint32(dir[24:])
+	nameRVAs, err := peRead(f, binary.LittleEndian.Uint32(dir[32:]), count*4)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, count)
+	for i := range count {
+		rva := binary.LittleEndian.Uint32(nameRVAs[i*4:])
+		name, err := peRead(f, rva, 0)
+		if err != nil {
+			return nil, err
+		}
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		names = append(names, string(name))
+	}
+	return names, nil
+}
+
+// peRead returns n bytes at the relative virtual address rva, or the rest of
+// the section holding rva when n is zero.
+func peRead(f *pe.File, rva, n uint32) ([]byte, error) {
+	for _, s := range f.Sections {
+		if rva < s.VirtualAddress || rva >= s.VirtualAddress+max(s.VirtualSize, s.Size) {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		start := rva - s.VirtualAddress
+		if n == 0 {
+			n = uint32(len(data)) - min(start, uint32(len(data)))
+		}
+		if uint64(start)+uint64(n) > uint64(len(data)) {
+			return nil, fmt.Errorf("RVA %#x+%d is outside section %s", rva, n, s.Name)
+		}
+		return data[start : start+n], nil
+	}
+	return nil, fmt.Errorf("RVA %#x is not in any section", rva)
+}
+
+func wasmLinkage(path string) (Linkage, error) {
+	m, err := wasm.ParseFile(path)
+	if err != nil {
+		return Linkage{}, fmt.Errorf("parse wasm: %w", err)
+	}
+	var l Linkage
+	for _, exp := range m.Exports {
+		l.Exports = append(l.Exports, exp.Name)
+	}
+	return l, nil
+}
@@ -0,0 +1,161 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLinkageELF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		library string
+		want    Linkage
+	}{
+		{
+			library: "libquack_linux_amd64.so",
+			want:    Linkage{Exports: []string{"quack_init_c_api"}, Libraries: []string{"libc.so.6"}},
+		},
+		{
+			library: "libquack_cpp_linux_amd64.so",
+			want:    Linkage{Exports: []string{"quack_duckdb_cpp_init"}, Libraries: []string{"libc.so.6", "libgcc_s.so.1", "libstdc++.so.6"}},
+		},
+		{
+			library: "libquack_leaky_linux_amd64.so",
+			want:    Linkage{Exports: []string{"_ZN6duckdb6LeakedEi", "duckdb_open", "quack_init_c_api"}, Libraries: []string{"libduckdb.so"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.library, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ReadLinkage(filepath.Join("..", "..", "testdata", "objfile", tc.library))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestReadLinkageMachO(t *testing.T) {
+	t.Parallel()
+
+	got, err := ReadLinkage(writeTemp(t, machOWithSymbols()))
+	require.NoError(t, err)
+	assert.Equal(t, Linkage{Exports: []string{"quack_init_c_api"}}, got)
+}
+
+func TestReadLinkagePE(t *testing.T) {
+	t.Parallel()
+
+	got, err := ReadLinkage(writeTemp(t, peWithExports("quack_init_c_api", "duckdb_open")))
+	require.NoError(t, err)
+	assert.Equal(t, Linkage{Exports: []string{"duckdb_open", "quack_init_c_api"}}, got)
+}
+
+func TestReadLinkageWasm(t *testing.T) {
+	t.Parallel()
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	// Export section: one function export named quack_init_c_api.
+	module = append(module, 0x07, 0x14, 0x01, 0x10)
+	module = append(module, "quack_init_c_api"...)
+	module = append(module, 0x00, 0x00)
+
+	got, err := ReadLinkage(writeTemp(t, module))
+	require.NoError(t, err)
+	assert.Equal(t, Linkage{Exports: []string{"quack_init_c_api"}}, got)
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quack.duckdb_extension")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+// machOWithSymbols returns an arm64 dylib with a symbol table holding an
+// exported, a private and an undefined symbol.
+func machOWithSymbols() []byte {
+	const symoff = 32 + 24
+	names := []string{"_quack_init_c_api", "_quack_helper", "_printf"}
+	types := []uint8{0x0f, 0x0e, 0x01} // N_SECT|N_EXT, N_SECT, N_UNDF|N_EXT
+
+	strtab := []byte{0}
+	var syms bytes.Buffer
+	for i, name := range names {
+		sect := uint8(1)
+		if types[i] == 0x01 {
+			sect = 0
+		}
+		_ = binary.Write(&syms, binary.LittleEndian, macho.Nlist64{Name: uint32(len(strtab)), Type: types[i], Sect: sect})
+		strtab = append(append(strtab, name...), 0)
+	}
+
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, macho.FileHeader{Magic: macho.Magic64, Cpu: macho.CpuArm64, Type: macho.TypeDylib, Ncmd: 1, Cmdsz: 24})
+	b.Write(make([]byte, 4))
+	_ = binary.Write(&b, binary.LittleEndian, macho.SymtabCmd{
+		Cmd:     macho.LoadCmdSymtab,
+		Len:     24,
+		Symoff:  symoff,
+		Nsyms:   uint32(len(names)),
+		Stroff:  uint32(symoff + syms.Len()),
+		Strsize: uint32(len(strtab)),
+	})
+	b.Write(syms.Bytes())
+	b.Write(strtab)
+	return b.Bytes()
+}
+
+// peWithExports returns an amd64 DLL with a single .edata section holding an
+// export directory that names the given functions.
+func peWithExports(names ...string) []byte {
+	const (
+		rawOffset = 0x200
+		rva       = 0x1000
+	)
+	edata := make([]byte, 40+4*len(names))
+	binary.LittleEndian.PutUint32(edata[24:], uint32(len(names)))
+	binary.LittleEndian.PutUint32(edata[32:], rva+40)
+	for i, name := range names {
+		binary.LittleEndian.PutUint32(edata[40+4*i:], uint32(rva+len(edata)))
+		edata = append(append(edata, name...), 0)
+	}
+
+	header := pe.OptionalHeader64{Magic: 0x20b, NumberOfRvaAndSizes: 16}
+	header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT] = pe.DataDirectory{VirtualAddress: rva, Size: uint32(len(edata))}
+	section := pe.SectionHeader32{
+		VirtualSize:      uint32(len(edata)),
+		VirtualAddress:   rva,
+		SizeOfRawData:    uint32(len(edata)),
+		PointerToRawData: rawOffset,
+	}
+	copy(section.Name[:], ".edata")
+
+	var b bytes.Buffer
+	dos := make([]byte, 0x80)
+	copy(dos, "MZ")
+	binary.LittleEndian.PutUint32(dos[0x3c:], 0x80)
+	b.Write(dos)
+	b.WriteString("PE\x00\x00")
+	_ = binary.Write(&b, binary.LittleEndian, pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_AMD64,
+		NumberOfSections:     1,
+		SizeOfOptionalHeader: uint16(binary.Size(header)),
+		Characteristics:      pe.IMAGE_FILE_DLL,
+	})
+	_ = binary.Write(&b, binary.LittleEndian, header)
+	_ = binary.Write(&b, binary.LittleEndian, section)
+	b.Write(make([]byte, rawOffset-b.Len()))
+	b.Write(edata)
+	return b.Bytes()
+}
@@ -0,0 +1,125 @@
+package objfile
+
+import (
+	"cmp"
+	"debug/elf"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Symbol version prefixes of the GNU C and C++ runtime libraries.
+const (
+	glibcPrefix   = "GLIBC_"
+	glibcxxPrefix = "GLIBCXX_"
+)
+
+// glibcLibraries are the sonames only a glibc based binary links against.
+var glibcLibraries = []string{"libc.so.6", "libm.so.6", "libdl.so.2", "libpthread.so.0", "librt.so.1"}
+
+// SymbolVersion is the highest version of a versioned runtime library a
+// binary requires, with the imported symbols that require it.
+type SymbolVersion struct {
+	Version string   `json:"version"`
+	Symbols []string `json:"symbols"`
+}
+
+// LinuxDependencies lists what an ELF binary needs from the C and C++
+// runtime of the system it is loaded on.
+type LinuxDependencies struct {
+	Libraries []string       `json:"libraries"`
+	GLIBC     *SymbolVersion `json:"glibc,omitempty"`
+	GLIBCXX   *SymbolVersion `json:"glibcxx,omitempty"`
+}
+
+// UsesGLIBC reports whether the binary depends on glibc, either through a
+// versioned symbol or a glibc soname.
+func (d LinuxDependencies) UsesGLIBC() bool {
+	if d.GLIBC != nil {
+		return true
+	}
+	for _, library := range d.Libraries {
+		if slices.Contains(glibcLibraries, library) || strings.HasPrefix(library, "ld-linux") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadLinuxDependencies reads the needed libraries and the highest GLIBC and
+// GLIBCXX symbol versions from the dynamic section of the ELF file at path.
+func ReadLinuxDependencies(path string) (LinuxDependencies, error) {
+	ef, err := elf.Open(path)
+	if err != nil {
+		return LinuxDependencies{}, fmt.Errorf("parse ELF: %w", err)
+	}
+	defer ef.Close()
+
+	libraries, err := ef.ImportedLibraries()
+	if err != nil {
+		return LinuxDependencies{}, fmt.Errorf("read needed libraries: %w", err)
+	}
+	symbols, err := ef.ImportedSymbols()
+	if err != nil {
+		return LinuxDependencies{}, fmt.Errorf("read imported symbols: %w", err)
+	}
+
+	deps := LinuxDependencies{Libraries: libraries}
+	for _, sym := range symbols {
+		switch {
+		case strings.HasPrefix(sym.Version, glibcxxPrefix):
+			deps.GLIBCXX = maxSymbolVersion(deps.GLIBCXX, strings.TrimPrefix(sym.Version, glibcxxPrefix), sym.Name)
+		case strings.HasPrefix(sym.Version, glibcPrefix):
+			deps.GLIBC = maxSymbolVersion(deps.GLIBC, strings.TrimPrefix(sym.Version, glibcPrefix), sym.Name)
+		}
+	}
+	for _, v := range []*SymbolVersion{deps.GLIBC, deps.GLIBCXX} {
+		if v != nil {
+			slices.Sort(v.Symbols)
+			v.Symbols = slices.Compact(v.Symbols)
+		}
+	}
+	return deps, nil
+}
+
+func maxSymbolVersion(current *SymbolVersion, version, symbol string) *SymbolVersion {
+	if current == nil {
+		return &SymbolVersion{Version: version, Symbols: []string{symbol}}
+	}
+	switch CompareVersions(version, current.Version) {
+	case 1:
+		return &SymbolVersion{Version: version, Symbols: []string{symbol}}
+	case 0:
+		current.Symbols = append(current.Symbols, symbol)
+	}
+	return current
+}
+
+// CompareVersions compares dotted numeric versions such as 2.28 and 2.2.5,
+// returning -1, 0 or +1. Missing components count as zero and non-numeric
+// components compare as strings.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		var c int
+		if xerr == nil && yerr == nil {
+			c = cmp.Compare(xn, yn)
+		} else {
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
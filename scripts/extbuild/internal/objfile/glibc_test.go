@@ -0,0 +1,57 @@
+package objfile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "2.28", b: "2.28", want: 0},
+		{a: "2.2.5", b: "2.28", want: -1},
+		{a: "2.34", b: "2.28", want: 1},
+		{a: "3.4", b: "3.4.0", want: 0},
+		{a: "3.4.30", b: "3.4.9", want: 1},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, CompareVersions(tc.a, tc.b), "%s vs %s", tc.a, tc.b)
+	}
+}
+
+func TestReadLinuxDependencies(t *testing.T) {
+	t.Parallel()
+
+	deps, err := ReadLinuxDependencies(filepath.Join("..", "..", "testdata", "objfile", "libquack_linux_amd64.so"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"libc.so.6"}, deps.Libraries)
+	assert.Equal(t, &SymbolVersion{Version: "2.2.5", Symbols: []string{"printf"}}, deps.GLIBC)
+	assert.Nil(t, deps.GLIBCXX)
+	assert.True(t, deps.UsesGLIBC())
+}
+
+func TestReadLinuxDependenciesCPlusPlus(t *testing.T) {
+	t.Parallel()
+
+	deps, err := ReadLinuxDependencies(filepath.Join("..", "..", "testdata", "objfile", "libquack_cpp_linux_amd64.so"))
+	require.NoError(t, err)
+	assert.Contains(t, deps.Libraries, "libstdc++.so.6")
+	require.NotNil(t, deps.GLIBCXX)
+	assert.Equal(t, "3.4.21", deps.GLIBCXX.Version, "the highest version wins over GLIBCXX_3.4 and GLIBCXX_3.4.9")
+	assert.Len(t, deps.GLIBCXX.Symbols, 3)
+}
+
+func TestUsesGLIBCWithoutVersionedSymbols(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, LinuxDependencies{Libraries: []string{"ld-linux-x86-64.so.2"}}.UsesGLIBC())
+	assert.False(t, LinuxDependencies{Libraries: []string{"libc.musl-x86_64.so.1"}}.UsesGLIBC())
+}
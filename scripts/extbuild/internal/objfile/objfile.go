@@ -0,0 +1,178 @@
+// Package objfile identifies the object format, operating system and CPU of
+// extension binaries, and maps them to DuckDB platform names.
+package objfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Object formats.
+const (
+	FormatELF   = "elf"
+	FormatMachO = "macho"
+	FormatPE    = "pe"
+	FormatWasm  = "wasm"
+)
+
+// Operating systems and architectures, named like the parts of a duckdb_arch.
+const (
+	OSLinux   = "linux"
+	OSMacOS   = "osx"
+	OSWindows = "windows"
+	OSWasm    = "wasm"
+
+	ArchAMD64  = "amd64"
+	ArchARM64  = "arm64"
+	ArchWasm32 = "wasm32"
+)
+
+// ErrUnknownFormat is returned for files that are not ELF, Mach-O, PE or wasm.
+var ErrUnknownFormat = errors.New("unknown object format")
+
+var wasmMagic = []byte{0x00, 'a', 's', 'm'}
+
+// Target describes what a binary was built for. Universal Mach-O binaries
+// list every architecture they contain.
+type Target struct {
+	Format string   `json:"format"`
+	OS     string   `json:"os"`
+	Archs  []string `json:"archs"`
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s/%s (%s)", t.OS, strings.Join(t.Archs, "+"), t.Format)
+}
+
+// IdentifyFile identifies the binary at path.
+func IdentifyFile(path string) (Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Target{}, err
+	}
+	defer f.Close()
+	return Identify(f)
+}
+
+// Identify identifies a binary from its headers. Trailing data such as the
+// DuckDB metadata footer is ignored.
+func Identify(r io.ReaderAt) (Target, error) {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Target{}, ErrUnknownFormat
+		}
+		return Target{}, err
+	}
+
+	switch {
+	case bytes.Equal(magic, wasmMagic):
+		return Target{Format: FormatWasm, OS: OSWasm, Archs: []string{ArchWasm32}}, nil
+	case bytes.Equal(magic, []byte(elf.ELFMAG)):
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return Target{}, fmt.Errorf("parse ELF: %w", err)
+		}
+		return Target{Format: FormatELF, OS: OSLinux, Archs: []string{elfArch(f.Machine)}}, nil
+	case bytes.Equal(magic[:2], []byte("MZ")):
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return Target{}, fmt.Errorf("parse PE: %w", err)
+		}
+		return Target{Format: FormatPE, OS: OSWindows, Archs: []string{peArch(f.Machine)}}, nil
+	}
+
+	if fat, err := macho.NewFatFile(r); err == nil {
+		archs := make([]string, 0, len(fat.Arches))
+		for _, arch := range fat.Arches {
+			archs = append(archs, machoArch(arch.Cpu))
+		}
+		slices.Sort(archs)
+		return Target{Format: FormatMachO, OS: OSMacOS, Archs: archs}, nil
+	}
+	if f, err := macho.NewFile(r); err == nil {
+		return Target{Format: FormatMachO, OS: OSMacOS, Archs: []string{machoArch(f.Cpu)}}, nil
+	}
+	return Target{}, ErrUnknownFormat
+}
+
+func elfArch(machine elf.Machine) string {
+	switch machine {
+	case elf.EM_X86_64:
+		return ArchAMD64
+	case elf.EM_AARCH64:
+		return ArchARM64
+	default:
+		return strings.ToLower(strings.TrimPrefix(machine.String(), "EM_"))
+	}
+}
+
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return ArchAMD64
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return ArchARM64
+	default:
+		return fmt.Sprintf("pe_machine_%#x", machine)
+	}
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return ArchAMD64
+	case macho.CpuArm64:
+		return ArchARM64
+	default:
+		return strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+	}
+}
+
+// PlatformTarget returns the target a DuckDB platform such as linux_amd64_musl,
+// windows_amd64_mingw or wasm_eh must be built for.
+func PlatformTarget(platform string) (Target, error) {
+	parts := strings.Split(platform, "_")
+	if len(parts) < 2 {
+		return Target{}, fmt.Errorf("invalid DuckDB platform %q", platform)
+	}
+	if parts[0] == OSWasm {
+		return Target{Format: FormatWasm, OS: OSWasm, Archs: []string{ArchWasm32}}, nil
+	}
+
+	var format string
+	switch parts[0] {
+	case OSLinux:
+		format = FormatELF
+	case OSMacOS:
+		format = FormatMachO
+	case OSWindows:
+		format = FormatPE
+	default:
+		return Target{}, fmt.Errorf("invalid DuckDB platform %q: unknown operating system %q", platform, parts[0])
+	}
+	switch parts[1] {
+	case ArchAMD64, ArchARM64:
+	default:
+		return Target{}, fmt.Errorf("invalid DuckDB platform %q: unknown architecture %q", platform, parts[1])
+	}
+	return Target{Format: format, OS: parts[0], Archs: []string{parts[1]}}, nil
+}
+
+// MatchesPlatform reports whether the binary target can serve the DuckDB
+// platform. A universal Mach-O binary matches every architecture it contains.
+func (t Target) MatchesPlatform(platform string) (bool, error) {
+	want, err := PlatformTarget(platform)
+	if err != nil {
+		return false, err
+	}
+	return t.Format == want.Format && t.OS == want.OS && slices.Contains(t.Archs, want.Archs[0]), nil
+}
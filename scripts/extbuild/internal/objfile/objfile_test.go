@@ -0,0 +1,142 @@
+package objfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want Target
+	}{
+		{name: "elf arm64", data: minimalELF(elf.EM_AARCH64), want: Target{Format: FormatELF, OS: OSLinux, Archs: []string{ArchARM64}}},
+		{name: "macho amd64", data: minimalMachO(macho.CpuAmd64), want: Target{Format: FormatMachO, OS: OSMacOS, Archs: []string{ArchAMD64}}},
+		{name: "macho universal", data: minimalFatMachO(macho.CpuArm64, macho.CpuAmd64), want: Target{Format: FormatMachO, OS: OSMacOS, Archs: []string{ArchAMD64, ArchARM64}}},
+		{name: "pe arm64", data: minimalPE(pe.IMAGE_FILE_MACHINE_ARM64), want: Target{Format: FormatPE, OS: OSWindows, Archs: []string{ArchARM64}}},
+		{name: "wasm", data: []byte("\x00asm\x01\x00\x00\x00"), want: Target{Format: FormatWasm, OS: OSWasm, Archs: []string{ArchWasm32}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Identify(bytes.NewReader(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestIdentifyFileSharedLibrary(t *testing.T) {
+	t.Parallel()
+
+	got, err := IdentifyFile(filepath.Join("..", "..", "testdata", "objfile", "libquack_linux_amd64.so"))
+	require.NoError(t, err)
+	assert.Equal(t, Target{Format: FormatELF, OS: OSLinux, Archs: []string{ArchAMD64}}, got)
+}
+
+func TestIdentifyRejectsUnknownFormats(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range [][]byte{nil, []byte("#!/bin/sh\n"), []byte("quack quack quack")} {
+		_, err := Identify(bytes.NewReader(data))
+		require.ErrorIs(t, err, ErrUnknownFormat)
+	}
+}
+
+func TestMatchesPlatform(t *testing.T) {
+	t.Parallel()
+
+	linuxAMD64 := Target{Format: FormatELF, OS: OSLinux, Archs: []string{ArchAMD64}}
+	universal := Target{Format: FormatMachO, OS: OSMacOS, Archs: []string{ArchAMD64, ArchARM64}}
+	wasm := Target{Format: FormatWasm, OS: OSWasm, Archs: []string{ArchWasm32}}
+
+	tests := []struct {
+		target   Target
+		platform string
+		want     bool
+	}{
+		{target: linuxAMD64, platform: "linux_amd64", want: true},
+		{target: linuxAMD64, platform: "linux_amd64_musl", want: true},
+		{target: linuxAMD64, platform: "linux_arm64", want: false},
+		{target: linuxAMD64, platform: "osx_amd64", want: false},
+		{target: universal, platform: "osx_arm64", want: true},
+		{target: wasm, platform: "wasm_mvp", want: true},
+		{target: wasm, platform: "windows_amd64_mingw", want: false},
+	}
+
+	for _, tc := range tests {
+		got, err := tc.target.MatchesPlatform(tc.platform)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%s for %s", tc.target, tc.platform)
+	}
+
+	_, err := linuxAMD64.MatchesPlatform("freebsd_amd64")
+	require.ErrorContains(t, err, `unknown operating system "freebsd"`)
+	_, err = linuxAMD64.MatchesPlatform("linux_riscv64")
+	require.ErrorContains(t, err, `unknown architecture "riscv64"`)
+}
+
+// minimalELF returns a 64-bit little endian ELF header without sections.
+func minimalELF(machine elf.Machine) []byte {
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, elf.Header64{
+		Ident:   [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS64), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT)},
+		Type:    uint16(elf.ET_DYN),
+		Machine: uint16(machine),
+		Version: uint32(elf.EV_CURRENT),
+		Ehsize:  64,
+	})
+	return b.Bytes()
+}
+
+// minimalMachO returns a 64-bit Mach-O dylib header without load commands.
+func minimalMachO(cpu macho.Cpu) []byte {
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, macho.FileHeader{Magic: macho.Magic64, Cpu: cpu, Type: macho.TypeDylib})
+	b.Write(make([]byte, 4)) // reserved field of the 64-bit header
+	return b.Bytes()
+}
+
+// minimalFatMachO returns a universal binary holding one minimalMachO per cpu.
+func minimalFatMachO(cpus ...macho.Cpu) []byte {
+	const align = 12
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(cpus))})
+	offset := uint32(1 << align)
+	for _, cpu := range cpus {
+		size := uint32(len(minimalMachO(cpu)))
+		_ = binary.Write(&b, binary.BigEndian, macho.FatArchHeader{Cpu: cpu, Offset: offset, Size: size, Align: align})
+		offset += 1 << align
+	}
+	for _, cpu := range cpus {
+		b.Write(make([]byte, (1<<align)-b.Len()%(1<<align)))
+		b.Write(minimalMachO(cpu))
+	}
+	return b.Bytes()
+}
+
+// minimalPE returns a DOS stub pointing at a COFF header without sections.
+func minimalPE(machine uint16) []byte {
+	var b bytes.Buffer
+	// debug/pe reads 96 bytes of DOS header.
+	dos := make([]byte, 0x80)
+	copy(dos, "MZ")
+	binary.LittleEndian.PutUint32(dos[0x3c:], 0x80)
+	b.Write(dos)
+	b.WriteString("PE\x00\x00")
+	_ = binary.Write(&b, binary.LittleEndian, pe.FileHeader{Machine: machine, Characteristics: pe.IMAGE_FILE_DLL})
+	return b.Bytes()
+}
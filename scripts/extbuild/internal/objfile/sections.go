@@ -0,0 +1,121 @@
+package objfile
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+
+	"github.com/duckdb/extension-ci-tools/internal/wasm"
+)
+
+// Section is a named part of a binary and the number of bytes it occupies in
+// the file.
+type Section struct {
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+}
+
+// ReadSections lists the sections of the ELF, Mach-O, PE or wasm binary at
+// path in file order. Sections that occupy no file space, such as .bss, are
+// left out. Sections of universal Mach-O binaries are prefixed with their
+// architecture.
+func ReadSections(path string) ([]Section, error) {
+	target, err := IdentifyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.Format {
+	case FormatELF:
+		return elfSections(path)
+	case FormatMachO:
+		return machoSections(path)
+	case FormatPE:
+		return peSections(path)
+	default:
+		return wasmSections(path)
+	}
+}
+
+func elfSections(path string) ([]Section, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse ELF: %w", err)
+	}
+	defer f.Close()
+
+	var sections []Section
+	for _, s := range f.Sections {
+		if s.Type == elf.SHT_NULL || s.Type == elf.SHT_NOBITS {
+			continue
+		}
+		sections = append(sections, Section{Name: s.Name, Size: s.Size})
+	}
+	return sections, nil
+}
+
+func machoSections(path string) ([]Section, error) {
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		var sections []Section
+		for _, arch := range fat.Arches {
+			for _, s := range machoFileSections(arch.File) {
+				s.Name = machoArch(arch.Cpu) + ":" + s.Name
+				sections = append(sections, s)
+			}
+		}
+		return sections, nil
+	}
+
+	f, err := macho.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse Mach-O: %w", err)
+	}
+	defer f.Close()
+	return machoFileSections(f), nil
+}
+
+func machoFileSections(f *macho.File) []Section {
+	var sections []Section
+	for _, s := range f.Sections {
+		// S_ZEROFILL, S_GB_ZEROFILL and S_THREAD_LOCAL_ZEROFILL sections such
+		// as __DATA,__bss have no file content.
+		if typ := s.Flags & 0xff; typ == 0x01 || typ == 0x0c || typ == 0x12 {
+			continue
+		}
+		sections = append(sections, Section{Name: s.Seg + "," + s.Name, Size: s.Size})
+	}
+	return sections
+}
+
+func peSections(path string) ([]Section, error) {
+	f, err := pe.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse PE: %w", err)
+	}
+	defer f.Close()
+
+	sections := make([]Section, 0, len(f.Sections))
+	for _, s := range f.Sections {
+		sections = append(sections, Section{Name: s.Name, Size: uint64(s.Size)})
+	}
+	return sections, nil
+}
+
+func wasmSections(path string) ([]Section, error) {
+	m, err := wasm.ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse wasm: %w", err)
+	}
+
+	sections := make([]Section, 0, len(m.Sections))
+	for _, s := range m.Sections {
+		name := s.Kind()
+		if s.Name != "" {
+			name += " " + s.Name
+		}
+		sections = append(sections, Section{Name: name, Size: uint64(s.Size)})
+	}
+	return sections, nil
+}
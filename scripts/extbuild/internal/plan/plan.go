@@ -0,0 +1,508 @@
+// Package plan resolves the jobs and steps that _extension_distribution.yml
+// and _extension_deploy.yml run for a given event, set of inputs and
+// distribution matrix, without running any of them.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/duckdb/extension-ci-tools/internal/toolchain"
+	"github.com/duckdb/extension-ci-tools/internal/vcpkgdeps"
+)
+
+const (
+	extbuildPath        = "extension-ci-tools/scripts/extbuild/build/extbuild"
+	matrixPath          = "extension-ci-tools/config/distribution_matrix.json"
+	defaultDeployRunner = "ubuntu-latest"
+	defaultDeployScript = "./duckdb/scripts/extension-upload-single.sh"
+	nightlyBucket       = "duckdb-extensions-nightly"
+)
+
+// jobIDs maps matrix platforms to the job that builds them in
+// _extension_distribution.yml, in the order the jobs are declared.
+var jobIDs = []struct {
+	platform string
+	job      string
+}{
+	{platform: "linux", job: "linux"},
+	{platform: "osx", job: "macos"},
+	{platform: "windows", job: "windows"},
+	{platform: "wasm", job: "wasm"},
+}
+
+// Event is the subset of a GitHub event payload that influences the plan.
+type Event struct {
+	Type string `json:"type"`
+	Ref  string `json:"ref,omitempty"`
+}
+
+type Options struct {
+	Event         Event
+	Inputs        inputs.Inputs
+	ReducedCIMode distmatrix.ReducedCIMode
+	Matrices      map[string]distmatrix.PlatformMatrix
+}
+
+type Plan struct {
+	Event         Event  `json:"event"`
+	ReducedCIMode string `json:"reduced_ci_mode"`
+	Jobs          []Job  `json:"jobs"`
+}
+
+type Job struct {
+	ID         string   `json:"id"`
+	DuckDBArch string   `json:"duckdb_arch,omitempty"`
+	Runner     string   `json:"runner"`
+	Needs      []string `json:"needs,omitempty"`
+	Steps      []Step   `json:"steps"`
+}
+
+// Step is a single resolved workflow step, identified within its job by ID.
+// Steps that run a shell command carry it in Command, together with the
+// environment variables it needs and the directory it runs in relative to the
+// extension repository. Steps that call an action carry the action in Uses and
+// its arguments in With.
+type Step struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Command string            `json:"command,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	WorkDir string            `json:"workdir,omitempty"`
+	Uses    string            `json:"uses,omitempty"`
+	With    map[string]string `json:"with,omitempty"`
+}
+
+// ForArch keeps only the jobs that build or deploy duckdbArch.
+func (p Plan) ForArch(duckdbArch string) Plan {
+	filtered := p
+	filtered.Jobs = nil
+	for _, job := range p.Jobs {
+		if job.DuckDBArch == duckdbArch {
+			filtered.Jobs = append(filtered.Jobs, job)
+		}
+	}
+	return filtered
+}
+
+// Job returns the job with the given ID that runs for duckdbArch, which is
+// empty for jobs that are not part of the matrix.
+func (p Plan) Job(id, duckdbArch string) (Job, bool) {
+	for _, job := range p.Jobs {
+		if job.ID == id && job.DuckDBArch == duckdbArch {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// Step returns the step with the given ID.
+func (j Job) Step(id string) (Step, bool) {
+	for _, step := range j.Steps {
+		if step.ID == id {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+// Build resolves the job graph. Steps whose condition is false for the given
+// inputs and architecture are left out.
+func Build(opts Options) (Plan, error) {
+	in := opts.Inputs
+	if in == nil {
+		in = inputs.Inputs{}
+	}
+	vcpkgDeps, err := vcpkgdeps.Parse(in.String("vcpkg_extra_dependencies"))
+	if err != nil {
+		return Plan{}, err
+	}
+	testConfig, err := in.TestConfig()
+	if err != nil {
+		return Plan{}, err
+	}
+
+	toolchains, err := toolchain.Requested(in.String("extra_toolchains"), in.Bool("enable_rust"))
+	if err != nil {
+		return Plan{}, fmt.Errorf("parse extra_toolchains: %w", err)
+	}
+
+	b := builder{in: in, vcpkgDeps: vcpkgDeps, testEnv: testConfig.Env(), toolchains: toolchains}
+	p := Plan{
+		Event:         opts.Event,
+		ReducedCIMode: string(opts.ReducedCIMode),
+		Jobs:          []Job{b.generateMatrixJob()},
+	}
+
+	var buildJobs []string
+	for _, id := range jobIDs {
+		matrix, ok := opts.Matrices[id.platform]
+		if !ok || len(matrix.Include) == 0 {
+			continue
+		}
+		buildJobs = append(buildJobs, id.job)
+		for _, entry := range matrix.Include {
+			p.Jobs = append(p.Jobs, b.platformJob(id.platform, id.job, entry))
+		}
+	}
+
+	latest, versioned := deployFlags(opts.Event)
+	for _, id := range jobIDs {
+		for _, entry := range opts.Matrices[id.platform].Include {
+			p.Jobs = append(p.Jobs, b.deployJob(entry.DuckDBArch, buildJobs, latest, versioned))
+		}
+	}
+
+	return p, nil
+}
+
+// deployFlags mirrors the deploy_latest and deploy_versioned expressions of
+// the generated caller workflow.
+func deployFlags(event Event) (latest bool, versioned bool) {
+	if event.Type != "push" {
+		return false, false
+	}
+	tag := strings.HasPrefix(event.Ref, "refs/tags/v")
+	return tag || event.Ref == "refs/heads/main", tag
+}
+
+type builder struct {
+	in         inputs.Inputs
+	vcpkgDeps  vcpkgdeps.Map
+	testEnv    [][2]string
+	toolchains []string
+}
+
+func (b builder) generateMatrixJob() Job {
+	return Job{
+		ID:     "generate_matrix",
+		Runner: "ubuntu-latest",
+		Steps: []Step{
+			{
+				ID:   "matrix",
+				Name: "Compute extension build matrix",
+				Command: fmt.Sprintf("%s matrix --input %s --exclude %s --opt-in %s --runners %s --reduced-ci-mode %s --out \"$GITHUB_OUTPUT\"",
+					extbuildPath, matrixPath,
+					shellQuote(b.in.String("exclude_archs")),
+					shellQuote(b.in.String("opt_in_archs")),
+					shellQuote(b.in.String("runners")),
+					shellQuote(b.in.String("reduced_ci_mode"))),
+			},
+			{
+				ID:      "validate-inputs",
+				Name:    "Validate workflow inputs",
+				Command: fmt.Sprintf("%s inputs validate --matrix %s --inputs \"$WORKFLOW_INPUTS\" --format github", extbuildPath, matrixPath),
+			},
+		},
+	}
+}
+
+func (b builder) platformJob(platform, jobID string, entry distmatrix.PlatformOutput) Job {
+	job := Job{
+		ID:         jobID,
+		DuckDBArch: entry.DuckDBArch,
+		Runner:     entry.Runner,
+		Needs:      []string{"generate_matrix"},
+	}
+	if platform != "linux" {
+		job.Needs = append(job.Needs, "linux")
+	}
+
+	if version := b.in.String("duckdb_version"); version != "" {
+		job.Steps = append(job.Steps, Step{
+			ID:      "checkout-duckdb",
+			Name:    "Checkout DuckDB to version",
+			Command: "make set_duckdb_version",
+			Env:     map[string]string{"DUCKDB_GIT_VERSION": version},
+		})
+	}
+
+	switch platform {
+	case "linux":
+		job.Steps = append(job.Steps, b.linuxSteps(entry)...)
+	case "osx":
+		job.Steps = append(job.Steps, b.osxSteps(entry)...)
+	case "windows":
+		job.Steps = append(job.Steps, b.windowsSteps(entry)...)
+	case "wasm":
+		job.Steps = append(job.Steps, b.wasmSteps(entry)...)
+	}
+
+	job.Steps = append(job.Steps, b.uploadStep(platform, entry.DuckDBArch))
+	return job
+}
+
+func (b builder) linuxSteps(entry distmatrix.PlatformOutput) []Step {
+	arch := entry.DuckDBArch
+	image := "duckdb/" + arch
+	dockerRun := "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir -v `pwd`/ccache_dir:/ccache_dir " + image
+
+	toolchains, _ := toolchain.ForArch(b.toolchains, arch)
+
+	steps := []Step{
+		{
+			ID:   "docker-image",
+			Name: "Build Docker image",
+			Command: fmt.Sprintf("docker build --build-arg %s --build-arg %s --build-arg %s --build-arg %s -t %s ./extension-ci-tools/docker/%s",
+				shellQuote("vcpkg_url="+b.in.String("vcpkg_url")),
+				shellQuote("vcpkg_commit="+b.in.String("vcpkg_commit")),
+				shellQuote("extra_toolchains="+toolchain.BuildArg(toolchains)),
+				shellQuote("cuda_version="+b.in.String("cuda_version")),
+				image, arch),
+		},
+		{
+			ID:      "docker-env",
+			Name:    "Create env file for docker",
+			Command: fmt.Sprintf(`%s docker env --duckdb-arch %s --matrix %s --inputs "$WORKFLOW_INPUTS" -o docker_env.txt`, extbuildPath, arch, matrixPath),
+			Env:     map[string]string{"WORKFLOW_INPUTS": b.inputsJSON()},
+		},
+		{
+			ID:      "configure",
+			Name:    "Run configure (outside Docker)",
+			Command: "make configure_ci",
+			Env:     b.duckdbVersionEnv(map[string]string{"LINUX_CI_IN_DOCKER": "0"}),
+		},
+	}
+	for _, dep := range b.vcpkgDeps[arch] {
+		steps = append(steps, Step{
+			ID:      "vcpkg-" + dep.Port,
+			Name:    "Install extra vcpkg dependency " + dep.String(),
+			Command: vcpkgdeps.DockerCommand(dep, arch),
+		})
+	}
+	steps = append(steps,
+		Step{ID: "configure-docker", Name: "Run configure (inside Docker)", Command: dockerRun + " make configure_ci"},
+		Step{ID: "build", Name: "Build extension (inside Docker)", Command: dockerRun + " make " + b.in.String("build_type")},
+	)
+	steps = append(steps, b.postBuildSteps()...)
+	steps = append(steps, b.binaryCheckSteps("linux", arch)...)
+	steps = append(steps, Step{
+		ID:      "audit-glibc",
+		Name:    "Audit glibc symbol versions",
+		Command: fmt.Sprintf("%s audit glibc %s --duckdb-arch %s --matrix %s", extbuildPath, b.extensionPath("linux", arch), arch, matrixPath),
+	})
+	if b.testsIn(entry, distmatrix.TestModeDocker) {
+		steps = append(steps, Step{ID: "test-docker", Name: "Test extension (inside docker)", Command: dockerRun + " make test_" + b.in.String("build_type")})
+	}
+	if b.testsIn(entry, distmatrix.TestModeNative) {
+		steps = append(steps, b.testStep("Test extension (outside docker)", b.duckdbVersionEnv(map[string]string{"LINUX_CI_IN_DOCKER": "0"})))
+	}
+	return steps
+}
+
+func (b builder) osxSteps(entry distmatrix.PlatformOutput) []Step {
+	steps := []Step{b.configureStep(nil)}
+	steps = append(steps, b.hostVCPKGSteps(entry.DuckDBArch)...)
+	steps = append(steps, Step{ID: "build", Name: "Build extension", Command: "make " + b.in.String("build_type"), Env: b.extensionEnv(nil)})
+	steps = append(steps, b.binaryCheckSteps("osx", entry.DuckDBArch)...)
+	if b.testsIn(entry, distmatrix.TestModeNative) {
+		steps = append(steps, b.testStep("Test Extension", nil))
+	}
+	return steps
+}
+
+func (b builder) windowsSteps(entry distmatrix.PlatformOutput) []Step {
+	rtools := "0"
+	if entry.DuckDBArch == "windows_amd64_rtools" || entry.DuckDBArch == "windows_amd64_mingw" {
+		rtools = "1"
+	}
+	platformEnv := func() map[string]string {
+		return map[string]string{"DUCKDB_PLATFORM": entry.DuckDBArch, "DUCKDB_PLATFORM_RTOOLS": rtools}
+	}
+
+	steps := []Step{b.configureStep(platformEnv())}
+	steps = append(steps, b.hostVCPKGSteps(entry.DuckDBArch)...)
+	steps = append(steps, Step{ID: "build", Name: "Build extension", Command: "make " + b.in.String("build_type"), Env: b.extensionEnv(platformEnv())})
+	steps = append(steps, b.binaryCheckSteps("windows", entry.DuckDBArch)...)
+	if b.testsIn(entry, distmatrix.TestModeNative) {
+		steps = append(steps, b.testStep("Test extension", platformEnv()))
+	}
+	return steps
+}
+
+func (b builder) wasmSteps(entry distmatrix.PlatformOutput) []Step {
+	steps := []Step{b.configureStep(nil)}
+	steps = append(steps, b.hostVCPKGSteps(entry.DuckDBArch)...)
+	steps = append(steps, Step{ID: "build", Name: "Build Wasm module", Command: "make " + entry.DuckDBArch, Env: b.extensionEnv(nil)})
+	steps = append(steps, b.binaryCheckSteps("wasm", entry.DuckDBArch)...)
+	steps = append(steps, Step{
+		ID:      "audit-wasm",
+		Name:    "Audit wasm module",
+		Command: fmt.Sprintf("%s audit wasm %s --duckdb-arch %s", extbuildPath, b.extensionPath("wasm", entry.DuckDBArch), entry.DuckDBArch),
+	})
+	return steps
+}
+
+func (b builder) hostVCPKGSteps(arch string) []Step {
+	var steps []Step
+	for _, dep := range b.vcpkgDeps[arch] {
+		steps = append(steps, Step{
+			ID:      "vcpkg-" + dep.Port,
+			Name:    "Install extra vcpkg dependency " + dep.String(),
+			Command: vcpkgdeps.HostCommand(dep),
+		})
+	}
+	return steps
+}
+
+func (b builder) postBuildSteps() []Step {
+	command := b.in.String("post_build_command")
+	if command == "" {
+		return nil
+	}
+	return []Step{{ID: "post-build", Name: "Run post build command", Command: command}}
+}
+
+// binaryCheckSteps verifies the platform and the exported symbols of the
+// extension binary the build produced.
+func (b builder) binaryCheckSteps(platform, arch string) []Step {
+	path := b.extensionPath(platform, arch)
+	return []Step{
+		{ID: "verify-platform", Name: "Verify extension platform", Command: fmt.Sprintf("%s verify-platform %s --duckdb-arch %s", extbuildPath, path, arch)},
+		{ID: "audit-symbols", Name: "Audit exported symbols", Command: fmt.Sprintf("%s audit symbols %s", extbuildPath, path)},
+	}
+}
+
+// extensionPath returns the path of the extension binary the build produces.
+func (b builder) extensionPath(platform, arch string) string {
+	name := b.in.String("extension_name")
+	if platform == "wasm" {
+		return fmt.Sprintf("build/%s/extension/%s/%s.duckdb_extension.wasm", arch, name, name)
+	}
+	return fmt.Sprintf("build/%s/extension/%s/%s.duckdb_extension", b.in.String("build_type"), name, name)
+}
+
+func (b builder) uploadStep(platform, arch string) Step {
+	path := b.extensionPath(platform, arch)
+	if b.in.Bool("upload_all_extensions") {
+		buildDir, suffix := b.in.String("build_type"), ""
+		if platform == "wasm" {
+			buildDir, suffix = arch, ".wasm"
+		}
+		path = fmt.Sprintf("build/%s/repository/**/*.duckdb_extension%s", buildDir, suffix)
+	}
+	return Step{
+		ID:   "upload",
+		Name: "Upload extension artifact",
+		Uses: "actions/upload-artifact",
+		With: map[string]string{
+			"name": artifactName(b.in, arch),
+			"path": path,
+		},
+	}
+}
+
+func (b builder) deployJob(arch string, buildJobs []string, latest, versioned bool) Job {
+	return Job{
+		ID:         "deploy",
+		DuckDBArch: arch,
+		Runner:     defaultDeployRunner,
+		Needs:      slices.Clone(buildJobs),
+		Steps: []Step{
+			{
+				ID:      "checkout-duckdb",
+				Name:    "Checkout DuckDB to version",
+				Command: "git checkout " + shellQuote(b.in.String("duckdb_version")),
+				WorkDir: "duckdb",
+			},
+			{
+				ID:   "download",
+				Name: "Download extension artifact",
+				Uses: "actions/download-artifact",
+				With: map[string]string{
+					"name": artifactName(b.in, arch),
+					"path": "/tmp/extension",
+				},
+			},
+			{
+				ID:   "deploy",
+				Name: "Deploy",
+				Command: fmt.Sprintf("%s %s $EXT_VERSION $DUCKDB_VERSION %s %s %t %t",
+					defaultDeployScript, shellQuote(b.in.String("extension_name")), arch, nightlyBucket, latest, versioned),
+			},
+		},
+	}
+}
+
+// configureStep runs the configure target for the host builds.
+func (b builder) configureStep(env map[string]string) Step {
+	return Step{ID: "configure", Name: "Run configure", Command: "make configure_ci", Env: b.duckdbVersionEnv(env)}
+}
+
+// duckdbVersionEnv adds DUCKDB_GIT_VERSION to env.
+func (b builder) duckdbVersionEnv(env map[string]string) map[string]string {
+	if env == nil {
+		env = map[string]string{}
+	}
+	env["DUCKDB_GIT_VERSION"] = b.in.String("duckdb_version")
+	return env
+}
+
+// extensionEnv adds the variables the build targets read to env.
+func (b builder) extensionEnv(env map[string]string) map[string]string {
+	if env == nil {
+		env = map[string]string{}
+	}
+	env["EXTENSION_NAME"] = b.in.String("extension_name")
+	env["EXTENSION_CANONICAL"] = b.in.String("extension_canonical")
+	env["ENABLE_EXTENSION_AUTOINSTALL"] = "1"
+	env["ENABLE_EXTENSION_AUTOLOADING"] = "1"
+	return env
+}
+
+// testsIn reports whether the workflow runs the test step of mode for entry,
+// which it does when C++ or C API extensions are tested that way. Whether the
+// tests then run is up to extbuild test should-run in the Makefiles.
+func (b builder) testsIn(entry distmatrix.PlatformOutput, mode distmatrix.TestMode) bool {
+	return !b.in.Bool("skip_tests") && (entry.TestMode == mode || entry.CAPITestMode == mode)
+}
+
+// testStep runs the test target with the test_env_variables from test_config
+// added to env, the same way extbuild test-config export does in the workflow.
+func (b builder) testStep(name string, env map[string]string) Step {
+	if env == nil {
+		env = map[string]string{}
+	}
+	env["SUBSET_EXTENSIONS_TESTS"] = b.in.String("extensions_test_selection")
+	for _, kv := range b.testEnv {
+		env[kv[0]] = kv[1]
+	}
+	return Step{ID: "test", Name: name, Command: "make test_" + b.in.String("build_type"), Env: env}
+}
+
+// inputsJSON returns the inputs the way ${{ toJSON(inputs) }} passes them to
+// a step.
+func (b builder) inputsJSON() string {
+	data, err := json.Marshal(b.in)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+func artifactName(in inputs.Inputs, arch string) string {
+	return fmt.Sprintf("%s-%s-extension-%s%s", in.String("extension_name"), in.String("duckdb_version"), arch, in.String("artifact_postfix"))
+}
+
+// shellQuote quotes value for POSIX shells when it contains anything other
+// than characters that are safe unquoted.
+func shellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
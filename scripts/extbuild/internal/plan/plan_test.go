@@ -0,0 +1,265 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMatrices() map[string]distmatrix.PlatformMatrix {
+	arm64 := "arm64"
+	return map[string]distmatrix.PlatformMatrix{
+		"linux": {Include: []distmatrix.PlatformOutput{
+			{DuckDBArch: "linux_amd64", Runner: "ubuntu-24.04", TestMode: distmatrix.TestModeDocker, CAPITestMode: distmatrix.TestModeNative},
+			{DuckDBArch: "linux_arm64", Runner: "ubuntu-24.04-arm", TestMode: distmatrix.TestModeNone, CAPITestMode: distmatrix.TestModeNone},
+		}},
+		"osx": {Include: []distmatrix.PlatformOutput{
+			{DuckDBArch: "osx_arm64", Runner: "macos-14", OSXBuildArch: &arm64, TestMode: distmatrix.TestModeNative, CAPITestMode: distmatrix.TestModeNative},
+		}},
+		"wasm": {Include: []distmatrix.PlatformOutput{
+			{DuckDBArch: "wasm_eh", Runner: "ubuntu-latest"},
+		}},
+	}
+}
+
+func TestBuildJobGraph(t *testing.T) {
+	t.Parallel()
+
+	p, err := Build(Options{
+		Event:         Event{Type: "push", Ref: "refs/tags/v1.0.0"},
+		Inputs:        inputs.Inputs{"extension_name": "quack", "duckdb_version": "v1.5.4"},
+		ReducedCIMode: distmatrix.ReducedCIAuto,
+		Matrices:      testMatrices(),
+	})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, job := range p.Jobs {
+		ids = append(ids, job.ID+"/"+job.DuckDBArch)
+	}
+	assert.Equal(t, []string{
+		"generate_matrix/",
+		"linux/linux_amd64",
+		"linux/linux_arm64",
+		"macos/osx_arm64",
+		"wasm/wasm_eh",
+		"deploy/linux_amd64",
+		"deploy/linux_arm64",
+		"deploy/osx_arm64",
+		"deploy/wasm_eh",
+	}, ids)
+
+	assert.Equal(t, []string{"generate_matrix"}, p.Jobs[1].Needs)
+	assert.Equal(t, []string{"generate_matrix", "linux"}, p.Jobs[3].Needs)
+	assert.Equal(t, []string{"linux", "macos", "wasm"}, p.Jobs[5].Needs)
+	assert.Equal(t,
+		"./duckdb/scripts/extension-upload-single.sh quack $EXT_VERSION $DUCKDB_VERSION linux_amd64 duckdb-extensions-nightly true true",
+		p.Jobs[5].Steps[2].Command)
+}
+
+func TestBuildResolvesStepConditions(t *testing.T) {
+	t.Parallel()
+
+	p, err := Build(Options{
+		Event: Event{Type: "pull_request"},
+		Inputs: inputs.Inputs{
+			"extension_name":           "quack",
+			"duckdb_version":           "v1.5.4",
+			"build_type":               "relassert",
+			"enable_rust":              true,
+			"extra_toolchains":         "python3;Parser_Tools",
+			"test_config":              `{"test_env_variables": {"QUACK_MODE": "very loud"}}`,
+			"vcpkg_extra_dependencies": `{"linux_amd64": ["openssl"]}`,
+		},
+		ReducedCIMode: distmatrix.ReducedCIEnabled,
+		Matrices:      testMatrices(),
+	})
+	require.NoError(t, err)
+
+	dockerEnv, ok := p.Jobs[1].Step("docker-env")
+	require.True(t, ok)
+	assert.Equal(t, `extension-ci-tools/scripts/extbuild/build/extbuild docker env --duckdb-arch linux_amd64 --matrix extension-ci-tools/config/distribution_matrix.json --inputs "$WORKFLOW_INPUTS" -o docker_env.txt`, dockerEnv.Command)
+	assert.JSONEq(t, `{
+		"extension_name": "quack",
+		"duckdb_version": "v1.5.4",
+		"build_type": "relassert",
+		"enable_rust": true,
+		"extra_toolchains": "python3;Parser_Tools",
+		"test_config": "{\"test_env_variables\": {\"QUACK_MODE\": \"very loud\"}}",
+		"vcpkg_extra_dependencies": "{\"linux_amd64\": [\"openssl\"]}"
+	}`, dockerEnv.Env["WORKFLOW_INPUTS"])
+
+	amd64 := stepCommands(p.Jobs[1])
+	delete(amd64, dockerEnv.Name)
+	assert.Equal(t, map[string]string{
+		"Checkout DuckDB to version":             "DUCKDB_GIT_VERSION=v1.5.4 make set_duckdb_version",
+		"Build Docker image":                     "docker build --build-arg vcpkg_url=https://github.com/microsoft/vcpkg.git --build-arg vcpkg_commit=84bab45d415d22042bd0b9081aea57f362da3f35 --build-arg 'extra_toolchains=;parser_tools;rust;' --build-arg cuda_version=13 -t duckdb/linux_amd64 ./extension-ci-tools/docker/linux_amd64",
+		"Run configure (outside Docker)":         "DUCKDB_GIT_VERSION=v1.5.4 LINUX_CI_IN_DOCKER=0 make configure_ci",
+		"Install extra vcpkg dependency openssl": "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir duckdb/linux_amd64 vcpkg install openssl --recurse",
+		"Run configure (inside Docker)":          "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir -v `pwd`/ccache_dir:/ccache_dir duckdb/linux_amd64 make configure_ci",
+		"Build extension (inside Docker)":        "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir -v `pwd`/ccache_dir:/ccache_dir duckdb/linux_amd64 make relassert",
+		"Test extension (inside docker)":         "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir -v `pwd`/ccache_dir:/ccache_dir duckdb/linux_amd64 make test_relassert",
+		"Test extension (outside docker)":        "DUCKDB_GIT_VERSION=v1.5.4 LINUX_CI_IN_DOCKER=0 QUACK_MODE='very loud' SUBSET_EXTENSIONS_TESTS=regular make test_relassert",
+		"Verify extension platform":              "extension-ci-tools/scripts/extbuild/build/extbuild verify-platform build/relassert/extension/quack/quack.duckdb_extension --duckdb-arch linux_amd64",
+		"Audit exported symbols":                 "extension-ci-tools/scripts/extbuild/build/extbuild audit symbols build/relassert/extension/quack/quack.duckdb_extension",
+		"Audit glibc symbol versions":            "extension-ci-tools/scripts/extbuild/build/extbuild audit glibc build/relassert/extension/quack/quack.duckdb_extension --duckdb-arch linux_amd64 --matrix extension-ci-tools/config/distribution_matrix.json",
+		"Upload extension artifact":              "",
+	}, amd64)
+
+	arm64 := stepCommands(p.Jobs[2])
+	assert.NotContains(t, arm64, "Install extra vcpkg dependency openssl")
+	assert.NotContains(t, arm64, "Test extension (inside docker)", "linux_arm64 is never tested")
+
+	upload := p.Jobs[4].Steps[len(p.Jobs[4].Steps)-1]
+	assert.Equal(t, "actions/upload-artifact", upload.Uses)
+	assert.Equal(t, map[string]string{
+		"name": "quack-v1.5.4-extension-wasm_eh",
+		"path": "build/wasm_eh/extension/quack/quack.duckdb_extension.wasm",
+	}, upload.With)
+
+	assert.Equal(t,
+		"extension-ci-tools/scripts/extbuild/build/extbuild audit wasm build/wasm_eh/extension/quack/quack.duckdb_extension.wasm --duckdb-arch wasm_eh",
+		stepCommands(p.Jobs[4])["Audit wasm module"])
+
+	deploy := p.Jobs[5].Steps[2].Command
+	assert.Contains(t, deploy, "duckdb-extensions-nightly false false")
+}
+
+func TestBuildSkipsTests(t *testing.T) {
+	t.Parallel()
+
+	p, err := Build(Options{
+		Event:    Event{Type: "push", Ref: "refs/heads/main"},
+		Inputs:   inputs.Inputs{"extension_name": "quack", "skip_tests": true, "duckdb_version": ""},
+		Matrices: testMatrices(),
+	})
+	require.NoError(t, err)
+
+	for _, job := range p.Jobs[1:5] {
+		commands := stepCommands(job)
+		assert.NotContains(t, commands, "Checkout DuckDB to version", "job %s/%s", job.ID, job.DuckDBArch)
+		for name := range commands {
+			assert.NotContains(t, name, "Test", "job %s/%s", job.ID, job.DuckDBArch)
+		}
+	}
+}
+
+func TestBuildAssignsUniqueStepIDs(t *testing.T) {
+	t.Parallel()
+
+	p, err := Build(Options{
+		Event: Event{Type: "push", Ref: "refs/heads/main"},
+		Inputs: inputs.Inputs{
+			"extension_name":           "quack",
+			"duckdb_version":           "v1.5.4",
+			"post_build_command":       "ls build",
+			"vcpkg_extra_dependencies": `{"linux_amd64": ["openssl", "zlib"], "osx_arm64": ["openssl"]}`,
+		},
+		Matrices: testMatrices(),
+	})
+	require.NoError(t, err)
+
+	for _, job := range p.Jobs {
+		seen := map[string]bool{}
+		for _, step := range job.Steps {
+			require.NotEmpty(t, step.ID, "job %s/%s step %q", job.ID, job.DuckDBArch, step.Name)
+			assert.False(t, seen[step.ID], "job %s/%s repeats step %s", job.ID, job.DuckDBArch, step.ID)
+			seen[step.ID] = true
+		}
+	}
+
+	linux, ok := p.Job("linux", "linux_amd64")
+	require.True(t, ok)
+	var ids []string
+	for _, step := range linux.Steps {
+		ids = append(ids, step.ID)
+	}
+	assert.Equal(t, []string{
+		"checkout-duckdb", "docker-image", "docker-env", "configure", "vcpkg-openssl", "vcpkg-zlib", "configure-docker", "build",
+		"post-build", "verify-platform", "audit-symbols", "audit-glibc", "test-docker", "test", "upload",
+	}, ids)
+
+	deploy, ok := p.Job("deploy", "osx_arm64")
+	require.True(t, ok)
+	checkout, ok := deploy.Step("checkout-duckdb")
+	require.True(t, ok)
+	assert.Equal(t, "duckdb", checkout.WorkDir)
+
+	_, ok = p.Job("macos", "linux_amd64")
+	assert.False(t, ok)
+
+	osx := p.ForArch("osx_arm64")
+	require.Len(t, osx.Jobs, 2)
+	assert.Equal(t, "macos", osx.Jobs[0].ID)
+	assert.Equal(t, "deploy", osx.Jobs[1].ID)
+}
+
+func TestRenderShell(t *testing.T) {
+	t.Parallel()
+
+	p, err := Build(Options{
+		Event:    Event{Type: "push", Ref: "refs/heads/main"},
+		Inputs:   inputs.Inputs{"extension_name": "quack", "duckdb_version": "v1.5.4", "test_config": `{"test_env_variables": {"QUACK_MODE": "very loud"}}`},
+		Matrices: testMatrices(),
+	})
+	require.NoError(t, err)
+
+	script := RenderShell(p.ForArch("osx_arm64"))
+	assert.True(t, strings.HasPrefix(script, "#!/usr/bin/env bash\n# Generated by extbuild plan for a push event (refs/heads/main).\nset -euo pipefail\n"))
+	assert.Contains(t, script, "\n# Job macos (osx_arm64), runs on macos-14\n")
+	assert.Contains(t, script, "\n# 2. Run configure [configure]\n(\n  export DUCKDB_GIT_VERSION=v1.5.4\n  make configure_ci\n)\n")
+	assert.Contains(t, script, "  export QUACK_MODE='very loud'\n  export SUBSET_EXTENSIONS_TESTS=regular\n  make test_release\n")
+	assert.Contains(t, script, "\n# 7. Upload extension artifact [upload]: uses actions/upload-artifact, skipped outside GitHub Actions\n")
+	assert.Contains(t, script, "(\n  cd duckdb\n  git checkout v1.5.4\n)\n")
+}
+
+func TestBuildRejectsInvalidTestConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := Build(Options{Inputs: inputs.Inputs{"test_config": "{"}})
+	require.ErrorContains(t, err, "parse test_config")
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "v1.5.4", shellQuote("v1.5.4"))
+	assert.Equal(t, "'a b'", shellQuote("a b"))
+	assert.Equal(t, `'it'"'"'s'`, shellQuote("it's"))
+}
+
+// stepCommands renders the command of every step with its environment and
+// working directory as a single shell line.
+func stepCommands(job Job) map[string]string {
+	commands := make(map[string]string, len(job.Steps))
+	for _, step := range job.Steps {
+		var parts []string
+		if step.WorkDir != "" {
+			parts = append(parts, "cd "+step.WorkDir+" &&")
+		}
+		for _, key := range sortedKeys(step.Env) {
+			parts = append(parts, key+"="+shellQuote(step.Env[key]))
+		}
+		if step.Command != "" {
+			parts = append(parts, step.Command)
+		}
+		commands[step.Name] = strings.Join(parts, " ")
+	}
+	return commands
+}
+
+func TestBuildRejectsUnknownToolchains(t *testing.T) {
+	t.Parallel()
+
+	_, err := Build(Options{
+		Event:    Event{Type: "pull_request"},
+		Inputs:   inputs.Inputs{"extra_toolchains": "rust;rsut"},
+		Matrices: testMatrices(),
+	})
+	require.ErrorContains(t, err, `parse extra_toolchains: unknown toolchain "rsut"`)
+}
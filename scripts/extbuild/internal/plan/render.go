@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// RenderText renders the plan as an indented, human readable job graph.
+func RenderText(p Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s", p.Event.Type)
+	if p.Event.Ref != "" {
+		fmt.Fprintf(&b, " (%s)", p.Event.Ref)
+	}
+	fmt.Fprintf(&b, "\nreduced CI mode: %s\n", p.ReducedCIMode)
+
+	for _, job := range p.Jobs {
+		b.WriteString("\n")
+		b.WriteString(job.ID)
+		if job.DuckDBArch != "" {
+			fmt.Fprintf(&b, " (%s)", job.DuckDBArch)
+		}
+		fmt.Fprintf(&b, "\n  runs-on: %s\n", job.Runner)
+		if len(job.Needs) > 0 {
+			fmt.Fprintf(&b, "  needs: %s\n", strings.Join(job.Needs, ", "))
+		}
+		for i, step := range job.Steps {
+			fmt.Fprintf(&b, "  %d. %s\n", i+1, step.Name)
+			if step.Command != "" {
+				if step.WorkDir != "" {
+					fmt.Fprintf(&b, "     workdir: %s\n", step.WorkDir)
+				}
+				for _, key := range sortedKeys(step.Env) {
+					fmt.Fprintf(&b, "     env: %s=%s\n", key, shellQuote(step.Env[key]))
+				}
+				fmt.Fprintf(&b, "     $ %s\n", step.Command)
+			}
+			if step.Uses != "" {
+				fmt.Fprintf(&b, "     uses: %s\n", step.Uses)
+				for _, key := range sortedKeys(step.With) {
+					fmt.Fprintf(&b, "       %s: %s\n", key, step.With[key])
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// RenderShell renders the plan as a bash script that runs the command steps
+// of every job in order, each in a subshell with its environment and working
+// directory. Steps that call an action are left as comments.
+func RenderShell(p Plan) string {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	fmt.Fprintf(&b, "# Generated by extbuild plan for a %s event", p.Event.Type)
+	if p.Event.Ref != "" {
+		fmt.Fprintf(&b, " (%s)", p.Event.Ref)
+	}
+	b.WriteString(".\nset -euo pipefail\n")
+
+	for _, job := range p.Jobs {
+		fmt.Fprintf(&b, "\n# Job %s", job.ID)
+		if job.DuckDBArch != "" {
+			fmt.Fprintf(&b, " (%s)", job.DuckDBArch)
+		}
+		fmt.Fprintf(&b, ", runs on %s\n", job.Runner)
+		for i, step := range job.Steps {
+			if step.Uses != "" {
+				fmt.Fprintf(&b, "\n# %d. %s [%s]: uses %s, skipped outside GitHub Actions\n", i+1, step.Name, step.ID, step.Uses)
+				continue
+			}
+			fmt.Fprintf(&b, "\n# %d. %s [%s]\n(\n", i+1, step.Name, step.ID)
+			for _, key := range sortedKeys(step.Env) {
+				fmt.Fprintf(&b, "  export %s=%s\n", key, shellQuote(step.Env[key]))
+			}
+			if step.WorkDir != "" {
+				fmt.Fprintf(&b, "  cd %s\n", shellQuote(step.WorkDir))
+			}
+			fmt.Fprintf(&b, "  %s\n)\n", step.Command)
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func RenderJSON(p Plan) (string, error) {
+	payload, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payload) + "\n", nil
+}
@@ -0,0 +1,87 @@
+// Package platform detects the DuckDB platform of the host without a DuckDB
+// build, naming it the way duckdb_arch values are named in the distribution
+// matrix.
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Host describes the machine a platform is detected for. Native returns the
+// running host; tests construct their own.
+type Host struct {
+	GOOS   string
+	GOARCH string
+	// Getenv looks up the environment variables used as mingw hints.
+	Getenv func(string) string
+	// Musl reports whether the C library of a Linux host is musl.
+	Musl func() bool
+}
+
+// Native returns the host extbuild runs on.
+func Native() Host {
+	return Host{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, Getenv: os.Getenv, Musl: hasMuslLoader}
+}
+
+// hasMuslLoader reports whether the musl dynamic loader is installed, as it
+// is on Alpine and the other musl based distributions.
+func hasMuslLoader() bool {
+	matches, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+	return len(matches) > 0
+}
+
+// mingwSystems are the MSYS2 environments whose compilers target the mingw
+// runtime.
+var mingwSystems = map[string]bool{"MINGW64": true, "UCRT64": true, "CLANG64": true}
+
+// Detect returns the DuckDB platform of h, such as linux_amd64_musl or
+// osx_arm64.
+//
+// Windows hosts are windows_amd64_mingw when DUCKDB_PLATFORM_RTOOLS is 1, as
+// in the distribution workflow, or when MSYSTEM names a MinGW environment and
+// CC is a gcc or clang compiler. MSYSTEM alone is not enough because Git Bash
+// sets it to MINGW64 for MSVC builds too.
+func Detect(h Host) (string, error) {
+	var arch string
+	switch h.GOARCH {
+	case "amd64", "arm64":
+		arch = h.GOARCH
+	default:
+		return "", fmt.Errorf("no DuckDB platform for %s/%s", h.GOOS, h.GOARCH)
+	}
+
+	switch h.GOOS {
+	case "linux":
+		if h.Musl != nil && h.Musl() {
+			return "linux_" + arch + "_musl", nil
+		}
+		return "linux_" + arch, nil
+	case "darwin":
+		return "osx_" + arch, nil
+	case "windows":
+		if arch == "amd64" && isMinGW(h.Getenv) {
+			return "windows_amd64_mingw", nil
+		}
+		return "windows_" + arch, nil
+	default:
+		return "", fmt.Errorf("no DuckDB platform for %s/%s", h.GOOS, h.GOARCH)
+	}
+}
+
+func isMinGW(getenv func(string) string) bool {
+	if getenv == nil {
+		return false
+	}
+	if getenv("DUCKDB_PLATFORM_RTOOLS") == "1" {
+		return true
+	}
+	if !mingwSystems[strings.ToUpper(getenv("MSYSTEM"))] {
+		return false
+	}
+	compiler := strings.ToLower(filepath.Base(strings.ReplaceAll(getenv("CC"), `\`, "/")))
+	return strings.Contains(compiler, "gcc") || strings.Contains(compiler, "clang")
+}
@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	musl := func() bool { return true }
+	tests := []struct {
+		name string
+		host Host
+		want string
+	}{
+		{name: "linux glibc", host: Host{GOOS: "linux", GOARCH: "amd64"}, want: "linux_amd64"},
+		{name: "linux arm64 glibc", host: Host{GOOS: "linux", GOARCH: "arm64", Musl: func() bool { return false }}, want: "linux_arm64"},
+		{name: "linux musl", host: Host{GOOS: "linux", GOARCH: "amd64", Musl: musl}, want: "linux_amd64_musl"},
+		{name: "linux arm64 musl", host: Host{GOOS: "linux", GOARCH: "arm64", Musl: musl}, want: "linux_arm64_musl"},
+		{name: "macos intel", host: Host{GOOS: "darwin", GOARCH: "amd64"}, want: "osx_amd64"},
+		{name: "macos apple silicon", host: Host{GOOS: "darwin", GOARCH: "arm64", Musl: musl}, want: "osx_arm64"},
+		{name: "windows", host: Host{GOOS: "windows", GOARCH: "amd64", Getenv: env(nil)}, want: "windows_amd64"},
+		{name: "windows arm64", host: Host{GOOS: "windows", GOARCH: "arm64", Getenv: env(map[string]string{"DUCKDB_PLATFORM_RTOOLS": "1"})}, want: "windows_arm64"},
+		{name: "rtools", host: Host{GOOS: "windows", GOARCH: "amd64", Getenv: env(map[string]string{"DUCKDB_PLATFORM_RTOOLS": "1"})}, want: "windows_amd64_mingw"},
+		{name: "msys2 gcc", host: Host{GOOS: "windows", GOARCH: "amd64", Getenv: env(map[string]string{"MSYSTEM": "UCRT64", "CC": `C:\msys64\ucrt64\bin\gcc.exe`})}, want: "windows_amd64_mingw"},
+		{name: "git bash msvc", host: Host{GOOS: "windows", GOARCH: "amd64", Getenv: env(map[string]string{"MSYSTEM": "MINGW64", "CC": "cl"})}, want: "windows_amd64"},
+		{name: "gcc outside msys2", host: Host{GOOS: "windows", GOARCH: "amd64", Getenv: env(map[string]string{"MSYSTEM": "MSYS", "CC": "gcc"})}, want: "windows_amd64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Detect(tt.host)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetectRejectsUnsupportedHosts(t *testing.T) {
+	t.Parallel()
+
+	_, err := Detect(Host{GOOS: "linux", GOARCH: "386"})
+	require.EqualError(t, err, "no DuckDB platform for linux/386")
+
+	_, err = Detect(Host{GOOS: "freebsd", GOARCH: "amd64"})
+	require.EqualError(t, err, "no DuckDB platform for freebsd/amd64")
+}
+
+func TestDetectNamesMatchDistributionMatrix(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "config", "distribution_matrix.json"))
+	require.NoError(t, err)
+	matrix, err := distmatrix.ParseMatrixFile(data)
+	require.NoError(t, err)
+
+	hints := []map[string]string{nil, {"DUCKDB_PLATFORM_RTOOLS": "1"}}
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		for _, goarch := range []string{"amd64", "arm64"} {
+			for _, musl := range []bool{false, true} {
+				for _, hint := range hints {
+					host := Host{GOOS: goos, GOARCH: goarch, Getenv: env(hint), Musl: func() bool { return musl }}
+					got, err := Detect(host)
+					require.NoError(t, err)
+					assert.Contains(t, matrix.DuckDBArchs(), got)
+				}
+			}
+		}
+	}
+}
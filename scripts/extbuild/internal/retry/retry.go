@@ -0,0 +1,264 @@
+// Package retry runs a command again when it fails with output that looks
+// like a transient CI failure, such as a network reset or a failed vcpkg
+// download, waiting with exponential backoff and jitter between attempts.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultPatterns match the output of failures that are worth retrying.
+var DefaultPatterns = []string{
+	`(?i)connection reset by peer`,
+	`(?i)connection timed out`,
+	`(?i)operation timed out`,
+	`(?i)i/o timeout`,
+	`(?i)TLS handshake timeout`,
+	`(?i)temporary failure in name resolution`,
+	`(?i)could not resolve host`,
+	`(?i)unexpected EOF`,
+	`(?i)\b(502 Bad Gateway|503 Service Unavailable|504 Gateway Time-?out)\b`,
+	`(?i)toomanyrequests`,
+	`(?i)error: failed to download`,
+	`(?i)download failed`,
+	`(?i)error: curl operation failed`,
+}
+
+// waitDelay is how long a cancelled command gets to exit after SIGTERM, and
+// how long its output pipes are waited for after it exited, before it is
+// killed.
+const waitDelay = 10 * time.Second
+
+// maxLineLength caps the part of a line that is matched against the
+// patterns, so that output without line breaks cannot grow without bound.
+const maxLineLength = 64 << 10
+
+// Options configure Run.
+type Options struct {
+	// Attempts is the maximum number of attempts, at least 1.
+	Attempts int
+	// Delay is the backoff before the second attempt. It doubles with every
+	// attempt up to MaxDelay, and a random part of up to half of it is
+	// dropped to spread out retries.
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// Timeout limits each attempt. Zero means no limit. A timed out attempt is
+	// retried.
+	Timeout time.Duration
+	// Patterns select the failures that are retried by the stdout or stderr
+	// lines of the attempt. Without patterns every failure is retried.
+	Patterns []*regexp.Regexp
+	// Signals are forwarded to the running command. Once one arrived, the
+	// command is not retried.
+	Signals <-chan os.Signal
+
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+	Logger         *slog.Logger
+}
+
+// CompilePatterns compiles regular expressions for Options.Patterns.
+func CompilePatterns(exprs []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// Backoff returns the delay before the attempt following attempt n, starting
+// at 1, without jitter.
+func Backoff(n int, delay, maxDelay time.Duration) time.Duration {
+	d := delay
+	for i := 1; i < n && d < maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxDelay)
+}
+
+// Run runs name with args until it succeeds, fails for a reason that is not
+// transient, is interrupted by a signal or used up its attempts. The error of
+// the last attempt is returned; an *exec.ExitError holds its exit code.
+func Run(ctx context.Context, name string, args []string, opts Options) error {
+	if opts.Attempts < 1 {
+		return fmt.Errorf("invalid attempts: %d (must be at least 1)", opts.Attempts)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	for attempt := 1; ; attempt++ {
+		result := runAttempt(ctx, name, args, opts)
+		if result.err == nil {
+			if attempt > 1 {
+				logger.Info("Command succeeded after retrying", "attempt", attempt)
+			}
+			return nil
+		}
+
+		var reason string
+		switch {
+		case result.interrupted:
+			return result.err
+		case result.timedOut:
+			reason = fmt.Sprintf("timed out after %s", opts.Timeout)
+		case len(opts.Patterns) == 0:
+			reason = "any failure is retried"
+		case result.match != "":
+			reason = fmt.Sprintf("output matched %q", result.match)
+		default:
+			return fmt.Errorf("attempt %d failed, not retried as no transient error pattern matched: %w", attempt, result.err)
+		}
+		if attempt == opts.Attempts {
+			return fmt.Errorf("all %d attempts failed, the last %s: %w", attempt, reason, result.err)
+		}
+
+		delay := Backoff(attempt, opts.Delay, opts.MaxDelay)
+		if half := int64(delay / 2); half > 0 {
+			delay -= time.Duration(rand.Int64N(half + 1))
+		}
+		logger.Warn("Command failed, retrying", "attempt", attempt, "attempts", opts.Attempts, "reason", reason, "error", result.err, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case sig := <-opts.Signals:
+			timer.Stop()
+			return fmt.Errorf("interrupted by %s while waiting to retry: %w", sig, result.err)
+		}
+	}
+}
+
+type attemptResult struct {
+	err         error
+	timedOut    bool
+	interrupted bool
+	match       string
+}
+
+func runAttempt(ctx context.Context, name string, args []string, opts Options) attemptResult {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+	defer cancel()
+
+	stdout := &matcher{w: opts.Stdout, patterns: opts.Patterns}
+	stderr := &matcher{w: opts.Stderr, patterns: opts.Patterns}
+	cmd := exec.CommandContext(attemptCtx, name, args...)
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Cancel = func() error { return terminate(cmd.Process) }
+	cmd.WaitDelay = waitDelay
+
+	if err := cmd.Start(); err != nil {
+		return attemptResult{err: err}
+	}
+
+	done := make(chan struct{})
+	var result attemptResult
+	var forwarded sync.WaitGroup
+	forwarded.Add(1)
+	go func() {
+		defer forwarded.Done()
+		for {
+			select {
+			case sig := <-opts.Signals:
+				result.interrupted = true
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	result.err = cmd.Wait()
+	close(done)
+	forwarded.Wait()
+
+	result.timedOut = result.err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+	stdout.flush()
+	stderr.flush()
+	result.match = stdout.match
+	if result.match == "" {
+		result.match = stderr.match
+	}
+	return result
+}
+
+// terminate asks the process to exit. Where SIGTERM cannot be sent, as on
+// Windows, it is killed right away.
+func terminate(p *os.Process) error {
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
+
+// matcher passes output through to w and records the first pattern a line
+// of it matches.
+type matcher struct {
+	w        io.Writer
+	patterns []*regexp.Regexp
+	line     []byte
+	match    string
+}
+
+func (m *matcher) Write(p []byte) (int, error) {
+	if m.w != nil {
+		if _, err := m.w.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	rest := p
+	for len(rest) > 0 {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			m.appendLine(rest)
+			break
+		}
+		m.appendLine(rest[:i])
+		m.flush()
+		rest = rest[i+1:]
+	}
+	return len(p), nil
+}
+
+func (m *matcher) appendLine(p []byte) {
+	if room := maxLineLength - len(m.line); room > 0 {
+		m.line = append(m.line, p[:min(len(p), room)]...)
+	}
+}
+
+// flush matches the buffered line.
+func (m *matcher) flush() {
+	if m.match == "" {
+		for _, re := range m.patterns {
+			if re.Match(m.line) {
+				m.match = re.String()
+				break
+			}
+		}
+	}
+	m.line = m.line[:0]
+}
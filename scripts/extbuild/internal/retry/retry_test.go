@@ -0,0 +1,137 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shell skips the test where there is no POSIX shell to run the commands.
+func shell(t *testing.T) string {
+	t.Helper()
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not installed")
+	}
+	return sh
+}
+
+func testOptions(t *testing.T) Options {
+	t.Helper()
+	patterns, err := CompilePatterns(DefaultPatterns)
+	require.NoError(t, err)
+	return Options{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond, Patterns: patterns}
+}
+
+// flakyScript fails with the given output until it ran failures times,
+// counting its runs in a file.
+func flakyScript(t *testing.T, failures int, output string) string {
+	counter := filepath.Join(t.TempDir(), "runs")
+	return `n=$(cat '` + counter + `' 2>/dev/null || echo 0); n=$((n+1)); echo $n > '` + counter + `'; ` +
+		`if [ $n -le ` + string(rune('0'+failures)) + ` ]; then echo '` + output + `' >&2; exit 7; fi; echo "ran $n times"`
+}
+
+func TestRunRetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+	sh := shell(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := testOptions(t)
+	opts.Stdout, opts.Stderr = &stdout, &stderr
+	err := Run(context.Background(), sh, []string{"-c", flakyScript(t, 2, "curl: (56) Recv failure: Connection reset by peer")}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "ran 3 times\n", stdout.String())
+	assert.Equal(t, 2, strings.Count(stderr.String(), "Connection reset by peer"))
+}
+
+func TestRunGivesUpAfterAttempts(t *testing.T) {
+	t.Parallel()
+	sh := shell(t)
+
+	opts := testOptions(t)
+	opts.Attempts = 2
+	err := Run(context.Background(), sh, []string{"-c", flakyScript(t, 5, "error: Failed to download from mirror set")}, opts)
+	require.EqualError(t, err, `all 2 attempts failed, the last output matched "(?i)error: failed to download": exit status 7`)
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 7, exitErr.ExitCode())
+}
+
+func TestRunDoesNotRetryOtherFailures(t *testing.T) {
+	t.Parallel()
+	sh := shell(t)
+
+	var stdout bytes.Buffer
+	opts := testOptions(t)
+	opts.Stdout = &stdout
+	script := flakyScript(t, 1, "error: expected ; before }")
+	err := Run(context.Background(), sh, []string{"-c", script}, opts)
+	require.EqualError(t, err, "attempt 1 failed, not retried as no transient error pattern matched: exit status 7")
+
+	opts.Patterns = nil
+	require.NoError(t, Run(context.Background(), sh, []string{"-c", script}, opts))
+	assert.Equal(t, "ran 2 times\n", stdout.String())
+}
+
+func TestRunRetriesTimedOutAttempts(t *testing.T) {
+	t.Parallel()
+	sh := shell(t)
+
+	opts := testOptions(t)
+	opts.Attempts = 2
+	opts.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	err := Run(context.Background(), sh, []string{"-c", "exec sleep 10"}, opts)
+	require.ErrorContains(t, err, "all 2 attempts failed, the last timed out after 50ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	t.Parallel()
+	sh := shell(t)
+
+	signals := make(chan os.Signal, 1)
+	opts := testOptions(t)
+	opts.Signals = signals
+	opts.Patterns = nil
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		signals <- syscall.SIGTERM
+	}()
+	err := Run(context.Background(), sh, []string{"-c", "trap 'echo terminated; exit 143' TERM; sleep 10 >/dev/null 2>&1 & wait"}, opts)
+	require.EqualError(t, err, "exit status 143")
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	var got []time.Duration
+	for n := 1; n <= 5; n++ {
+		got = append(got, Backoff(n, time.Second, 5*time.Second))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, got)
+}
+
+func TestMatcherMatchesLinesAcrossWrites(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := CompilePatterns(DefaultPatterns)
+	require.NoError(t, err)
+	m := &matcher{patterns: patterns}
+	_, _ = m.Write([]byte("fetching...\nread tcp: connection res"))
+	assert.Empty(t, m.match)
+	_, _ = m.Write([]byte("et by peer\n"))
+	assert.Equal(t, "(?i)connection reset by peer", m.match)
+
+	_, err = CompilePatterns([]string{"("})
+	require.ErrorContains(t, err, `invalid pattern "("`)
+}
@@ -0,0 +1,116 @@
+package sizereport
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Size metrics a budget limit can apply to.
+const (
+	MetricRaw  = "raw"
+	MetricGzip = "gzip"
+)
+
+// Limit caps how much an extension may grow compared with its baseline.
+type Limit struct {
+	MaxGrowthPercent float64 `json:"max_growth_percent"`
+	Metric           string  `json:"metric,omitempty"`
+}
+
+// Budget holds the default limit and per duckdb_arch overrides.
+type Budget struct {
+	Default   Limit            `json:"default"`
+	Platforms map[string]Limit `json:"platforms,omitempty"`
+}
+
+// ParseBudget decodes and validates a budget file.
+func ParseBudget(data []byte) (Budget, error) {
+	var budget Budget
+	if err := decodeStrict(data, &budget); err != nil {
+		return Budget{}, err
+	}
+
+	var errs []error
+	check := func(name string, l Limit) {
+		if l.MaxGrowthPercent < 0 {
+			errs = append(errs, fmt.Errorf("%s: max_growth_percent must not be negative, got %v", name, l.MaxGrowthPercent))
+		}
+		switch l.Metric {
+		case "", MetricRaw, MetricGzip:
+		default:
+			errs = append(errs, fmt.Errorf("%s: invalid metric %q (must be raw|gzip)", name, l.Metric))
+		}
+	}
+	check("default", budget.Default)
+	for _, arch := range slices.Sorted(maps.Keys(budget.Platforms)) {
+		check("platforms."+arch, budget.Platforms[arch])
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Budget{}, err
+	}
+	return budget, nil
+}
+
+// limit returns the limit for duckdb_arch with the metric resolved, falling
+// back to the default limit and the gzip metric.
+func (b Budget) limit(duckdbArch string) Limit {
+	l, ok := b.Platforms[duckdbArch]
+	if !ok {
+		l = b.Default
+	}
+	l.Metric = cmp.Or(l.Metric, b.Default.Metric, MetricGzip)
+	return l
+}
+
+// Comparison is the growth of one extension binary against its baseline.
+type Comparison struct {
+	Extension        string  `json:"extension"`
+	DuckDBArch       string  `json:"duckdb_arch"`
+	Metric           string  `json:"metric"`
+	Baseline         int64   `json:"baseline_bytes"`
+	Current          int64   `json:"current_bytes"`
+	GrowthPercent    float64 `json:"growth_percent"`
+	MaxGrowthPercent float64 `json:"max_growth_percent"`
+	Exceeded         bool    `json:"exceeded"`
+}
+
+// Compare checks every extension of current that is also in baseline against
+// its limit. Extensions without a baseline, such as new platforms, are not
+// compared.
+func (b Budget) Compare(current, baseline Report) []Comparison {
+	previous := map[string]Extension{}
+	for _, ext := range baseline.Extensions {
+		previous[ext.key()] = ext
+	}
+
+	var comparisons []Comparison
+	for _, ext := range current.Extensions {
+		base, ok := previous[ext.key()]
+		if !ok {
+			continue
+		}
+		limit := b.limit(ext.DuckDBArch)
+		before, after := base.GzipBytes, ext.GzipBytes
+		if limit.Metric == MetricRaw {
+			before, after = base.RawBytes, ext.RawBytes
+		}
+		if before <= 0 {
+			continue
+		}
+		growth := float64(after-before) / float64(before) * 100
+		comparisons = append(comparisons, Comparison{
+			Extension:        ext.Name,
+			DuckDBArch:       ext.DuckDBArch,
+			Metric:           limit.Metric,
+			Baseline:         before,
+			Current:          after,
+			GrowthPercent:    growth,
+			MaxGrowthPercent: limit.MaxGrowthPercent,
+			Exceeded:         growth > limit.MaxGrowthPercent,
+		})
+	}
+	return comparisons
+}
@@ -0,0 +1,44 @@
+package sizereport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// RenderText renders the sizes as a table, with the sections of each binary
+// indented below it, followed by the budget comparisons.
+func RenderText(r Report) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "extension\tduckdb_arch\traw\tgzip\t")
+	for _, ext := range r.Extensions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t\n", ext.Name, ext.DuckDBArch, ext.RawBytes, ext.GzipBytes)
+		for _, s := range ext.Sections {
+			fmt.Fprintf(w, "\t  %s\t%d\t\t\n", s.Name, s.Size)
+		}
+	}
+	_ = w.Flush()
+
+	if len(r.Comparisons) > 0 {
+		b.WriteString("\nbudget:\n")
+	}
+	for _, c := range r.Comparisons {
+		status := "ok"
+		if c.Exceeded {
+			status = "EXCEEDED"
+		}
+		fmt.Fprintf(&b, "  %s %s: %s %d -> %d bytes (%+.1f%%, limit %.1f%%) %s\n",
+			c.Extension, c.DuckDBArch, c.Metric, c.Baseline, c.Current, c.GrowthPercent, c.MaxGrowthPercent, status)
+	}
+	return b.String()
+}
+
+func RenderJSON(r Report) (string, error) {
+	payload, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payload) + "\n", nil
+}
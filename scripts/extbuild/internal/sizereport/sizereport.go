@@ -0,0 +1,129 @@
+// Package sizereport measures built extension binaries and checks their
+// growth against a stored baseline and a per-platform budget.
+package sizereport
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/duckdb/extension-ci-tools/internal/objfile"
+)
+
+// Extension is the measured size of one extension binary.
+type Extension struct {
+	Name       string            `json:"extension"`
+	DuckDBArch string            `json:"duckdb_arch"`
+	Path       string            `json:"path"`
+	RawBytes   int64             `json:"raw_bytes"`
+	GzipBytes  int64             `json:"gzip_bytes"`
+	Sections   []objfile.Section `json:"sections,omitempty"`
+}
+
+// key identifies the same extension across reports.
+func (e Extension) key() string {
+	return e.Name + "/" + e.DuckDBArch
+}
+
+// Report holds the sizes of a set of extension binaries, sorted by
+// extension name and duckdb_arch, and their comparison with a baseline when
+// a budget was checked.
+type Report struct {
+	Extensions  []Extension  `json:"extensions"`
+	Comparisons []Comparison `json:"comparisons,omitempty"`
+}
+
+// Measure reads the extension binary at path. The extension name is the file
+// name up to the first dot and the duckdb_arch comes from the metadata
+// footer. Sections are only broken down when withSections is set.
+func Measure(path string, withSections bool) (Extension, error) {
+	info, err := metadata.ReadFile(path)
+	if err != nil {
+		return Extension{}, fmt.Errorf("read metadata: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Extension{}, err
+	}
+	defer f.Close()
+
+	gz := &countingWriter{}
+	zw := gzip.NewWriter(gz)
+	raw, err := io.Copy(zw, f)
+	if err != nil {
+		return Extension{}, fmt.Errorf("compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return Extension{}, fmt.Errorf("compress: %w", err)
+	}
+
+	name, _, _ := strings.Cut(filepath.Base(path), ".")
+	ext := Extension{Name: name, DuckDBArch: info.DuckDBPlatform, Path: path, RawBytes: raw, GzipBytes: gz.n}
+	if withSections {
+		ext.Sections, err = objfile.ReadSections(path)
+		if err != nil {
+			return Extension{}, fmt.Errorf("read sections: %w", err)
+		}
+	}
+	return ext, nil
+}
+
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// Build measures every path and returns the sorted report. Two binaries for
+// the same extension and duckdb_arch are rejected.
+func Build(paths []string, withSections bool) (Report, error) {
+	var report Report
+	seen := map[string]string{}
+	for _, path := range paths {
+		ext, err := Measure(path, withSections)
+		if err != nil {
+			return Report{}, fmt.Errorf("measure %q: %w", path, err)
+		}
+		if other, ok := seen[ext.key()]; ok {
+			return Report{}, fmt.Errorf("%q and %q are both %s", other, path, ext.key())
+		}
+		seen[ext.key()] = path
+		report.Extensions = append(report.Extensions, ext)
+	}
+	slices.SortFunc(report.Extensions, func(a, b Extension) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.DuckDBArch, b.DuckDBArch))
+	})
+	return report, nil
+}
+
+// ParseReport decodes a report previously written as JSON, e.g. a baseline.
+func ParseReport(data []byte) (Report, error) {
+	var report Report
+	if err := decodeStrict(data, &report); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if err := decoder.Decode(new(struct{})); err != io.EOF {
+		return errors.New("invalid JSON: multiple top-level values")
+	}
+	return nil
+}
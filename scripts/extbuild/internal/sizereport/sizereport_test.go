@@ -0,0 +1,110 @@
+package sizereport
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/duckdb/extension-ci-tools/internal/objfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stamp appends a footer for platform to one of the objfile test libraries.
+func stamp(t *testing.T, library, name, platform string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".duckdb_extension")
+	require.NoError(t, metadata.AppendFile(filepath.Join("..", "..", "testdata", "objfile", library), path, metadata.Metadata{
+		ABIType:          metadata.ABITypeCStruct,
+		ExtensionVersion: "v0.1.0",
+		DuckDBVersion:    "v1.2.0",
+		DuckDBPlatform:   platform,
+	}))
+	return path
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	arm64 := stamp(t, "libquack_linux_amd64.so", "quack", "linux_arm64")
+	amd64 := stamp(t, "libquack_cpp_linux_amd64.so", "quack", "linux_amd64")
+
+	report, err := Build([]string{arm64, amd64}, true)
+	require.NoError(t, err)
+	require.Len(t, report.Extensions, 2)
+
+	first := report.Extensions[0]
+	assert.Equal(t, "quack", first.Name)
+	assert.Equal(t, "linux_amd64", first.DuckDBArch, "extensions are sorted by duckdb_arch")
+	assert.Equal(t, amd64, first.Path)
+	assert.Equal(t, int64(14392+metadata.FooterSize), first.RawBytes)
+	assert.Less(t, first.GzipBytes, first.RawBytes)
+	assert.True(t, slices.ContainsFunc(first.Sections, func(s objfile.Section) bool { return s.Name == ".text" && s.Size > 0 }))
+
+	_, err = Build([]string{arm64, arm64}, false)
+	require.ErrorContains(t, err, "are both quack/linux_arm64")
+}
+
+func TestBuildRequiresFooter(t *testing.T) {
+	t.Parallel()
+
+	_, err := Build([]string{filepath.Join("..", "..", "testdata", "objfile", "libquack_linux_amd64.so")}, false)
+	require.ErrorIs(t, err, metadata.ErrNoFooter)
+}
+
+func TestParseBudget(t *testing.T) {
+	t.Parallel()
+
+	budget, err := ParseBudget([]byte(`{"default": {"max_growth_percent": 10}, "platforms": {"wasm_eh": {"max_growth_percent": 5, "metric": "raw"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Limit{MaxGrowthPercent: 10, Metric: MetricGzip}, budget.limit("linux_amd64"))
+	assert.Equal(t, Limit{MaxGrowthPercent: 5, Metric: MetricRaw}, budget.limit("wasm_eh"))
+
+	_, err = ParseBudget([]byte(`{"default": {"max_growth_percent": -1}, "platforms": {"osx_arm64": {"metric": "zstd"}}}`))
+	require.EqualError(t, err, "default: max_growth_percent must not be negative, got -1\n"+
+		`platforms.osx_arm64: invalid metric "zstd" (must be raw|gzip)`)
+
+	_, err = ParseBudget([]byte(`{"default": {"max_growth": 10}}`))
+	require.ErrorContains(t, err, `unknown field "max_growth"`)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	budget := Budget{
+		Default:   Limit{MaxGrowthPercent: 10},
+		Platforms: map[string]Limit{"wasm_eh": {MaxGrowthPercent: 5, Metric: MetricRaw}},
+	}
+	baseline := Report{Extensions: []Extension{
+		{Name: "quack", DuckDBArch: "linux_amd64", RawBytes: 1000, GzipBytes: 400},
+		{Name: "quack", DuckDBArch: "wasm_eh", RawBytes: 1000, GzipBytes: 400},
+	}}
+	current := Report{Extensions: []Extension{
+		{Name: "quack", DuckDBArch: "linux_amd64", RawBytes: 2000, GzipBytes: 440},
+		{Name: "quack", DuckDBArch: "osx_arm64", RawBytes: 5000, GzipBytes: 2000},
+		{Name: "quack", DuckDBArch: "wasm_eh", RawBytes: 1060, GzipBytes: 400},
+	}}
+
+	assert.Equal(t, []Comparison{
+		{Extension: "quack", DuckDBArch: "linux_amd64", Metric: MetricGzip, Baseline: 400, Current: 440, GrowthPercent: 10, MaxGrowthPercent: 10},
+		{Extension: "quack", DuckDBArch: "wasm_eh", Metric: MetricRaw, Baseline: 1000, Current: 1060, GrowthPercent: 6, MaxGrowthPercent: 5, Exceeded: true},
+	}, budget.Compare(current, baseline))
+}
+
+func TestRenderText(t *testing.T) {
+	t.Parallel()
+
+	got := RenderText(Report{
+		Extensions: []Extension{{Name: "quack", DuckDBArch: "wasm_eh", RawBytes: 1060, GzipBytes: 400}},
+		Comparisons: []Comparison{
+			{Extension: "quack", DuckDBArch: "wasm_eh", Metric: MetricRaw, Baseline: 1000, Current: 1060, GrowthPercent: 6, MaxGrowthPercent: 5, Exceeded: true},
+		},
+	})
+	assert.Equal(t, `extension  duckdb_arch  raw   gzip  
+quack      wasm_eh      1060  400   
+
+budget:
+  quack wasm_eh: raw 1000 -> 1060 bytes (+6.0%, limit 5.0%) EXCEEDED
+`, got)
+}
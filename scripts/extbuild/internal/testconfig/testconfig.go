@@ -0,0 +1,199 @@
+// Package testconfig parses the test_config input of
+// _extension_distribution.yml, a JSON object configuring the test runs of the
+// distribution jobs, and renders its variables for the shells the test steps
+// run in.
+package testconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Shells lists the output formats of Export.
+var Shells = []string{"bash", "pwsh", "docker-env"}
+
+// Fields lists the known top-level fields of test_config.
+var Fields = []string{"test_env_variables", "skip_archs"}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config is a parsed test_config.
+type Config struct {
+	// TestEnvVariables are exported to the environment of the tests.
+	TestEnvVariables map[string]string `json:"test_env_variables,omitempty"`
+	// SkipArchs are the duckdb_arch values whose tests are skipped.
+	SkipArchs []string `json:"skip_archs,omitempty"`
+}
+
+// Parse parses and validates a test_config value. An empty value is an empty
+// config. Unknown fields are ignored, see UnknownFields. All problems found
+// are returned, joined with errors.Join.
+func Parse(raw string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(raw) == "" {
+		return config, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return Config{}, fmt.Errorf("invalid JSON object: %w", err)
+	}
+
+	var errs []error
+	if envRaw, ok := fields["test_env_variables"]; ok {
+		var err error
+		config.TestEnvVariables, err = parseEnv(envRaw)
+		errs = append(errs, err)
+	}
+	if skipRaw, ok := fields["skip_archs"]; ok {
+		if err := json.Unmarshal(skipRaw, &config.SkipArchs); err != nil {
+			errs = append(errs, fmt.Errorf("skip_archs must be an array of duckdb_arch strings: %w", err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+func parseEnv(raw json.RawMessage) (map[string]string, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("test_env_variables must be a JSON object: %w", err)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	env := make(map[string]string, len(values))
+	var errs []error
+	for _, name := range names {
+		if !namePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("test_env_variables: invalid variable name %q (must match %s)", name, namePattern))
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(values[name], &value); err != nil {
+			errs = append(errs, fmt.Errorf("test_env_variables.%s must be a string, got %s", name, jsonTypeName(values[name])))
+			continue
+		}
+		// The environment of a process cannot hold a NUL byte in any shell.
+		if strings.ContainsRune(value, 0) {
+			errs = append(errs, fmt.Errorf("test_env_variables.%s: value contains a NUL byte", name))
+			continue
+		}
+		env[name] = value
+	}
+	return env, errors.Join(errs...)
+}
+
+// jsonTypeName describes the type of a valid JSON value by its first byte.
+func jsonTypeName(raw json.RawMessage) string {
+	switch trimmed := strings.TrimSpace(string(raw)); {
+	case trimmed == "null":
+		return "null"
+	case strings.HasPrefix(trimmed, "{"):
+		return "an object"
+	case strings.HasPrefix(trimmed, "["):
+		return "an array"
+	case trimmed == "true" || trimmed == "false":
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
+
+// UnknownFields returns the sorted top-level fields of raw that are not in
+// Fields. Invalid JSON has none.
+func UnknownFields(raw string) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil
+	}
+	var unknown []string
+	for name := range fields {
+		if !slices.Contains(Fields, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// Env returns the test env variables as name/value pairs sorted by name.
+func (c Config) Env() [][2]string {
+	names := make([]string, 0, len(c.TestEnvVariables))
+	for name := range c.TestEnvVariables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	env := make([][2]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, [2]string{name, c.TestEnvVariables[name]})
+	}
+	return env
+}
+
+// Skips reports whether the tests of duckdbArch are skipped.
+func (c Config) Skips(duckdbArch string) bool {
+	return slices.Contains(c.SkipArchs, duckdbArch)
+}
+
+// Export writes a statement per test env variable that sets it in shell:
+//
+//	bash        export NAME='value'
+//	pwsh        $env:NAME = 'value'
+//	docker-env  NAME=value
+//
+// A Docker env file has no quoting, so values with a line break are rejected
+// for docker-env. Nothing is written when a value is rejected.
+func (c Config) Export(w io.Writer, shell string) error {
+	if !slices.Contains(Shells, shell) {
+		return fmt.Errorf("invalid shell: %q (must be %s)", shell, strings.Join(Shells, "|"))
+	}
+	var b strings.Builder
+	for _, kv := range c.Env() {
+		name, value := kv[0], kv[1]
+		switch shell {
+		case "bash":
+			fmt.Fprintf(&b, "export %s=%s\n", name, QuoteBash(value))
+		case "pwsh":
+			fmt.Fprintf(&b, "$env:%s = %s\n", name, QuotePowerShell(value))
+		case "docker-env":
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("test_env_variables.%s: value contains a line break, which a Docker env file cannot hold", name)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", name, value)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// QuoteBash quotes s as a single-quoted bash word, in which nothing but the
+// closing quote is special.
+func QuoteBash(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuotes are the characters PowerShell accepts as a single quote.
+var powerShellQuotes = strings.NewReplacer(
+	"'", "''",
+	"‘", "‘‘",
+	"’", "’’",
+	"‚", "‚‚",
+	"‛", "‛‛",
+)
+
+// QuotePowerShell quotes s as a verbatim PowerShell string. PowerShell also
+// ends such a string at the typographic single quotes, so those are doubled
+// too.
+func QuotePowerShell(s string) string {
+	return "'" + powerShellQuotes.Replace(s) + "'"
+}
@@ -0,0 +1,105 @@
+package testconfig
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	config, err := Parse(`{"test_env_variables": {"QUACK_MODE": "loud", "A": ""}, "skip_archs": ["linux_arm64"], "timeout": "1h"}`)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{{"A", ""}, {"QUACK_MODE", "loud"}}, config.Env())
+	assert.True(t, config.Skips("linux_arm64"))
+	assert.False(t, config.Skips("linux_amd64"))
+
+	config, err = Parse(" ")
+	require.NoError(t, err)
+	assert.Empty(t, config.Env())
+
+	assert.Equal(t, []string{"timeout"}, UnknownFields(`{"timeout": "1h", "skip_archs": []}`))
+	assert.Empty(t, UnknownFields("{"))
+}
+
+func TestParseReportsEveryInvalidField(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse(`{"test_env_variables": {"RETRIES": 3, "VERBOSE": true, "QUACK MODE": "loud", "LIST": ["a"], "OK": "1", "NUL": "a\u0000b"}, "skip_archs": "linux_arm64"}`)
+	require.Error(t, err)
+	assert.Equal(t, `test_env_variables.LIST must be a string, got an array
+test_env_variables.NUL: value contains a NUL byte
+test_env_variables: invalid variable name "QUACK MODE" (must match ^[A-Za-z_][A-Za-z0-9_]*$)
+test_env_variables.RETRIES must be a string, got a number
+test_env_variables.VERBOSE must be a string, got a boolean
+skip_archs must be an array of duckdb_arch strings: json: cannot unmarshal string into Go value of type []string`, err.Error())
+
+	_, err = Parse(`{"test_env_variables": ["A=1"]}`)
+	require.ErrorContains(t, err, "test_env_variables must be a JSON object")
+
+	_, err = Parse(`{"test_env_variables": `)
+	require.ErrorContains(t, err, "invalid JSON object: ")
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	config := Config{TestEnvVariables: map[string]string{
+		"QUACK_MODE": "it's $(loud)",
+		"EMPTY":      "",
+	}}
+	tests := map[string]string{
+		"bash":       "export EMPTY=''\nexport QUACK_MODE='it'\\''s $(loud)'\n",
+		"pwsh":       "$env:EMPTY = ''\n$env:QUACK_MODE = 'it''s $(loud)'\n",
+		"docker-env": "EMPTY=\nQUACK_MODE=it's $(loud)\n",
+	}
+	for shell, want := range tests {
+		var out bytes.Buffer
+		require.NoError(t, config.Export(&out, shell))
+		assert.Equal(t, want, out.String(), shell)
+	}
+
+	var out bytes.Buffer
+	require.EqualError(t, config.Export(&out, "fish"), `invalid shell: "fish" (must be bash|pwsh|docker-env)`)
+
+	multiline := Config{TestEnvVariables: map[string]string{"A": "1", "B": "line1\nline2"}}
+	require.NoError(t, multiline.Export(&out, "bash"))
+	out.Reset()
+	require.EqualError(t, multiline.Export(&out, "docker-env"), "test_env_variables.B: value contains a line break, which a Docker env file cannot hold")
+	assert.Empty(t, out.String())
+}
+
+func TestQuotePowerShell(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "'a''b‘‘c’’d'", QuotePowerShell("a'b‘c’d"))
+	assert.Equal(t, "'$env:PATH `n'", QuotePowerShell("$env:PATH `n"))
+}
+
+// TestExportBashRoundTrip evaluates the bash output and compares the values
+// bash sees with the configured ones.
+func TestExportBashRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	values := map[string]string{
+		"V1": `it's "quoted" $HOME $(echo pwned) ` + "`id`",
+		"V2": "line1\nline2\\",
+		"V3": "'''",
+	}
+	var script bytes.Buffer
+	require.NoError(t, Config{TestEnvVariables: values}.Export(&script, "bash"))
+	script.WriteString(`printf '%s\0' "$V1" "$V2" "$V3"`)
+
+	out, err := exec.Command(bash, "-c", script.String()).Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{values["V1"], values["V2"], values["V3"], ""}, strings.Split(string(out), "\x00"))
+}
@@ -0,0 +1,86 @@
+// Package testpolicy decides whether the tests of an extension run for a
+// duckdb_arch, from the test modes of the distribution matrix and the
+// skip_tests and test_config workflow inputs.
+package testpolicy
+
+import (
+	"fmt"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+)
+
+// archAliases maps former names of a duckdb_arch to the matrix entry.
+var archAliases = map[string]string{
+	"windows_amd64_rtools": "windows_amd64_mingw",
+}
+
+// Options are the facts a decision is made from.
+type Options struct {
+	Matrix     distmatrix.MatrixFile
+	DuckDBArch string
+	// CAPI selects the test mode of C API extensions.
+	CAPI bool
+	// Context is where the caller runs, native or docker. Empty matches
+	// either, for runs outside of CI.
+	Context    distmatrix.TestMode
+	SkipTests  bool
+	TestConfig testconfig.Config
+}
+
+// Decision is the outcome of Decide.
+type Decision struct {
+	DuckDBArch string              `json:"duckdb_arch"`
+	Mode       distmatrix.TestMode `json:"test_mode"`
+	Run        bool                `json:"run"`
+	Reason     string              `json:"reason"`
+}
+
+// Decide reports whether the tests run. A duckdb_arch that is not in the
+// matrix, such as the host platform of a local build, has no policy and its
+// tests run.
+func Decide(opts Options) Decision {
+	d := Decision{DuckDBArch: opts.DuckDBArch}
+	switch {
+	case opts.SkipTests:
+		d.Reason = "skip_tests is set"
+		return d
+	case opts.TestConfig.Skips(opts.DuckDBArch):
+		d.Reason = "test_config skips this duckdb_arch"
+		return d
+	}
+
+	arch := opts.DuckDBArch
+	if alias, ok := archAliases[arch]; ok {
+		arch = alias
+	}
+	entry, ok := opts.Matrix.Entry(arch)
+	if !ok {
+		d.Run = true
+		d.Reason = "duckdb_arch is not in the distribution matrix"
+		return d
+	}
+
+	mode := entry.TestMode
+	if opts.CAPI {
+		mode = entry.CAPITestMode
+	}
+	d.Mode, _ = distmatrix.ParseTestMode(string(mode))
+	switch {
+	case d.Mode == distmatrix.TestModeNone:
+		d.Reason = "tests cannot run for this duckdb_arch"
+	case opts.Context != "" && opts.Context != d.Mode:
+		d.Reason = fmt.Sprintf("tests of this duckdb_arch run %s, not %s", where(d.Mode), where(opts.Context))
+	default:
+		d.Run = true
+		d.Reason = "tests run " + where(d.Mode)
+	}
+	return d
+}
+
+func where(mode distmatrix.TestMode) string {
+	if mode == distmatrix.TestModeDocker {
+		return "in docker"
+	}
+	return "natively"
+}
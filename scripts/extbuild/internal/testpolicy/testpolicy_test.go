@@ -0,0 +1,74 @@
+package testpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/distmatrix"
+	"github.com/duckdb/extension-ci-tools/internal/testconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadMatrix(t *testing.T) distmatrix.MatrixFile {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "config", "distribution_matrix.json"))
+	require.NoError(t, err)
+	matrix, err := distmatrix.ParseMatrixFile(data)
+	require.NoError(t, err)
+	return matrix
+}
+
+// TestDecideMatchesFormerMakefileRules checks the distribution matrix against
+// the SKIP_TESTS rules base.Makefile and duckdb_extension.Makefile used to
+// hard-code.
+func TestDecideMatchesFormerMakefileRules(t *testing.T) {
+	t.Parallel()
+
+	matrix := loadMatrix(t)
+	tests := []struct {
+		arch    string
+		capi    bool
+		context distmatrix.TestMode
+		want    bool
+	}{
+		{arch: "linux_amd64", context: distmatrix.TestModeDocker, want: true},
+		{arch: "linux_amd64", context: distmatrix.TestModeNative, want: false},
+		{arch: "linux_amd64_musl", context: distmatrix.TestModeDocker, want: true},
+		{arch: "linux_arm64", context: distmatrix.TestModeDocker, want: false},
+		{arch: "osx_arm64", want: true},
+		{arch: "osx_amd64", want: false},
+		{arch: "windows_amd64_mingw", want: true},
+		{arch: "linux_amd64", capi: true, context: distmatrix.TestModeDocker, want: false},
+		{arch: "linux_amd64", capi: true, context: distmatrix.TestModeNative, want: false},
+		{arch: "linux_amd64_musl", capi: true, context: distmatrix.TestModeNative, want: false},
+		{arch: "linux_arm64_musl", capi: true, context: distmatrix.TestModeNative, want: false},
+		{arch: "windows_amd64_mingw", capi: true, want: false},
+		{arch: "windows_amd64_rtools", capi: true, want: false},
+		{arch: "windows_amd64", capi: true, want: true},
+		{arch: "osx_arm64", capi: true, want: true},
+		{arch: "linux_amd64_gcc4", capi: true, context: distmatrix.TestModeDocker, want: true},
+	}
+	for _, tt := range tests {
+		d := Decide(Options{Matrix: matrix, DuckDBArch: tt.arch, CAPI: tt.capi, Context: tt.context})
+		assert.Equal(t, tt.want, d.Run, "%s capi=%t context=%q: %s", tt.arch, tt.capi, tt.context, d.Reason)
+	}
+}
+
+func TestDecideSkips(t *testing.T) {
+	t.Parallel()
+
+	matrix := loadMatrix(t)
+	d := Decide(Options{Matrix: matrix, DuckDBArch: "osx_arm64", SkipTests: true})
+	assert.Equal(t, Decision{DuckDBArch: "osx_arm64", Reason: "skip_tests is set"}, d)
+
+	d = Decide(Options{Matrix: matrix, DuckDBArch: "osx_arm64", TestConfig: testconfig.Config{SkipArchs: []string{"osx_arm64"}}})
+	assert.Equal(t, Decision{DuckDBArch: "osx_arm64", Reason: "test_config skips this duckdb_arch"}, d)
+
+	d = Decide(Options{Matrix: matrix, DuckDBArch: "linux_amd64", Context: distmatrix.TestModeNative})
+	assert.Equal(t, Decision{DuckDBArch: "linux_amd64", Mode: distmatrix.TestModeDocker, Reason: "tests of this duckdb_arch run in docker, not natively"}, d)
+
+	d = Decide(Options{Matrix: distmatrix.MatrixFile{"linux": {Include: []distmatrix.Entry{{DuckDBArch: "linux_amd64"}}}}, DuckDBArch: "linux_amd64"})
+	assert.Equal(t, Decision{DuckDBArch: "linux_amd64", Mode: distmatrix.TestModeNone, Reason: "tests cannot run for this duckdb_arch"}, d)
+}
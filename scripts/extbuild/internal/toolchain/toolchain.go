@@ -0,0 +1,129 @@
+// Package toolchain is the registry of the extra toolchains the build images
+// and the install steps of _extension_distribution.yml can provide, selected
+// with the extra_toolchains input.
+package toolchain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Toolchain is an extra toolchain and the targets it can be installed on.
+type Toolchain struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Targets are matrix platforms (linux, osx, windows, wasm) or single
+	// duckdb_arch values.
+	Targets []string `json:"targets"`
+}
+
+// Registry lists the known toolchains, in the order the Dockerfiles and
+// workflow steps install them.
+var Registry = []Toolchain{
+	{Name: "parser_tools", Description: "bison and flex", Targets: []string{"linux", "osx", "windows"}},
+	{Name: "rust", Description: "Rust toolchain with the cross compilation target of the arch", Targets: []string{"linux", "osx", "windows", "wasm"}},
+	{Name: "fortran", Description: "gfortran", Targets: []string{"linux", "osx"}},
+	{Name: "omp", Description: "libomp, with the compiler flags to find it", Targets: []string{"osx"}},
+	{Name: "go", Description: "Go toolchain", Targets: []string{"linux", "osx", "windows", "wasm"}},
+	{Name: "python3", Description: "Python 3, which only the musl images lack", Targets: []string{"linux_amd64_musl", "linux_arm64_musl"}},
+	{Name: "unixodbc", Description: "unixODBC development files", Targets: []string{"linux", "osx"}},
+	{Name: "multimedia", Description: "FFmpeg, SDL2 and nasm", Targets: []string{"linux"}},
+	{Name: "cuda", Description: "CUDA toolkit of the cuda_version input", Targets: []string{"linux_amd64", "linux_arm64"}},
+	{Name: "downgraded_aws_cli", Description: "AWS CLI 2.22.35", Targets: []string{"osx", "windows", "wasm"}},
+}
+
+// Names returns the names of the known toolchains in registry order.
+func Names() []string {
+	names := make([]string, len(Registry))
+	for i, tc := range Registry {
+		names[i] = tc.Name
+	}
+	return names
+}
+
+// Lookup returns the toolchain with the given normalized name.
+func Lookup(name string) (Toolchain, bool) {
+	i := slices.IndexFunc(Registry, func(tc Toolchain) bool { return tc.Name == name })
+	if i < 0 {
+		return Toolchain{}, false
+	}
+	return Registry[i], true
+}
+
+// Supports reports whether the toolchain can be installed for duckdbArch.
+func (tc Toolchain) Supports(duckdbArch string) bool {
+	platform, _, _ := strings.Cut(duckdbArch, "_")
+	return slices.Contains(tc.Targets, duckdbArch) || slices.Contains(tc.Targets, platform)
+}
+
+// Normalize trims and lower-cases a toolchain name.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Parse splits an extra_toolchains value on ';' or ',' and returns the
+// normalized names without duplicates, in registry order. Empty entries, such
+// as the leading and trailing ';' of the build-arg form, are skipped. Unknown
+// names are rejected.
+func Parse(raw string) ([]string, error) {
+	requested := map[string]bool{}
+	var unknown []string
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == ',' }) {
+		name := Normalize(part)
+		if name == "" {
+			continue
+		}
+		if _, ok := Lookup(name); !ok {
+			if !slices.Contains(unknown, name) {
+				unknown = append(unknown, name)
+			}
+			continue
+		}
+		requested[name] = true
+	}
+	if len(unknown) > 0 {
+		quoted := make([]string, len(unknown))
+		for i, name := range unknown {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		return nil, fmt.Errorf("unknown toolchain %s (known: %s)", strings.Join(quoted, ", "), strings.Join(Names(), ", "))
+	}
+
+	var names []string
+	for _, tc := range Registry {
+		if requested[tc.Name] {
+			names = append(names, tc.Name)
+		}
+	}
+	return names, nil
+}
+
+// Requested parses the extra_toolchains input and adds rust when the
+// deprecated enable_rust input is set.
+func Requested(extraToolchains string, enableRust bool) ([]string, error) {
+	if enableRust {
+		extraToolchains += ";rust"
+	}
+	return Parse(extraToolchains)
+}
+
+// BuildArg returns the value of the extra_toolchains build argument of the
+// Dockerfiles, which match ";name;" and so need the list to start and end
+// with ';'.
+func BuildArg(names []string) string {
+	return ";" + strings.Join(names, ";") + ";"
+}
+
+// ForArch splits names into the toolchains that can be installed for
+// duckdbArch and those that cannot.
+func ForArch(names []string, duckdbArch string) (supported, unsupported []string) {
+	for _, name := range names {
+		if tc, ok := Lookup(name); ok && tc.Supports(duckdbArch) {
+			supported = append(supported, name)
+		} else {
+			unsupported = append(unsupported, name)
+		}
+	}
+	return supported, unsupported
+}
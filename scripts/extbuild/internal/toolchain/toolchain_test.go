@@ -0,0 +1,69 @@
+package toolchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{name: "empty", raw: "", want: nil},
+		{name: "build-arg form", raw: ";;", want: nil},
+		{name: "registry order", raw: "rust;parser_tools", want: []string{"parser_tools", "rust"}},
+		{name: "normalized", raw: " Rust , GO;;rust;", want: []string{"rust", "go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Parse(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRejectsUnknownNames(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse("rust;rsut;Pyhton;rsut")
+	require.EqualError(t, err, `unknown toolchain "rsut", "pyhton" (known: parser_tools, rust, fortran, omp, go, python3, unixodbc, multimedia, cuda, downgraded_aws_cli)`)
+}
+
+func TestBuildArg(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ";;", BuildArg(nil))
+	assert.Equal(t, ";rust;go;", BuildArg([]string{"rust", "go"}))
+}
+
+func TestForArch(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"parser_tools", "rust", "omp", "python3", "cuda"}
+	tests := []struct {
+		arch        string
+		supported   []string
+		unsupported []string
+	}{
+		{arch: "linux_amd64", supported: []string{"parser_tools", "rust", "cuda"}, unsupported: []string{"omp", "python3"}},
+		{arch: "linux_arm64_musl", supported: []string{"parser_tools", "rust", "python3"}, unsupported: []string{"omp", "cuda"}},
+		{arch: "osx_arm64", supported: []string{"parser_tools", "rust", "omp"}, unsupported: []string{"python3", "cuda"}},
+		{arch: "wasm_eh", supported: []string{"rust"}, unsupported: []string{"parser_tools", "omp", "python3", "cuda"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arch, func(t *testing.T) {
+			t.Parallel()
+			supported, unsupported := ForArch(names, tt.arch)
+			assert.Equal(t, tt.supported, supported)
+			assert.Equal(t, tt.unsupported, unsupported)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package vcpkgdeps parses the vcpkg_extra_dependencies input, a JSON object
+// mapping duckdb_arch values to the vcpkg packages to install before the
+// build of that arch.
+package vcpkgdeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// namePattern is the vcpkg rule for port, feature and triplet names.
+var namePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Dependency is a vcpkg package spec: port[feature,...]:triplet.
+type Dependency struct {
+	Port     string   `json:"port"`
+	Features []string `json:"features,omitempty"`
+	Triplet  string   `json:"triplet,omitempty"`
+}
+
+// String renders the package spec the way vcpkg install accepts it.
+func (d Dependency) String() string {
+	spec := d.Port
+	if len(d.Features) > 0 {
+		spec += "[" + strings.Join(d.Features, ",") + "]"
+	}
+	if d.Triplet != "" {
+		spec += ":" + d.Triplet
+	}
+	return spec
+}
+
+// ParseDependency parses and validates a package spec.
+func ParseDependency(spec string) (Dependency, error) {
+	rest := spec
+	var d Dependency
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		rest, d.Triplet = rest[:i], rest[i+1:]
+		if !namePattern.MatchString(d.Triplet) {
+			return Dependency{}, fmt.Errorf("invalid dependency %q: invalid triplet %q", spec, d.Triplet)
+		}
+	}
+	if i := strings.Index(rest, "["); i >= 0 {
+		if !strings.HasSuffix(rest, "]") {
+			return Dependency{}, fmt.Errorf("invalid dependency %q: unterminated feature list", spec)
+		}
+		for _, feature := range strings.Split(rest[i+1:len(rest)-1], ",") {
+			feature = strings.TrimSpace(feature)
+			if !namePattern.MatchString(feature) {
+				return Dependency{}, fmt.Errorf("invalid dependency %q: invalid feature %q", spec, feature)
+			}
+			d.Features = append(d.Features, feature)
+		}
+		rest = rest[:i]
+	}
+	d.Port = rest
+	if !namePattern.MatchString(d.Port) {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: invalid port name %q (must be lowercase letters, digits and dashes)", spec, d.Port)
+	}
+	return d, nil
+}
+
+// Map holds the dependencies of each duckdb_arch.
+type Map map[string][]Dependency
+
+// Parse parses the vcpkg_extra_dependencies input. An empty value has no
+// dependencies.
+func Parse(raw string) (Map, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var specs map[string][]string
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return nil, fmt.Errorf("parse vcpkg_extra_dependencies: %w", err)
+	}
+	deps := make(Map, len(specs))
+	for arch, list := range specs {
+		for i, spec := range list {
+			d, err := ParseDependency(spec)
+			if err != nil {
+				return nil, fmt.Errorf("vcpkg_extra_dependencies.%s[%d]: %w", arch, i, err)
+			}
+			deps[arch] = append(deps[arch], d)
+		}
+	}
+	return deps, nil
+}
+
+// UnknownArches returns the sorted keys of m that are not in arches.
+func (m Map) UnknownArches(arches []string) []string {
+	var unknown []string
+	for arch := range m {
+		if !slices.Contains(arches, arch) {
+			unknown = append(unknown, arch)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// DockerCommand returns the command that installs d in the build image of
+// duckdbArch, with the env file of the Linux jobs.
+func DockerCommand(d Dependency, duckdbArch string) string {
+	return fmt.Sprintf("docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir duckdb/%s vcpkg install %s --recurse", duckdbArch, quote(d.String()))
+}
+
+// HostCommand returns the command that installs d with the vcpkg on the PATH.
+func HostCommand(d Dependency) string {
+	return fmt.Sprintf("vcpkg install %s --recurse", quote(d.String()))
+}
+
+// quote quotes the brackets of a feature list for the shell. Validated specs
+// hold no other special characters.
+func quote(spec string) string {
+	if strings.ContainsAny(spec, "[]") {
+		return "'" + spec + "'"
+	}
+	return spec
+}
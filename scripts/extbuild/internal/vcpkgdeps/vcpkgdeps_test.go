@@ -0,0 +1,78 @@
+package vcpkgdeps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDependency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec string
+		want Dependency
+	}{
+		{spec: "openssl", want: Dependency{Port: "openssl"}},
+		{spec: "curl[http2, ssl]", want: Dependency{Port: "curl", Features: []string{"http2", "ssl"}}},
+		{spec: "zlib:x64-linux-release", want: Dependency{Port: "zlib", Triplet: "x64-linux-release"}},
+		{spec: "arrow[parquet]:arm64-osx", want: Dependency{Port: "arrow", Features: []string{"parquet"}, Triplet: "arm64-osx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseDependency(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	got, _ := ParseDependency("curl[http2, ssl]")
+	assert.Equal(t, "curl[http2,ssl]", got.String())
+}
+
+func TestParseDependencyRejectsInvalidSpecs(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"OpenSSL":             `invalid dependency "OpenSSL": invalid port name "OpenSSL" (must be lowercase letters, digits and dashes)`,
+		"openssl; rm -rf /":   `invalid dependency "openssl; rm -rf /": invalid port name "openssl; rm -rf /" (must be lowercase letters, digits and dashes)`,
+		"curl[http2":          `invalid dependency "curl[http2": unterminated feature list`,
+		"curl[]":              `invalid dependency "curl[]": invalid feature ""`,
+		"zlib:x64_linux":      `invalid dependency "zlib:x64_linux": invalid triplet "x64_linux"`,
+		"":                    `invalid dependency "": invalid port name "" (must be lowercase letters, digits and dashes)`,
+		"curl[ssl]:$(whoami)": `invalid dependency "curl[ssl]:$(whoami)": invalid triplet "$(whoami)"`,
+	}
+	for spec, want := range tests {
+		_, err := ParseDependency(spec)
+		assert.EqualError(t, err, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	deps, err := Parse(`{"linux_amd64": ["openssl", "curl[ssl]"], "linux_amd64_gcc4": ["zlib"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, []Dependency{{Port: "openssl"}, {Port: "curl", Features: []string{"ssl"}}}, deps["linux_amd64"])
+	assert.Equal(t, []string{"linux_amd64_gcc4"}, deps.UnknownArches([]string{"linux_amd64", "osx_arm64"}))
+
+	deps, err = Parse(" ")
+	require.NoError(t, err)
+	assert.Empty(t, deps)
+
+	_, err = Parse(`{"linux_amd64": ["openssl", "Bad"]}`)
+	require.ErrorContains(t, err, `vcpkg_extra_dependencies.linux_amd64[1]: invalid dependency "Bad"`)
+
+	_, err = Parse(`{"linux_amd64": "openssl"}`)
+	require.ErrorContains(t, err, "parse vcpkg_extra_dependencies: json: cannot unmarshal string")
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	d := Dependency{Port: "curl", Features: []string{"ssl"}, Triplet: "x64-linux-release"}
+	assert.Equal(t, "vcpkg install 'curl[ssl]:x64-linux-release' --recurse", HostCommand(d))
+	assert.Equal(t, "docker run --env-file=docker_env.txt -v `pwd`:/duckdb_build_dir duckdb/linux_amd64 vcpkg install openssl --recurse",
+		DockerCommand(Dependency{Port: "openssl"}, "linux_amd64"))
+}
@@ -0,0 +1,199 @@
+// Package version derives the extension version of a build from the git
+// repository it is built from, following git describe semantics.
+package version
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/compat"
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+)
+
+// DefaultTagPatterns are the git glob patterns of release tags such as
+// v1.2.3 or 1.2.3-rc1.
+var DefaultTagPatterns = []string{"v[0-9]*.[0-9]*.[0-9]*", "[0-9]*.[0-9]*.[0-9]*"}
+
+// describePattern splits the output of git describe --long into the tag and
+// the number of commits since it.
+var describePattern = regexp.MustCompile(`^(.+)-([0-9]+)-g[0-9a-f]+$`)
+
+// Runner runs git in a repository directory and returns its trimmed standard
+// output.
+type Runner interface {
+	Git(dir string, args ...string) (string, error)
+}
+
+// ExecRunner runs the git executable on the PATH.
+type ExecRunner struct{}
+
+// Git implements Runner.
+func (ExecRunner) Git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Options configure Detect.
+type Options struct {
+	// Dir is the repository directory, the working directory when empty.
+	Dir string
+	// TagPatterns are the git glob patterns selecting the release tags,
+	// DefaultTagPatterns when empty.
+	TagPatterns []string
+	// Override is used as the version instead of reading the repository.
+	Override string
+	// Runner runs git, ExecRunner when nil.
+	Runner Runner
+}
+
+// Result is a detected extension version and how it was derived.
+type Result struct {
+	Version  string `json:"version"`
+	Tag      string `json:"tag,omitempty"`
+	Distance int    `json:"distance"`
+	Commit   string `json:"commit,omitempty"`
+	Dirty    bool   `json:"dirty"`
+}
+
+// Detect derives the extension version of HEAD:
+//
+//   - A release tag on HEAD is the version. When several release tags point at
+//     HEAD, the highest version wins.
+//   - Otherwise the release tag git describe finds gives TAG-N-gHASH, where N
+//     is the number of commits since the tag.
+//   - Without any reachable release tag the version is the short commit hash.
+//
+// A working tree with uncommitted changes to tracked files adds a -dirty
+// suffix. The version must fit the metadata footer field.
+func Detect(opts Options) (Result, error) {
+	if opts.Override != "" {
+		return checkLength(Result{Version: opts.Override})
+	}
+
+	patterns := opts.TagPatterns
+	if len(patterns) == 0 {
+		patterns = DefaultTagPatterns
+	}
+	git := opts.git()
+
+	commit, err := git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Version: commit, Commit: commit}
+
+	atHead, err := git(append([]string{"tag", "--points-at", "HEAD", "--list"}, patterns...)...)
+	if err != nil {
+		return Result{}, err
+	}
+	if tags := sortTags(atHead); len(tags) > 0 {
+		result.Version, result.Tag = tags[0], tags[0]
+	} else {
+		args := []string{"describe", "--tags", "--long", "--always"}
+		for _, pattern := range patterns {
+			args = append(args, "--match", pattern)
+		}
+		described, err := git(args...)
+		if err != nil {
+			return Result{}, err
+		}
+		// Without a matching tag, --always prints the bare commit hash.
+		if m := describePattern.FindStringSubmatch(described); m != nil {
+			distance, err := strconv.Atoi(m[2])
+			if err != nil {
+				return Result{}, fmt.Errorf("count commits since %s: %w", m[1], err)
+			}
+			result.Tag, result.Distance = m[1], distance
+			result.Version = fmt.Sprintf("%s-%d-g%s", result.Tag, result.Distance, commit)
+		}
+	}
+
+	status, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return Result{}, err
+	}
+	if status != "" {
+		result.Dirty = true
+		result.Version += "-dirty"
+	}
+	return checkLength(result)
+}
+
+// Exact derives the extension version of HEAD the way configure_helper.py
+// did: a tag on HEAD, whatever its name, is the version, otherwise the short
+// commit hash. Uncommitted changes are not marked, as CI builds modify
+// tracked files such as extension_config.cmake.
+func Exact(opts Options) (Result, error) {
+	if opts.Override != "" {
+		return checkLength(Result{Version: opts.Override})
+	}
+
+	git := opts.git()
+	commit, err := git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Version: commit, Commit: commit}
+
+	atHead, err := git("tag", "--points-at", "HEAD")
+	if err != nil {
+		return Result{}, err
+	}
+	if tags := sortTags(atHead); len(tags) > 0 {
+		result.Version, result.Tag = tags[0], tags[0]
+	}
+	return checkLength(result)
+}
+
+func (opts Options) git() func(args ...string) (string, error) {
+	runner := opts.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	dir := cmp.Or(opts.Dir, ".")
+	return func(args ...string) (string, error) { return runner.Git(dir, args...) }
+}
+
+// sortTags returns the tags of the newline separated list from the highest
+// version down. Tags that are no semantic version sort after those that are,
+// in reverse lexical order.
+func sortTags(list string) []string {
+	tags := strings.Fields(list)
+	slices.SortFunc(tags, func(a, b string) int {
+		va, errA := compat.ParseVersion(a)
+		vb, errB := compat.ParseVersion(b)
+		switch {
+		case errA == nil && errB == nil:
+			return cmp.Or(vb.Compare(va), strings.Compare(b, a))
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			return strings.Compare(b, a)
+		}
+	})
+	return tags
+}
+
+func checkLength(r Result) (Result, error) {
+	if len(r.Version) > metadata.FieldSize {
+		return Result{}, fmt.Errorf("extension version %q is %d bytes long (must be at most %d)", r.Version, len(r.Version), metadata.FieldSize)
+	}
+	return r, nil
+}
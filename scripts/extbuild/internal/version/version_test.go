@@ -0,0 +1,153 @@
+package version
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner answers git commands from a map keyed by the joined arguments.
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (r *fakeRunner) Git(_ string, args ...string) (string, error) {
+	key := strings.Join(args, " ")
+	r.calls = append(r.calls, key)
+	out, ok := r.outputs[key]
+	if !ok {
+		return "", errors.New("unexpected git " + key)
+	}
+	return out, nil
+}
+
+const (
+	tagsAtHead = "tag --points-at HEAD --list v[0-9]*.[0-9]*.[0-9]* [0-9]*.[0-9]*.[0-9]*"
+	describe   = "describe --tags --long --always --match v[0-9]*.[0-9]*.[0-9]* --match [0-9]*.[0-9]*.[0-9]*"
+)
+
+func repository(outputs map[string]string) *fakeRunner {
+	base := map[string]string{
+		"rev-parse --short HEAD": "1a2b3c4",
+		tagsAtHead:               "",
+		describe:                 "1a2b3c4",
+		"tag --points-at HEAD":   "",
+		"status --porcelain --untracked-files=no": "",
+	}
+	for k, v := range outputs {
+		base[k] = v
+	}
+	return &fakeRunner{outputs: base}
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		outputs  map[string]string
+		patterns []string
+		want     Result
+	}{
+		{
+			name: "no tags",
+			want: Result{Version: "1a2b3c4", Commit: "1a2b3c4"},
+		},
+		{
+			name:    "tag on HEAD",
+			outputs: map[string]string{tagsAtHead: "v1.2.0"},
+			want:    Result{Version: "v1.2.0", Tag: "v1.2.0", Commit: "1a2b3c4"},
+		},
+		{
+			name:    "highest of several tags on HEAD",
+			outputs: map[string]string{tagsAtHead: "v1.10.0\nv1.2.0\nv1.10.0-rc1"},
+			want:    Result{Version: "v1.10.0", Tag: "v1.10.0", Commit: "1a2b3c4"},
+		},
+		{
+			name:    "nearest reachable tag",
+			outputs: map[string]string{describe: "v1.1.0-3-g1a2b3c4d"},
+			want:    Result{Version: "v1.1.0-3-g1a2b3c4", Tag: "v1.1.0", Distance: 3, Commit: "1a2b3c4"},
+		},
+		{
+			name:    "reachable tag with a hyphen",
+			outputs: map[string]string{describe: "v2.0.0-rc1-4-g1a2b3c4"},
+			want:    Result{Version: "v2.0.0-rc1-4-g1a2b3c4", Tag: "v2.0.0-rc1", Distance: 4, Commit: "1a2b3c4"},
+		},
+		{
+			name: "dirty working tree",
+			outputs: map[string]string{
+				tagsAtHead: "v1.2.0",
+				"status --porcelain --untracked-files=no": " M src/quack.c",
+			},
+			want: Result{Version: "v1.2.0-dirty", Tag: "v1.2.0", Commit: "1a2b3c4", Dirty: true},
+		},
+		{
+			name: "custom patterns",
+			outputs: map[string]string{
+				"tag --points-at HEAD --list quack-*":             "",
+				"describe --tags --long --always --match quack-*": "quack-2024.05-2-g1a2b3c4",
+			},
+			patterns: []string{"quack-*"},
+			want:     Result{Version: "quack-2024.05-2-g1a2b3c4", Tag: "quack-2024.05", Distance: 2, Commit: "1a2b3c4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Detect(Options{Runner: repository(tt.outputs), TagPatterns: tt.patterns})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetectOverride(t *testing.T) {
+	t.Parallel()
+
+	runner := repository(nil)
+	got, err := Detect(Options{Override: "v9.9.9", Runner: runner})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Version: "v9.9.9"}, got)
+	assert.Empty(t, runner.calls)
+}
+
+func TestDetectRejectsVersionsLongerThanTheFooterField(t *testing.T) {
+	t.Parallel()
+
+	_, err := Detect(Options{Runner: repository(map[string]string{describe: "v1.0.0-preview.20240501-120-g1a2b3c4"})})
+	require.EqualError(t, err, `extension version "v1.0.0-preview.20240501-120-g1a2b3c4" is 36 bytes long (must be at most 32)`)
+
+	_, err = Detect(Options{Override: strings.Repeat("x", 33)})
+	require.ErrorContains(t, err, "is 33 bytes long (must be at most 32)")
+}
+
+func TestExact(t *testing.T) {
+	t.Parallel()
+
+	got, err := Exact(Options{Runner: repository(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Version: "1a2b3c4", Commit: "1a2b3c4"}, got)
+
+	// Any tag counts, and neither reachable tags nor a dirty tree change the
+	// version.
+	got, err = Exact(Options{Runner: repository(map[string]string{
+		"tag --points-at HEAD": "nightly\nquack-release",
+		describe:               "v1.0.0-3-g1a2b3c4",
+		"status --porcelain --untracked-files=no": " M extension_config.cmake",
+	})})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Version: "quack-release", Tag: "quack-release", Commit: "1a2b3c4"}, got)
+}
+
+func TestDetectPropagatesGitErrors(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{outputs: map[string]string{}}
+	_, err := Detect(Options{Runner: runner})
+	require.EqualError(t, err, "unexpected git rev-parse --short HEAD")
+}
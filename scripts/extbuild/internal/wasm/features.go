@@ -0,0 +1,218 @@
+package wasm
+
+import "fmt"
+
+// Features lists the post-MVP proposals a module relies on.
+type Features struct {
+	// Exceptions is set by a tag section, tag imports or exports, or any
+	// exception handling instruction (legacy try/catch or try_table).
+	Exceptions bool `json:"exceptions"`
+	// Threads is set by a shared memory or any atomic instruction.
+	Threads bool `json:"threads"`
+	// SIMD is set by v128 values or any SIMD instruction.
+	SIMD bool `json:"simd"`
+}
+
+const (
+	valTypeV128        = 0x7b
+	refTypeNullable    = 0x63
+	refTypeNonNull     = 0x64
+	blockTypeEmpty     = 0x40
+	limitsHasMax       = 0x01
+	limitsShared       = 0x02
+	opcodeEnd          = 0x0b
+	opcodePrefixMisc   = 0xfc
+	opcodePrefixSIMD   = 0xfd
+	opcodePrefixAtomic = 0xfe
+)
+
+// leb skips a signed or unsigned LEB128 number of at most 64 bits.
+func (r *reader) leb() {
+	for range 10 {
+		if r.byte()&0x80 == 0 {
+			return
+		}
+	}
+	r.fail(fmt.Errorf("LEB128 number at offset %d is too long", r.pos))
+}
+
+func (r *reader) valType(f *Features) {
+	switch t := r.byte(); t {
+	case valTypeV128:
+		f.SIMD = true
+	case refTypeNullable, refTypeNonNull:
+		r.leb()
+	}
+}
+
+func (r *reader) types(f *Features) {
+	for range r.u32() {
+		if form := r.byte(); form != 0x60 {
+			r.fail(fmt.Errorf("unsupported type form %#x", form))
+			return
+		}
+		for range 2 { // params, results
+			for range r.u32() {
+				r.valType(f)
+			}
+		}
+	}
+}
+
+func (r *reader) memories(f *Features) {
+	for range r.u32() {
+		if r.limits()&limitsShared != 0 {
+			f.Threads = true
+		}
+	}
+}
+
+func (r *reader) globals(f *Features) {
+	for range r.u32() {
+		r.valType(f)
+		r.byte()
+		r.expression(f)
+	}
+}
+
+func (r *reader) code(f *Features) {
+	for i := range r.u32() {
+		size := r.u32()
+		body := &reader{data: r.bytes(size)}
+		for range body.u32() {
+			body.u32()
+			body.valType(f)
+		}
+		for body.err == nil && body.pos < len(body.data) {
+			body.instruction(f)
+		}
+		if body.err != nil {
+			r.fail(fmt.Errorf("function body %d: %w", i, body.err))
+		}
+	}
+}
+
+// expression decodes a constant expression up to its end opcode.
+func (r *reader) expression(f *Features) {
+	for r.err == nil {
+		if r.pos < len(r.data) && r.data[r.pos] == opcodeEnd {
+			r.pos++
+			return
+		}
+		r.instruction(f)
+	}
+}
+
+func (r *reader) blockType(f *Features) {
+	if r.pos >= len(r.data) {
+		r.byte()
+		return
+	}
+	switch t := r.data[r.pos]; {
+	case t == blockTypeEmpty, t >= 0x6f && t <= 0x7f, t == refTypeNullable, t == refTypeNonNull:
+		r.valType(f)
+	default:
+		r.leb()
+	}
+}
+
+func (r *reader) memArg() {
+	if r.u32()&0x40 != 0 { // multi-memory index follows the alignment
+		r.u32()
+	}
+	r.u64()
+}
+
+// instruction decodes one instruction and its immediates, recording the
+// features its opcode belongs to.
+func (r *reader) instruction(f *Features) {
+	switch op := r.byte(); {
+	case op <= 0x01, op == 0x05, op == opcodeEnd, op == 0x0f, op == 0x1a, op == 0x1b,
+		op >= 0x45 && op <= 0xc4, op == 0xd1:
+	case op == 0x0a, op == 0x19: // throw_ref, catch_all
+		f.Exceptions = true
+	case op >= 0x02 && op <= 0x04:
+		r.blockType(f)
+	case op == 0x06: // try
+		f.Exceptions = true
+		r.blockType(f)
+	case op == 0x07, op == 0x08, op == 0x09, op == 0x18: // catch, throw, rethrow, delegate
+		f.Exceptions = true
+		r.u32()
+	case op == 0x1f: // try_table
+		f.Exceptions = true
+		r.blockType(f)
+		for range r.u32() {
+			if kind := r.byte(); kind <= 0x01 {
+				r.u32()
+			}
+			r.u32()
+		}
+	case op == 0x0c, op == 0x0d, op == 0x10, op == 0x14, op == 0x15, op == 0xd2,
+		op >= 0x20 && op <= 0x26, op == 0x3f, op == 0x40:
+		r.u32()
+	case op == 0x0e:
+		for range r.u32() {
+			r.u32()
+		}
+		r.u32()
+	case op == 0x11, op == 0x12, op == 0x13:
+		r.u32()
+		if op != 0x12 {
+			r.u32()
+		}
+	case op == 0x1c:
+		for range r.u32() {
+			r.valType(f)
+		}
+	case op >= 0x28 && op <= 0x3e:
+		r.memArg()
+	case op == 0x41, op == 0x42, op == 0xd0:
+		r.leb()
+	case op == 0x43:
+		r.bytes(4)
+	case op == 0x44:
+		r.bytes(8)
+	case op == opcodePrefixMisc:
+		r.miscInstruction()
+	case op == opcodePrefixSIMD:
+		f.SIMD = true
+		r.simdInstruction()
+	case op == opcodePrefixAtomic:
+		f.Threads = true
+		if r.u32() == 0x03 { // atomic.fence
+			r.byte()
+		} else {
+			r.memArg()
+		}
+	default:
+		r.fail(fmt.Errorf("unsupported opcode %#x", op))
+	}
+}
+
+func (r *reader) miscInstruction() {
+	switch sub := r.u32(); {
+	case sub <= 7:
+	case sub == 8, sub == 10, sub == 12, sub == 14:
+		r.u32()
+		r.u32()
+	case sub <= 17:
+		r.u32()
+	default:
+		r.fail(fmt.Errorf("unsupported 0xfc opcode %d", sub))
+	}
+}
+
+func (r *reader) simdInstruction() {
+	switch sub := r.u32(); {
+	case sub <= 11, sub == 92, sub == 93:
+		r.memArg()
+	case sub == 12, sub == 13: // v128.const, i8x16.shuffle
+		r.bytes(16)
+	case sub >= 21 && sub <= 34:
+		r.byte()
+	case sub >= 84 && sub <= 91:
+		r.memArg()
+		r.byte()
+	}
+}
@@ -0,0 +1,133 @@
+package wasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+)
+
+// dylinkSection is the custom section emscripten side modules start with.
+const dylinkSection = "dylink.0"
+
+// sectionOrder ranks the non-custom sections in the order the specification
+// requires. The tag and datacount sections sit between their neighbours
+// rather than at the position of their id.
+var sectionOrder = map[byte]int{
+	SectionType:      1,
+	SectionImport:    2,
+	SectionFunction:  3,
+	SectionTable:     4,
+	SectionMemory:    5,
+	SectionTag:       6,
+	SectionGlobal:    7,
+	SectionExport:    8,
+	SectionStart:     9,
+	SectionElement:   10,
+	SectionDataCount: 11,
+	SectionCode:      12,
+	SectionData:      13,
+}
+
+// ValidateExtension checks the layout of a .duckdb_extension.wasm file: an
+// emscripten side module whose sections appear once and in order, followed
+// by the metadata footer as the last custom section.
+func (m Module) ValidateExtension() []error {
+	var errs []error
+	if len(m.Sections) == 0 || m.Sections[0].Name != dylinkSection {
+		errs = append(errs, fmt.Errorf("first section must be the %s custom section of a side module", dylinkSection))
+	}
+
+	last := 0
+	for _, s := range m.Sections {
+		if s.ID == SectionCustom {
+			continue
+		}
+		rank, ok := sectionOrder[s.ID]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s section at offset %d is not a known section", s.Kind(), s.Offset))
+		case rank == last:
+			errs = append(errs, fmt.Errorf("%s section at offset %d appears more than once", s.Kind(), s.Offset))
+		case rank < last:
+			errs = append(errs, fmt.Errorf("%s section at offset %d is out of order", s.Kind(), s.Offset))
+		default:
+			last = rank
+		}
+	}
+
+	signatures := 0
+	for _, s := range m.Sections {
+		if s.ID == SectionCustom && s.Name == metadata.SectionName {
+			signatures++
+		}
+	}
+	if signatures > 1 {
+		errs = append(errs, fmt.Errorf("%s custom section appears %d times", metadata.SectionName, signatures))
+	}
+	if len(m.Sections) > 0 {
+		footer := m.Sections[len(m.Sections)-1]
+		switch {
+		case footer.ID != SectionCustom || footer.Name != metadata.SectionName:
+			errs = append(errs, fmt.Errorf("last section must be the %s custom section, got %s", metadata.SectionName, sectionLabel(footer)))
+		case footer.Offset != m.Size-metadata.FooterSize:
+			errs = append(errs, fmt.Errorf("%s custom section is %d bytes long (must be %d)", metadata.SectionName, m.Size-footer.Offset, metadata.FooterSize))
+		}
+	}
+	return errs
+}
+
+func sectionLabel(s Section) string {
+	if s.Name != "" {
+		return fmt.Sprintf("%s section %q", s.Kind(), s.Name)
+	}
+	return s.Kind() + " section"
+}
+
+// platformFeatures lists the features each DuckDB wasm platform is built with.
+var platformFeatures = map[string]Features{
+	"wasm_mvp":     {},
+	"wasm_eh":      {Exceptions: true},
+	"wasm_threads": {Exceptions: true, Threads: true, SIMD: true},
+}
+
+// CheckPlatform reports the features f uses that the DuckDB wasm platform is
+// not built with, such as wasm exceptions in a wasm_mvp extension.
+func (f Features) CheckPlatform(platform string) []error {
+	allowed, ok := platformFeatures[platform]
+	if !ok {
+		return []error{fmt.Errorf("invalid wasm platform %q (must be wasm_mvp|wasm_eh|wasm_threads)", platform)}
+	}
+	var errs []error
+	for _, feature := range []struct {
+		name          string
+		used, allowed bool
+	}{
+		{name: "wasm exceptions", used: f.Exceptions, allowed: allowed.Exceptions},
+		{name: "threads", used: f.Threads, allowed: allowed.Threads},
+		{name: "SIMD", used: f.SIMD, allowed: allowed.SIMD},
+	} {
+		if feature.used && !feature.allowed {
+			errs = append(errs, fmt.Errorf("%s build uses %s", platform, feature.name))
+		}
+	}
+	return errs
+}
+
+// String lists the used features, or "none".
+func (f Features) String() string {
+	var used []string
+	if f.Exceptions {
+		used = append(used, "exceptions")
+	}
+	if f.Threads {
+		used = append(used, "threads")
+	}
+	if f.SIMD {
+		used = append(used, "simd")
+	}
+	if len(used) == 0 {
+		return "none"
+	}
+	return strings.Join(used, ", ")
+}
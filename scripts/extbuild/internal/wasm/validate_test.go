@@ -0,0 +1,160 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/duckdb/extension-ci-tools/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withFooter appends a metadata footer for platform to module.
+func withFooter(t *testing.T, module []byte, platform string) []byte {
+	t.Helper()
+	footer, err := metadata.Metadata{
+		ABIType:          metadata.ABITypeCStruct,
+		ExtensionVersion: "v0.1.0",
+		DuckDBVersion:    "v1.2.0",
+		DuckDBPlatform:   platform,
+	}.Footer()
+	require.NoError(t, err)
+	return append(module, footer...)
+}
+
+// moduleWithCode returns a side module with a single function whose body
+// holds the given instructions.
+func moduleWithCode(instructions ...byte) []byte {
+	body := append([]byte{0x00}, instructions...)
+	body = append(body, opcodeEnd)
+	return module(
+		section(SectionCustom, name(dylinkSection)),
+		section(SectionType, []byte{0x01, 0x60, 0x00, 0x00}),
+		section(SectionFunction, []byte{0x01, 0x00}),
+		section(SectionCode, leb128(1), leb128(uint32(len(body))), body),
+	)
+}
+
+func TestParseFeatures(t *testing.T) {
+	t.Parallel()
+
+	v128Const := append([]byte{opcodePrefixSIMD, 0x0c}, make([]byte, 16)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want Features
+	}{
+		{name: "mvp", data: moduleWithCode(0x41, 0x7f, 0x1a), want: Features{}},
+		{name: "legacy try", data: moduleWithCode(0x06, blockTypeEmpty, opcodeEnd), want: Features{Exceptions: true}},
+		{name: "try_table", data: moduleWithCode(0x1f, blockTypeEmpty, 0x01, 0x02, 0x00, opcodeEnd), want: Features{Exceptions: true}},
+		{name: "imported tag", data: sideModuleWithImport(name("env"), name("__cpp_exception"), []byte{0x04, 0x00, 0x00}), want: Features{Exceptions: true}},
+		{name: "shared memory", data: sideModuleWithImport(name("env"), name("memory"), []byte{0x02, 0x03, 0x01, 0x10}), want: Features{Threads: true}},
+		{name: "atomic load", data: moduleWithCode(0x41, 0x00, opcodePrefixAtomic, 0x10, 0x02, 0x00, 0x1a), want: Features{Threads: true}},
+		{name: "v128 const", data: moduleWithCode(append(v128Const, 0x1a)...), want: Features{SIMD: true}},
+		{name: "v128 param", data: module(section(SectionType, []byte{0x01, 0x60, 0x01, valTypeV128, 0x00})), want: Features{SIMD: true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := Parse(tc.data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, m.Features)
+		})
+	}
+}
+
+func TestParseRejectsUnknownOpcodes(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse(moduleWithCode(0xff))
+	require.ErrorContains(t, err, "code section at offset 29: function body 0: unsupported opcode 0xff")
+}
+
+func sideModuleWithImport(desc ...[]byte) []byte {
+	payload := append([][]byte{leb128(1)}, desc...)
+	return module(
+		section(SectionCustom, name(dylinkSection)),
+		section(SectionImport, payload...),
+	)
+}
+
+func TestValidateExtension(t *testing.T) {
+	t.Parallel()
+
+	valid := withFooter(t, sideModule(), "wasm_eh")
+	m, err := Parse(valid)
+	require.NoError(t, err)
+	assert.Empty(t, m.ValidateExtension())
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr []string
+	}{
+		{
+			name:    "no footer",
+			data:    sideModule(),
+			wantErr: []string{"last section must be the duckdb_signature custom section, got code section"},
+		},
+		{
+			name:    "footer with a short payload",
+			data:    append(sideModule(), section(SectionCustom, name("duckdb_signature"), make([]byte, 500))...),
+			wantErr: []string{"duckdb_signature custom section is 520 bytes long (must be 534)"},
+		},
+		{
+			name: "sections out of order",
+			data: withFooter(t, module(
+				section(SectionCustom, name(dylinkSection)),
+				section(SectionExport, leb128(0)),
+				section(SectionType, leb128(0)),
+				section(SectionType, leb128(0)),
+			), "wasm_eh"),
+			wantErr: []string{
+				"type section at offset 22 is out of order",
+				"type section at offset 25 is out of order",
+			},
+		},
+		{
+			name: "not a side module",
+			data: withFooter(t, module(section(SectionType, leb128(0)), section(SectionType, leb128(0))), "wasm_eh"),
+			wantErr: []string{
+				"first section must be the dylink.0 custom section of a side module",
+				"type section at offset 11 appears more than once",
+			},
+		},
+		{
+			name:    "footer appended twice",
+			data:    withFooter(t, valid, "wasm_eh"),
+			wantErr: []string{"duckdb_signature custom section appears 2 times"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := Parse(tc.data)
+			require.NoError(t, err)
+			var got []string
+			for _, err := range m.ValidateExtension() {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, tc.wantErr, got)
+		})
+	}
+}
+
+func TestCheckPlatform(t *testing.T) {
+	t.Parallel()
+
+	eh := Features{Exceptions: true}
+	assert.Empty(t, eh.CheckPlatform("wasm_eh"))
+	assert.Empty(t, Features{Exceptions: true, Threads: true, SIMD: true}.CheckPlatform("wasm_threads"))
+	assert.EqualError(t, eh.CheckPlatform("wasm_mvp")[0], "wasm_mvp build uses wasm exceptions")
+	assert.EqualError(t, Features{Threads: true}.CheckPlatform("wasm_eh")[0], "wasm_eh build uses threads")
+	assert.EqualError(t, eh.CheckPlatform("linux_amd64")[0], `invalid wasm platform "linux_amd64" (must be wasm_mvp|wasm_eh|wasm_threads)`)
+	assert.Equal(t, "exceptions", eh.String())
+	assert.Equal(t, "none", Features{}.String())
+}
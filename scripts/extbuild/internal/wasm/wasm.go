@@ -0,0 +1,299 @@
+// Package wasm decodes the section layout, imports and exports of
+// WebAssembly modules such as .duckdb_extension.wasm side modules.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNotWasm is returned for data that does not start with the wasm magic.
+var ErrNotWasm = errors.New("not a WebAssembly module")
+
+var (
+	magic   = []byte{0x00, 'a', 's', 'm'}
+	version = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// Section ids defined by the core specification and its proposals.
+const (
+	SectionCustom    byte = 0
+	SectionType      byte = 1
+	SectionImport    byte = 2
+	SectionFunction  byte = 3
+	SectionTable     byte = 4
+	SectionMemory    byte = 5
+	SectionGlobal    byte = 6
+	SectionExport    byte = 7
+	SectionStart     byte = 8
+	SectionElement   byte = 9
+	SectionCode      byte = 10
+	SectionData      byte = 11
+	SectionDataCount byte = 12
+	SectionTag       byte = 13
+)
+
+var sectionNames = map[byte]string{
+	SectionCustom:    "custom",
+	SectionType:      "type",
+	SectionImport:    "import",
+	SectionFunction:  "function",
+	SectionTable:     "table",
+	SectionMemory:    "memory",
+	SectionGlobal:    "global",
+	SectionExport:    "export",
+	SectionStart:     "start",
+	SectionElement:   "element",
+	SectionCode:      "code",
+	SectionData:      "data",
+	SectionDataCount: "datacount",
+	SectionTag:       "tag",
+}
+
+// External kinds of imports and exports.
+const (
+	KindFunc   = "func"
+	KindTable  = "table"
+	KindMemory = "memory"
+	KindGlobal = "global"
+	KindTag    = "tag"
+)
+
+var kindNames = []string{KindFunc, KindTable, KindMemory, KindGlobal, KindTag}
+
+// Section is one section of a module. Offset points at the section id and
+// Size is the length of the payload that follows the encoded size.
+type Section struct {
+	ID     byte   `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Offset int64  `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+// Kind returns the section name, such as "code" or "custom".
+func (s Section) Kind() string {
+	if name, ok := sectionNames[s.ID]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", s.ID)
+}
+
+// Import is an entry of the import section.
+type Import struct {
+	Module string `json:"module"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+}
+
+// Export is an entry of the export section.
+type Export struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Module is the decoded structure of a WebAssembly binary of Size bytes.
+type Module struct {
+	Size     int64     `json:"size"`
+	Sections []Section `json:"sections"`
+	Imports  []Import  `json:"imports"`
+	Exports  []Export  `json:"exports"`
+	Features Features  `json:"features"`
+}
+
+// ParseFile parses the WebAssembly module at path.
+func ParseFile(path string) (Module, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Module{}, err
+	}
+	return Parse(data)
+}
+
+// Parse parses the sections of a WebAssembly module, decodes its import and
+// export sections and scans its types, memories and code for the features it
+// uses.
+func Parse(data []byte) (Module, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], magic) {
+		return Module{}, ErrNotWasm
+	}
+	if !bytes.Equal(data[4:8], version) {
+		return Module{}, fmt.Errorf("unsupported wasm version % x", data[4:8])
+	}
+
+	m := Module{Size: int64(len(data))}
+	r := &reader{data: data, pos: 8}
+	for r.pos < len(data) {
+		offset := r.pos
+		id := r.byte()
+		size := r.u32()
+		if r.err != nil {
+			return Module{}, fmt.Errorf("section at offset %d: %w", offset, r.err)
+		}
+		if int(size) > len(data)-r.pos {
+			return Module{}, fmt.Errorf("section at offset %d: size %d exceeds the remaining %d bytes", offset, size, len(data)-r.pos)
+		}
+
+		payload := &reader{data: data[r.pos : r.pos+int(size)]}
+		section := Section{ID: id, Offset: int64(offset), Size: size}
+		switch id {
+		case SectionCustom:
+			section.Name = payload.name()
+		case SectionType:
+			payload.types(&m.Features)
+		case SectionImport:
+			m.Imports = payload.imports(&m.Features)
+		case SectionMemory:
+			payload.memories(&m.Features)
+		case SectionTag:
+			m.Features.Exceptions = true
+		case SectionGlobal:
+			payload.globals(&m.Features)
+		case SectionExport:
+			m.Exports = payload.exports(&m.Features)
+		case SectionCode:
+			payload.code(&m.Features)
+		}
+		if payload.err != nil {
+			return Module{}, fmt.Errorf("%s section at offset %d: %w", section.Kind(), offset, payload.err)
+		}
+		m.Sections = append(m.Sections, section)
+		r.pos += int(size)
+	}
+	return m, nil
+}
+
+// reader decodes the primitive encodings of the binary format. The first
+// error sticks and makes every later read return zero values.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail(fmt.Errorf("unexpected end of data at offset %d", r.pos))
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+// u64 decodes an unsigned LEB128 number of at most 64 bits.
+func (r *reader) u64() uint64 {
+	var value uint64
+	for shift := 0; shift < 64; shift += 7 {
+		b := r.byte()
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+	}
+	r.fail(fmt.Errorf("LEB128 number at offset %d is too long", r.pos))
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	value := r.u64()
+	if value > 1<<32-1 {
+		r.fail(fmt.Errorf("LEB128 number %d at offset %d does not fit 32 bits", value, r.pos))
+		return 0
+	}
+	return uint32(value)
+}
+
+func (r *reader) bytes(n uint32) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if int(n) > len(r.data)-r.pos {
+		r.fail(fmt.Errorf("%d bytes at offset %d exceed the remaining %d bytes", n, r.pos, len(r.data)-r.pos))
+		return nil
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+func (r *reader) name() string {
+	return string(r.bytes(r.u32()))
+}
+
+func (r *reader) kind() string {
+	k := r.byte()
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	r.fail(fmt.Errorf("unknown external kind %d", k))
+	return ""
+}
+
+// limits skips the limits of a table or memory and returns their flags.
+func (r *reader) limits() byte {
+	flags := r.byte()
+	r.u64()
+	if flags&limitsHasMax != 0 {
+		r.u64()
+	}
+	return flags
+}
+
+func (r *reader) imports(f *Features) []Import {
+	count := r.u32()
+	var imports []Import
+	for range count {
+		if r.err != nil {
+			return nil
+		}
+		imp := Import{Module: r.name(), Name: r.name(), Kind: r.kind()}
+		switch imp.Kind {
+		case KindFunc:
+			r.u32()
+		case KindTable:
+			r.byte()
+			r.limits()
+		case KindMemory:
+			if r.limits()&limitsShared != 0 {
+				f.Threads = true
+			}
+		case KindGlobal:
+			r.byte()
+			r.byte()
+		case KindTag:
+			f.Exceptions = true
+			r.byte()
+			r.u32()
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
+
+func (r *reader) exports(f *Features) []Export {
+	count := r.u32()
+	var exports []Export
+	for range count {
+		if r.err != nil {
+			return nil
+		}
+		exp := Export{Name: r.name(), Kind: r.kind()}
+		if exp.Kind == KindTag {
+			f.Exceptions = true
+		}
+		r.u32()
+		exports = append(exports, exp)
+	}
+	return exports
+}
@@ -0,0 +1,132 @@
+package wasm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leb128 encodes v as an unsigned LEB128 number.
+func leb128(v uint32) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func name(s string) []byte {
+	return append(leb128(uint32(len(s))), s...)
+}
+
+func section(id byte, payload ...[]byte) []byte {
+	var body []byte
+	for _, p := range payload {
+		body = append(body, p...)
+	}
+	return append(append([]byte{id}, leb128(uint32(len(body)))...), body...)
+}
+
+func module(sections ...[]byte) []byte {
+	b := append([]byte{}, magic...)
+	b = append(b, version...)
+	for _, s := range sections {
+		b = append(b, s...)
+	}
+	return b
+}
+
+// sideModule returns a module shaped like an emscripten side module: a
+// dylink.0 section, imported memory and functions, and exported init
+// functions.
+func sideModule() []byte {
+	return module(
+		section(SectionCustom, name("dylink.0"), []byte{0x01, 0x04, 0x00, 0x00, 0x00, 0x00}),
+		section(SectionType, []byte{0x01, 0x60, 0x00, 0x00}),
+		section(SectionImport, leb128(2),
+			name("env"), name("memory"), []byte{0x02, 0x00, 0x01},
+			name("env"), name("duckdb_open"), []byte{0x00, 0x00},
+		),
+		section(SectionFunction, []byte{0x01, 0x00}),
+		section(SectionExport, leb128(2),
+			name("quack_init_c_api"), []byte{0x00, 0x01},
+			name("__wasm_apply_data_relocs"), []byte{0x00, 0x01},
+		),
+		section(SectionCode, []byte{0x01, 0x02, 0x00, 0x0b}),
+	)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	m, err := Parse(sideModule())
+	require.NoError(t, err)
+
+	kinds := make([]string, 0, len(m.Sections))
+	for _, s := range m.Sections {
+		kinds = append(kinds, s.Kind())
+	}
+	assert.Equal(t, []string{"custom", "type", "import", "function", "export", "code"}, kinds)
+	assert.Equal(t, Section{ID: SectionCustom, Name: "dylink.0", Offset: 8, Size: 15}, m.Sections[0])
+	assert.Equal(t, []Import{
+		{Module: "env", Name: "memory", Kind: KindMemory},
+		{Module: "env", Name: "duckdb_open", Kind: KindFunc},
+	}, m.Imports)
+	assert.Equal(t, []Export{
+		{Name: "quack_init_c_api", Kind: KindFunc},
+		{Name: "__wasm_apply_data_relocs", Kind: KindFunc},
+	}, m.Exports)
+}
+
+func TestParseFileWithMetadataFooter(t *testing.T) {
+	t.Parallel()
+
+	m, err := ParseFile(filepath.Join("..", "..", "testdata", "metadata", "quack.duckdb_extension.wasm.golden"))
+	require.NoError(t, err)
+	require.Len(t, m.Sections, 1)
+	assert.Equal(t, Section{ID: SectionCustom, Name: "duckdb_signature", Offset: 8, Size: 531}, m.Sections[0])
+}
+
+func TestParseRejectsInvalidModules(t *testing.T) {
+	t.Parallel()
+
+	truncated := sideModule()
+	truncated = truncated[:len(truncated)-2]
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "not wasm", data: []byte("\x7fELF\x02\x01\x01\x00"), wantErr: ErrNotWasm.Error()},
+		{name: "wrong version", data: []byte("\x00asm\x02\x00\x00\x00"), wantErr: "unsupported wasm version 02 00 00 00"},
+		{name: "truncated section", data: truncated, wantErr: "section at offset 119: size 4 exceeds the remaining 2 bytes"},
+		{name: "unknown export kind", data: module(section(SectionExport, leb128(1), name("x"), []byte{0x09, 0x00})), wantErr: "export section at offset 8: unknown external kind 9"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(tc.data)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestParseFileMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseFile(filepath.Join(t.TempDir(), "missing.wasm"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
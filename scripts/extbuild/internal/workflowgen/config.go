@@ -0,0 +1,151 @@
+package workflowgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/duckdb/extension-ci-tools/internal/inputs"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultCIToolsRepository = "duckdb/extension-ci-tools"
+	DefaultWorkflowName      = "Main Extension Distribution Pipeline"
+)
+
+// ConfigFileNames lists the config files looked up in an extension repository,
+// in order of preference.
+var ConfigFileNames = []string{"extbuild.toml", "extbuild.yaml", "extbuild.yml"}
+
+// Config describes the caller workflow of an extension repository. Fields
+// without a dedicated entry can be passed to _extension_distribution.yml
+// through Inputs.
+type Config struct {
+	ExtensionName     string         `toml:"extension_name" yaml:"extension_name"`
+	DuckDBVersion     string         `toml:"duckdb_version" yaml:"duckdb_version"`
+	CIToolsVersion    string         `toml:"ci_tools_version" yaml:"ci_tools_version"`
+	CIToolsRepository string         `toml:"ci_tools_repository" yaml:"ci_tools_repository"`
+	WorkflowName      string         `toml:"workflow_name" yaml:"workflow_name"`
+	ExcludeArchs      []string       `toml:"exclude_archs" yaml:"exclude_archs"`
+	OptInArchs        []string       `toml:"opt_in_archs" yaml:"opt_in_archs"`
+	ExtraToolchains   []string       `toml:"extra_toolchains" yaml:"extra_toolchains"`
+	VCPKGCommit       string         `toml:"vcpkg_commit" yaml:"vcpkg_commit"`
+	Inputs            map[string]any `toml:"inputs" yaml:"inputs"`
+	Deploy            DeployConfig   `toml:"deploy" yaml:"deploy"`
+}
+
+// DeployConfig controls the optional job calling _extension_deploy.yml.
+// DeployLatest and DeployVersioned are GitHub expressions or booleans.
+type DeployConfig struct {
+	Enabled         bool   `toml:"enabled" yaml:"enabled"`
+	DeployLatest    string `toml:"deploy_latest" yaml:"deploy_latest"`
+	DeployVersioned string `toml:"deploy_versioned" yaml:"deploy_versioned"`
+}
+
+// dedicatedInputs have their own Config field and may not be repeated in
+// Config.Inputs.
+var dedicatedInputs = []string{
+	"extension_name",
+	"duckdb_version",
+	"ci_tools_version",
+	"override_ci_tools_repository",
+	"exclude_archs",
+	"opt_in_archs",
+	"extra_toolchains",
+	"vcpkg_commit",
+}
+
+// ParseConfigFile decodes a TOML or YAML config, picking the format from the
+// file extension of name. Unknown fields are rejected.
+func ParseConfigFile(name string, data []byte) (Config, error) {
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml":
+		meta, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, err
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported config file extension: %q (must be .toml, .yaml or .yml)", filepath.Ext(name))
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.CIToolsVersion == "" {
+		c.CIToolsVersion = c.DuckDBVersion
+	}
+	if c.CIToolsRepository == "" {
+		c.CIToolsRepository = DefaultCIToolsRepository
+	}
+	if c.WorkflowName == "" {
+		c.WorkflowName = DefaultWorkflowName
+	}
+	if c.Deploy.Enabled {
+		if c.Deploy.DeployLatest == "" {
+			c.Deploy.DeployLatest = "${{ startsWith(github.ref, 'refs/tags/v') || github.ref == 'refs/heads/main' }}"
+		}
+		if c.Deploy.DeployVersioned == "" {
+			c.Deploy.DeployVersioned = "${{ startsWith(github.ref, 'refs/tags/v') }}"
+		}
+	}
+}
+
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.ExtensionName) == "" {
+		return errors.New("extension_name is required")
+	}
+	if strings.TrimSpace(c.DuckDBVersion) == "" {
+		return errors.New("duckdb_version is required")
+	}
+
+	for key, value := range c.Inputs {
+		def, ok := inputs.DistributionInputs[key]
+		if !ok {
+			return fmt.Errorf("inputs: unknown _extension_distribution.yml input %q", key)
+		}
+		if slices.Contains(dedicatedInputs, key) {
+			return fmt.Errorf("inputs: %q must be set through the top-level %s field", key, dedicatedConfigField(key))
+		}
+		switch value.(type) {
+		case string:
+			if def.Kind != inputs.KindString {
+				return fmt.Errorf("inputs: %q must be a %s", key, def.Kind)
+			}
+		case bool:
+			if def.Kind != inputs.KindBoolean {
+				return fmt.Errorf("inputs: %q must be a %s", key, def.Kind)
+			}
+		default:
+			return fmt.Errorf("inputs: %q has unsupported value type %T (must be a %s)", key, value, def.Kind)
+		}
+	}
+	return nil
+}
+
+func dedicatedConfigField(input string) string {
+	if input == "override_ci_tools_repository" {
+		return "ci_tools_repository"
+	}
+	return input
+}
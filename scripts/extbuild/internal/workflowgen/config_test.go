@@ -0,0 +1,124 @@
+package workflowgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigFileTOMLAndYAMLAgree(t *testing.T) {
+	t.Parallel()
+
+	tomlCfg := mustParseTestdataConfig(t, "extbuild.toml")
+	yamlCfg := mustParseTestdataConfig(t, "extbuild.yaml")
+	assert.Equal(t, tomlCfg, yamlCfg)
+
+	assert.Equal(t, "quack", tomlCfg.ExtensionName)
+	assert.Equal(t, []string{"windows_amd64_mingw", "wasm_mvp"}, tomlCfg.ExcludeArchs)
+	assert.Equal(t, DefaultCIToolsRepository, tomlCfg.CIToolsRepository)
+	assert.True(t, tomlCfg.Deploy.Enabled)
+	assert.NotEmpty(t, tomlCfg.Deploy.DeployLatest)
+}
+
+func TestParseConfigFileDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := ParseConfigFile("extbuild.toml", []byte(`
+extension_name = "quack"
+duckdb_version = "v1.5.4"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.5.4", cfg.CIToolsVersion)
+	assert.Equal(t, DefaultWorkflowName, cfg.WorkflowName)
+	assert.False(t, cfg.Deploy.Enabled)
+	assert.Empty(t, cfg.Deploy.DeployLatest)
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown toml field",
+			file:    "extbuild.toml",
+			content: "extension_name = \"quack\"\nduckdb_version = \"v1.5.4\"\nexclude_arch = [\"wasm_mvp\"]\n",
+			wantErr: `unknown field "exclude_arch"`,
+		},
+		{
+			name:    "unknown yaml field",
+			file:    "extbuild.yaml",
+			content: "extension_name: quack\nduckdb_version: v1.5.4\nexclude_arch: [wasm_mvp]\n",
+			wantErr: "field exclude_arch not found",
+		},
+		{
+			name:    "missing extension name",
+			file:    "extbuild.toml",
+			content: "duckdb_version = \"v1.5.4\"\n",
+			wantErr: "extension_name is required",
+		},
+		{
+			name:    "missing duckdb version",
+			file:    "extbuild.yml",
+			content: "extension_name: quack\n",
+			wantErr: "duckdb_version is required",
+		},
+		{
+			name:    "unknown passthrough input",
+			file:    "extbuild.toml",
+			content: "extension_name = \"quack\"\nduckdb_version = \"v1.5.4\"\n[inputs]\nbuild_typ = \"debug\"\n",
+			wantErr: `unknown _extension_distribution.yml input "build_typ"`,
+		},
+		{
+			name:    "dedicated input in passthrough",
+			file:    "extbuild.toml",
+			content: "extension_name = \"quack\"\nduckdb_version = \"v1.5.4\"\n[inputs]\nextra_toolchains = \"rust\"\n",
+			wantErr: "top-level extra_toolchains field",
+		},
+		{
+			name:    "wrong input type",
+			file:    "extbuild.toml",
+			content: "extension_name = \"quack\"\nduckdb_version = \"v1.5.4\"\n[inputs]\nskip_tests = \"yes\"\n",
+			wantErr: `"skip_tests" must be a boolean`,
+		},
+		{
+			name:    "unsupported input value",
+			file:    "extbuild.toml",
+			content: "extension_name = \"quack\"\nduckdb_version = \"v1.5.4\"\n[inputs]\ncuda_version = 13\n",
+			wantErr: "unsupported value type int64",
+		},
+		{
+			name:    "unsupported extension",
+			file:    "extbuild.json",
+			content: "{}",
+			wantErr: "unsupported config file extension",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseConfigFile(tc.file, []byte(tc.content))
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func mustParseTestdataConfig(t *testing.T, name string) Config {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "workflow", name))
+	require.NoError(t, err)
+	cfg, err := ParseConfigFile(name, data)
+	require.NoError(t, err)
+	return cfg
+}
@@ -0,0 +1,196 @@
+package workflowgen
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	buildJobID  = "duckdb-stable-build"
+	deployJobID = "duckdb-stable-deploy"
+)
+
+const concurrencyGroup = "${{ github.workflow }}-${{ github.ref }}-${{ github.head_ref || '' }}-${{ github.base_ref || '' }}-${{ github.ref != 'refs/heads/main' || github.sha }}"
+
+type input struct {
+	name  string
+	value any
+}
+
+// Render produces the caller workflow for cfg. configName is only used in the
+// generated header to point readers at the file to edit.
+func Render(cfg Config, configName string) string {
+	var b strings.Builder
+	b.WriteString("#\n")
+	fmt.Fprintf(&b, "# This file is generated by extbuild from %s, do not edit it by hand.\n", configName)
+	fmt.Fprintf(&b, "# Regenerate it with: extbuild workflow generate --config %s\n", configName)
+	b.WriteString("#\n")
+	b.WriteString("# This workflow calls the main distribution pipeline from DuckDB to build, test and (optionally) release the extension\n")
+	b.WriteString("#\n")
+	fmt.Fprintf(&b, "name: %s\n", yamlScalar(cfg.WorkflowName))
+	b.WriteString("on:\n")
+	b.WriteString("  push:\n")
+	b.WriteString("  pull_request:\n")
+	b.WriteString("  workflow_dispatch:\n")
+	b.WriteString("\n")
+	b.WriteString("concurrency:\n")
+	fmt.Fprintf(&b, "  group: %s\n", yamlScalar(concurrencyGroup))
+	b.WriteString("  cancel-in-progress: true\n")
+	b.WriteString("\n")
+	b.WriteString("jobs:\n")
+
+	writeJob(&b, buildJobID, "Build extension binaries", "", cfg.reusableWorkflow("_extension_distribution.yml"), false, distributionWith(cfg))
+	if cfg.Deploy.Enabled {
+		b.WriteString("\n")
+		writeJob(&b, deployJobID, "Deploy extension binaries", buildJobID, cfg.reusableWorkflow("_extension_deploy.yml"), true, deployWith(cfg))
+	}
+	return b.String()
+}
+
+func (c Config) reusableWorkflow(file string) string {
+	return c.CIToolsRepository + "/.github/workflows/" + file + "@" + c.CIToolsVersion
+}
+
+func writeJob(b *strings.Builder, id, name, needs, uses string, inheritSecrets bool, with []input) {
+	fmt.Fprintf(b, "  %s:\n", id)
+	fmt.Fprintf(b, "    name: %s\n", yamlScalar(name))
+	if needs != "" {
+		fmt.Fprintf(b, "    needs: %s\n", needs)
+	}
+	fmt.Fprintf(b, "    uses: %s\n", yamlScalar(uses))
+	if inheritSecrets {
+		b.WriteString("    secrets: inherit\n")
+	}
+	b.WriteString("    with:\n")
+	for _, in := range with {
+		writeInput(b, in)
+	}
+}
+
+func writeInput(b *strings.Builder, in input) {
+	if s, ok := in.value.(string); ok && strings.Contains(s, "\n") {
+		fmt.Fprintf(b, "      %s: |\n", in.name)
+		for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(b, "        %s\n", line)
+		}
+		return
+	}
+	fmt.Fprintf(b, "      %s: %s\n", in.name, yamlScalar(in.value))
+}
+
+func distributionWith(cfg Config) []input {
+	with := commonWith(cfg)
+	if len(cfg.ExtraToolchains) > 0 {
+		with = append(with, input{"extra_toolchains", strings.Join(cfg.ExtraToolchains, ";")})
+	}
+	if cfg.VCPKGCommit != "" {
+		with = append(with, input{"vcpkg_commit", cfg.VCPKGCommit})
+	}
+
+	names := make([]string, 0, len(cfg.Inputs))
+	for name := range cfg.Inputs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		with = append(with, input{name, cfg.Inputs[name]})
+	}
+	return with
+}
+
+func deployWith(cfg Config) []input {
+	with := commonWith(cfg)
+	with = append(with,
+		input{"deploy_latest", deployFlag(cfg.Deploy.DeployLatest)},
+		input{"deploy_versioned", deployFlag(cfg.Deploy.DeployVersioned)},
+	)
+	return with
+}
+
+// commonWith returns the inputs shared by the build and deploy jobs, which
+// must agree on versions and the set of architectures.
+func commonWith(cfg Config) []input {
+	with := []input{
+		{"duckdb_version", cfg.DuckDBVersion},
+		{"ci_tools_version", cfg.CIToolsVersion},
+		{"extension_name", cfg.ExtensionName},
+	}
+	if cfg.CIToolsRepository != DefaultCIToolsRepository {
+		with = append(with, input{"override_ci_tools_repository", cfg.CIToolsRepository})
+	}
+	if len(cfg.ExcludeArchs) > 0 {
+		with = append(with, input{"exclude_archs", strings.Join(cfg.ExcludeArchs, ";")})
+	}
+	if len(cfg.OptInArchs) > 0 {
+		with = append(with, input{"opt_in_archs", strings.Join(cfg.OptInArchs, ";")})
+	}
+	return with
+}
+
+func deployFlag(raw string) any {
+	if value, err := strconv.ParseBool(raw); err == nil {
+		return value
+	}
+	return raw
+}
+
+var (
+	plainScalarPattern = regexp.MustCompile(`^[A-Za-z0-9_](?:[A-Za-z0-9_./@+ -]*[A-Za-z0-9_./@+-])?$`)
+	expressionPattern  = regexp.MustCompile(`^\$\{\{.*\}\}$`)
+	yamlReservedWords  = []string{"true", "false", "yes", "no", "on", "off", "null", "~"}
+)
+
+// yamlScalar renders a single-line YAML scalar. Strings that YAML would read
+// as another type, or that contain indicator characters, are single quoted.
+// GitHub expressions are left unquoted so boolean results keep their type.
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		if expressionPattern.MatchString(v) && !strings.Contains(v, ": ") && !strings.Contains(v, " #") {
+			return v
+		}
+		if plainScalarPattern.MatchString(v) && !slices.Contains(yamlReservedWords, strings.ToLower(v)) && !looksNumeric(v) {
+			return v
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	default:
+		return yamlScalar(fmt.Sprint(v))
+	}
+}
+
+func looksNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// Diff compares a committed workflow with the generated one and describes the
+// first differing line, or returns an empty string when both are identical.
+func Diff(committed, generated string) string {
+	if committed == generated {
+		return ""
+	}
+	committedLines := strings.Split(committed, "\n")
+	generatedLines := strings.Split(generated, "\n")
+	for i := 0; i < max(len(committedLines), len(generatedLines)); i++ {
+		var got, want string
+		if i < len(committedLines) {
+			got = committedLines[i]
+		}
+		if i < len(generatedLines) {
+			want = generatedLines[i]
+		}
+		if got != want {
+			return fmt.Sprintf("line %d: committed %q, generated %q", i+1, got, want)
+		}
+	}
+	return "files differ"
+}
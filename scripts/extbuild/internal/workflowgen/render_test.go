@@ -0,0 +1,82 @@
+package workflowgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderMatchesGoldenWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cfg := mustParseTestdataConfig(t, "extbuild.toml")
+	golden, err := os.ReadFile(filepath.Join("..", "..", "testdata", "workflow", "MainDistributionPipeline.yml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, string(golden), Render(cfg, "extbuild.toml"))
+}
+
+func TestRenderProducesValidCallerWorkflow(t *testing.T) {
+	t.Parallel()
+
+	cfg := mustParseTestdataConfig(t, "extbuild.toml")
+	cfg.CIToolsRepository = "my-org/extension-ci-tools"
+	cfg.Inputs["post_build_command"] = "echo one\necho 'two'\n"
+
+	var workflow struct {
+		Name string `yaml:"name"`
+		Jobs map[string]struct {
+			Needs string         `yaml:"needs"`
+			Uses  string         `yaml:"uses"`
+			With  map[string]any `yaml:"with"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(Render(cfg, "extbuild.toml")), &workflow))
+
+	build := workflow.Jobs[buildJobID]
+	assert.Equal(t, "my-org/extension-ci-tools/.github/workflows/_extension_distribution.yml@v1.5.4", build.Uses)
+	assert.Equal(t, "v1.5.4", build.With["ci_tools_version"])
+	assert.Equal(t, "my-org/extension-ci-tools", build.With["override_ci_tools_repository"])
+	assert.Equal(t, "rust;python3", build.With["extra_toolchains"])
+	assert.Equal(t, false, build.With["save_cache"])
+	assert.Equal(t, "echo one\necho 'two'\n", build.With["post_build_command"])
+
+	deploy := workflow.Jobs[deployJobID]
+	assert.Equal(t, buildJobID, deploy.Needs)
+	assert.Equal(t, build.With["exclude_archs"], deploy.With["exclude_archs"])
+}
+
+func TestYAMLScalar(t *testing.T) {
+	t.Parallel()
+
+	tests := map[any]string{
+		true:                 "true",
+		"v1.5.4":             "v1.5.4",
+		"quack":              "quack",
+		"Build binaries":     "Build binaries",
+		"":                   "''",
+		"true":               "'true'",
+		"On":                 "'On'",
+		"13":                 "'13'",
+		"a;b":                "'a;b'",
+		"{}":                 "'{}'",
+		"it's":               "'it''s'",
+		"${{ github.sha }}":  "${{ github.sha }}",
+		"${{ a }}: ${{ b }}": "'${{ a }}: ${{ b }}'",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, yamlScalar(input), "input %#v", input)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Diff("a\nb\n", "a\nb\n"))
+	assert.Equal(t, `line 2: committed "b", generated "c"`, Diff("a\nb\n", "a\nc\n"))
+	assert.Equal(t, `line 3: committed "", generated "d"`, Diff("a\nb\n", "a\nb\nd"))
+}
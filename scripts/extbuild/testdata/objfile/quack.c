@@ -0,0 +1,9 @@
+/* Source of libquack_linux_amd64.so, a stand-in for a C API extension:
+ *
+ *   gcc -shared -fPIC -Os -s -fvisibility=hidden -o libquack_linux_amd64.so quack.c
+ */
+#include <stdio.h>
+
+__attribute__((visibility("default"))) int quack_init_c_api(void *info, void *access) {
+	return printf("quack %p %p\n", info, access) > 0;
+}
@@ -0,0 +1,11 @@
+/* Source of libquack_cpp_linux_amd64.so, a stand-in for a C++ extension:
+ *
+ *   g++ -shared -fPIC -Os -s -fvisibility=hidden -o libquack_cpp_linux_amd64.so quack_cpp.cpp
+ */
+#include <iostream>
+#include <string>
+
+extern "C" __attribute__((visibility("default"))) void quack_duckdb_cpp_init(void *db) {
+	std::string message = "quack";
+	std::cout << message << ' ' << db << std::endl;
+}
@@ -0,0 +1,22 @@
+/* Source of libquack_leaky_linux_amd64.so, a C API extension that statically
+ * exports DuckDB symbols and links libduckdb dynamically:
+ *
+ *   echo 'void duckdb_stub(void) {}' | gcc -shared -fPIC -o /tmp/libduckdb.so -x c -
+ *   g++ -shared -fPIC -Os -s -o libquack_leaky_linux_amd64.so quack_leaky.cpp -L/tmp -lduckdb
+ */
+extern "C" void duckdb_stub(void);
+
+namespace duckdb {
+int Leaked(int x) {
+	return x + 1;
+}
+} // namespace duckdb
+
+extern "C" int duckdb_open(const char *path, void *out) {
+	return duckdb::Leaked(path != nullptr) + (out != nullptr);
+}
+
+extern "C" int quack_init_c_api(void *info, void *access) {
+	duckdb_stub();
+	return duckdb_open(nullptr, access) + (info != nullptr);
+}